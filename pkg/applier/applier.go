@@ -28,6 +28,9 @@ type Target struct {
 	DB       *sql.DB
 	Config   *mysql.Config
 	KeyRange string // Vitess-style key range: "-80", "80-", "80-c0", or "0" for unsharded
+	// ReplicaDB is an optional connection to a replica of the target.
+	// When set, move throttles the copier on its replication lag.
+	ReplicaDB *sql.DB
 }
 
 // ApplyCallback is invoked when rows have been safely flushed to the target(s).
@@ -96,6 +99,12 @@ type ApplierConfig struct {
 	ChunkletMaxSize int
 	Logger          *slog.Logger
 	DBConfig        *dbconn.DBConfig
+	// KeepUnmappedColumns makes UpsertRows of the SingleTargetApplier use
+	// INSERT ... ON DUPLICATE KEY UPDATE instead of REPLACE, so that columns
+	// of the target which are not in the column mapping keep their values.
+	// It is only safe if the target has no unique index other than the
+	// primary key: see UpsertRows.
+	KeepUnmappedColumns bool
 }
 
 // NewApplierDefaultConfig returns a default config for the applier.
@@ -20,9 +20,10 @@ import (
 type SingleTargetApplier struct {
 	sync.Mutex
 
-	target   Target
-	dbConfig *dbconn.DBConfig
-	logger   *slog.Logger
+	target              Target
+	dbConfig            *dbconn.DBConfig
+	logger              *slog.Logger
+	keepUnmappedColumns bool
 
 	// Internal chunklet processing
 	chunkletBuffer      chan chunklet
@@ -83,6 +84,7 @@ func NewSingleTargetApplier(target Target, cfg *ApplierConfig) (*SingleTargetApp
 		chunkletCompletions: make(chan chunkletCompletion, defaultBufferSize),
 		pendingWork:         make(map[int64]*pendingWork),
 		writeWorkersCount:   int32(cfg.Threads),
+		keepUnmappedColumns: cfg.KeepUnmappedColumns,
 	}, nil
 }
 
@@ -515,6 +517,14 @@ func (a *SingleTargetApplier) DeleteKeys(ctx context.Context, sourceTable, targe
 // We supply inline row images rather than `REPLACE INTO ... SELECT FROM
 // source`, so the read-after-commit race that motivated #746 does not
 // apply.
+//
+// With KeepUnmappedColumns, `INSERT ... ON DUPLICATE KEY UPDATE` of the
+// mapped columns is used instead, because REPLACE resets the columns which
+// are not in the mapping to their defaults. This is used to replicate
+// changes back to a table which has columns that were dropped. It loses
+// the order-independence described above, so a conflict on a unique index
+// other than the primary key updates (or fails on) the wrong row; callers
+// must only use it for targets without such indexes.
 func (a *SingleTargetApplier) UpsertRows(ctx context.Context, mapping *table.ColumnMapping, rows []LogicalRow, lock *dbconn.TableLock) (int64, error) {
 	if len(rows) == 0 {
 		return 0, nil
@@ -566,8 +576,23 @@ func (a *SingleTargetApplier) UpsertRows(ctx context.Context, mapping *table.Col
 		targetColumnList,
 		strings.Join(valuesClauses, ", "),
 	)
+	path := "replace-into"
+	if a.keepUnmappedColumns {
+		_, targetColumnNames := mapping.ColumnsSlice()
+		assignments := make([]string, 0, len(targetColumnNames))
+		for _, col := range targetColumnNames {
+			assignments = append(assignments, fmt.Sprintf("`%s` = new.`%s`", col, col))
+		}
+		upsertStmt = fmt.Sprintf("INSERT INTO %s (%s) VALUES %s AS new ON DUPLICATE KEY UPDATE %s",
+			mapping.TargetTable().QuotedTableName,
+			targetColumnList,
+			strings.Join(valuesClauses, ", "),
+			strings.Join(assignments, ", "),
+		)
+		path = "insert-on-duplicate-key-update"
+	}
 
-	a.logger.Debug("executing upsert", "rowCount", len(valuesClauses), "table", mapping.TargetTable().TableName, "path", "replace-into")
+	a.logger.Debug("executing upsert", "rowCount", len(valuesClauses), "table", mapping.TargetTable().TableName, "path", path)
 
 	// Execute under lock if provided
 	if lock != nil {
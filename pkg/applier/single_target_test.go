@@ -585,6 +585,50 @@ func TestSingleTargetApplierUpsertRows(t *testing.T) {
 }
 
 // TestSingleTargetApplierUpsertRowsSkipDeleted tests that deleted rows are skipped
+func TestSingleTargetApplierUpsertRowsKeepUnmappedColumns(t *testing.T) {
+	testutils.RunSQL(t, "DROP DATABASE IF EXISTS single_upsert_keep_test")
+	testutils.RunSQL(t, "CREATE DATABASE single_upsert_keep_test")
+
+	base, err := mysql.ParseDSN(testutils.DSN())
+	require.NoError(t, err)
+
+	target := base.Clone()
+	target.DBName = "single_upsert_keep_test"
+	targetDB, err := sql.Open("mysql", target.FormatDSN())
+	require.NoError(t, err)
+	defer utils.CloseAndLog(targetDB)
+
+	// The source does not have the column "extra", as if it was dropped.
+	_, err = targetDB.ExecContext(t.Context(), "CREATE TABLE src (id INT PRIMARY KEY, name VARCHAR(100))")
+	require.NoError(t, err)
+	_, err = targetDB.ExecContext(t.Context(), "CREATE TABLE dst (id INT PRIMARY KEY, name VARCHAR(100), extra VARCHAR(100))")
+	require.NoError(t, err)
+	_, err = targetDB.ExecContext(t.Context(), "INSERT INTO dst VALUES (1, 'Alice', 'kept')")
+	require.NoError(t, err)
+
+	srcTable := table.NewTableInfo(targetDB, target.DBName, "src")
+	require.NoError(t, srcTable.SetInfo(t.Context()))
+	dstTable := table.NewTableInfo(targetDB, target.DBName, "dst")
+	require.NoError(t, dstTable.SetInfo(t.Context()))
+
+	config := NewApplierDefaultConfig()
+	config.KeepUnmappedColumns = true
+	applier, err := NewSingleTargetApplier(Target{DB: targetDB, Config: target, KeyRange: "0"}, config)
+	require.NoError(t, err)
+
+	_, err = applier.UpsertRows(t.Context(), table.NewColumnMapping(srcTable, dstTable, nil), []LogicalRow{
+		{RowImage: []any{int64(1), "Alice Updated"}},
+		{RowImage: []any{int64(2), "Bob"}},
+	}, nil)
+	require.NoError(t, err)
+
+	// The updated row keeps its value of "extra"; the inserted row has the default.
+	var rows string
+	require.NoError(t, targetDB.QueryRowContext(t.Context(),
+		"SELECT GROUP_CONCAT(CONCAT_WS(':', id, name, IFNULL(extra, 'NULL')) ORDER BY id) FROM dst").Scan(&rows))
+	require.Equal(t, "1:Alice Updated:kept,2:Bob:NULL", rows)
+}
+
 func TestSingleTargetApplierUpsertRowsSkipDeleted(t *testing.T) {
 	testutils.RunSQL(t, "DROP DATABASE IF EXISTS single_upsert_deleted_test")
 	testutils.RunSQL(t, "CREATE DATABASE single_upsert_deleted_test")
@@ -0,0 +1,103 @@
+package checksum
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Algorithm is the function used to compute the checksum of a chunk.
+// Each row is hashed to a 64-bit integer, and the row hashes are then
+// aggregated into a single value for the chunk.
+type Algorithm string
+
+const (
+	// AlgorithmCRC32 aggregates the CRC32 of each row with BIT_XOR.
+	// It is the cheapest to compute, but XOR cancels out rows which are
+	// duplicated an even number of times, and a 32-bit hash is weak for
+	// large chunks. It is the default.
+	AlgorithmCRC32 Algorithm = "crc32"
+	// AlgorithmMD5 sums the first 64 bits of the MD5 of each row,
+	// modulo 2^64.
+	AlgorithmMD5 Algorithm = "md5"
+	// AlgorithmSHA256 sums the first 64 bits of the SHA-256 of each row,
+	// modulo 2^64.
+	AlgorithmSHA256 Algorithm = "sha256"
+)
+
+// DefaultAlgorithm is used when no algorithm is specified.
+const DefaultAlgorithm = AlgorithmCRC32
+
+// Algorithms lists the supported algorithms.
+var Algorithms = []Algorithm{AlgorithmCRC32, AlgorithmMD5, AlgorithmSHA256}
+
+// ParseAlgorithm returns the Algorithm with the given name. An empty name
+// returns DefaultAlgorithm.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	if name == "" {
+		return DefaultAlgorithm, nil
+	}
+	for _, a := range Algorithms {
+		if strings.EqualFold(name, string(a)) {
+			return a, nil
+		}
+	}
+	return "", fmt.Errorf("unknown checksum algorithm %q, must be one of %v", name, Algorithms)
+}
+
+// rowExpr returns an expression which hashes a row to an unsigned
+// 64-bit integer. cols is the comma-separated list of checksum column
+// expressions from ColumnMapping.ChecksumExprs.
+func (a Algorithm) rowExpr(cols string) string {
+	switch a {
+	case AlgorithmMD5:
+		return fmt.Sprintf("CAST(CONV(LEFT(MD5(CONCAT(%s)), 16), 16, 10) AS UNSIGNED)", cols)
+	case AlgorithmSHA256:
+		return fmt.Sprintf("CAST(CONV(LEFT(SHA2(CONCAT(%s), 256), 16), 16, 10) AS UNSIGNED)", cols)
+	default:
+		return fmt.Sprintf("CRC32(CONCAT(%s))", cols)
+	}
+}
+
+// chunkExpr returns an aggregate expression for the checksum of all
+// rows matched by a query. It is never NULL, even for an empty chunk.
+func (a Algorithm) chunkExpr(cols string) string {
+	switch a {
+	case AlgorithmMD5, AlgorithmSHA256:
+		// SUM of BIGINT UNSIGNED is a DECIMAL, so it does not overflow
+		// before the modulo is applied.
+		return fmt.Sprintf("CAST(COALESCE(SUM(%s), 0) %% 18446744073709551616 AS UNSIGNED)", a.rowExpr(cols))
+	default:
+		return fmt.Sprintf("BIT_XOR(%s)", a.rowExpr(cols))
+	}
+}
+
+// chunkQuery returns a query for the checksum and row count of a chunk.
+func (a Algorithm) chunkQuery(cols, tableName, where string) string {
+	return fmt.Sprintf("SELECT %s as checksum, count(*) as c FROM %s WHERE %s",
+		a.chunkExpr(cols),
+		tableName,
+		where,
+	)
+}
+
+// rowsQuery returns a query for the checksum of each row in a chunk,
+// along with its key.
+func (a Algorithm) rowsQuery(cols, keyCols, tableName, where string) string {
+	return fmt.Sprintf("SELECT %s as row_checksum, CONCAT_WS(',', %s) as pk FROM %s WHERE %s",
+		a.rowExpr(cols),
+		keyCols,
+		tableName,
+		where,
+	)
+}
+
+// combine merges the checksums of two disjoint sets of rows, such as the
+// same chunk range read from two shards.
+func (a Algorithm) combine(x, y uint64) uint64 {
+	switch a {
+	case AlgorithmMD5, AlgorithmSHA256:
+		return x + y // wraps, which is the modulo 2^64
+	default:
+		return x ^ y
+	}
+}
@@ -0,0 +1,85 @@
+package checksum
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/block/spirit/pkg/dbconn"
+	"github.com/block/spirit/pkg/table"
+	"github.com/block/spirit/pkg/testutils"
+	"github.com/block/spirit/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAlgorithm(t *testing.T) {
+	a, err := ParseAlgorithm("")
+	require.NoError(t, err)
+	require.Equal(t, AlgorithmCRC32, a)
+
+	a, err = ParseAlgorithm("SHA256")
+	require.NoError(t, err)
+	require.Equal(t, AlgorithmSHA256, a)
+
+	a, err = ParseAlgorithm("md5")
+	require.NoError(t, err)
+	require.Equal(t, AlgorithmMD5, a)
+
+	_, err = ParseAlgorithm("sha1")
+	require.ErrorContains(t, err, `unknown checksum algorithm "sha1"`)
+}
+
+func TestAlgorithmQueries(t *testing.T) {
+	require.Equal(t, "SELECT BIT_XOR(CRC32(CONCAT(`a`))) as checksum, count(*) as c FROM `t1` WHERE 1=1",
+		AlgorithmCRC32.chunkQuery("`a`", "`t1`", "1=1"))
+	require.Equal(t, "SELECT CAST(COALESCE(SUM(CAST(CONV(LEFT(MD5(CONCAT(`a`)), 16), 16, 10) AS UNSIGNED)), 0) % 18446744073709551616 AS UNSIGNED) as checksum, count(*) as c FROM `t1` WHERE 1=1",
+		AlgorithmMD5.chunkQuery("`a`", "`t1`", "1=1"))
+	require.Equal(t, "SELECT CAST(CONV(LEFT(SHA2(CONCAT(`a`), 256), 16), 16, 10) AS UNSIGNED) as row_checksum, CONCAT_WS(',', `id`) as pk FROM `t1` WHERE 1=1",
+		AlgorithmSHA256.rowsQuery("`a`", "`id`", "`t1`", "1=1"))
+}
+
+func TestAlgorithmCombine(t *testing.T) {
+	require.Equal(t, uint64(0b0110), AlgorithmCRC32.combine(0b0101, 0b0011))
+	// XOR cancels out the same value, a sum does not.
+	require.Equal(t, uint64(0), AlgorithmCRC32.combine(42, 42))
+	require.Equal(t, uint64(84), AlgorithmMD5.combine(42, 42))
+	// Sums wrap modulo 2^64.
+	require.Equal(t, uint64(1), AlgorithmSHA256.combine(^uint64(0), 2))
+}
+
+func TestChecksumAlgorithms(t *testing.T) {
+	testutils.RunSQL(t, "DROP TABLE IF EXISTS algot1, _algot1_new")
+	testutils.RunSQL(t, "CREATE TABLE algot1 (a INT NOT NULL, b VARCHAR(255), c DATETIME, PRIMARY KEY (a))")
+	testutils.RunSQL(t, "CREATE TABLE _algot1_new (a INT NOT NULL, b VARCHAR(255), c DATETIME, PRIMARY KEY (a))")
+	testutils.RunSQL(t, "INSERT INTO algot1 VALUES (1, 'a', NOW()), (2, NULL, NULL), (3, '', '2020-01-01 00:00:00')")
+	testutils.RunSQL(t, "INSERT INTO _algot1_new SELECT * FROM algot1")
+
+	db, err := dbconn.New(testutils.DSN(), dbconn.NewDBConfig())
+	require.NoError(t, err)
+	defer utils.CloseAndLog(db)
+
+	t1 := table.NewTableInfo(db, "test", "algot1")
+	require.NoError(t, t1.SetInfo(t.Context()))
+	t2 := table.NewTableInfo(db, "test", "_algot1_new")
+	require.NoError(t, t2.SetInfo(t.Context()))
+
+	run := func(algorithm Algorithm) error {
+		chunker, err := table.NewChunker(t1, table.ChunkerConfig{NewTable: t2})
+		require.NoError(t, err)
+		require.NoError(t, chunker.Open())
+		defer utils.CloseAndLog(chunker)
+		config := NewCheckerDefaultConfig()
+		config.Algorithm = algorithm
+		config.MaxRetries = 1
+		checker, err := NewStandaloneChecker([]*sql.DB{db}, chunker, config)
+		require.NoError(t, err)
+		return checker.Run(t.Context())
+	}
+	for _, algorithm := range Algorithms {
+		require.NoError(t, run(algorithm), algorithm)
+	}
+
+	testutils.RunSQL(t, "UPDATE _algot1_new SET b = 'b' WHERE a = 2") // NULL vs 'b'
+	for _, algorithm := range Algorithms {
+		require.ErrorIs(t, run(algorithm), ErrChecksumMismatch, algorithm)
+	}
+}
@@ -5,24 +5,32 @@ import (
 	"database/sql"
 	"errors"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/block/spirit/pkg/applier"
 	"github.com/block/spirit/pkg/dbconn"
 	"github.com/block/spirit/pkg/repl"
 	"github.com/block/spirit/pkg/table"
+	"github.com/block/spirit/pkg/throttler"
 )
 
 var (
-	// Query template for row checksums
-	queryTemplate = "SELECT CRC32(CONCAT(%s)) as row_checksum, CONCAT_WS(',', %s) as pk FROM %s WHERE %s"
-
 	// ErrYieldTimeout is returned by runChecksum when the yield timeout expires.
 	// This is distinct from the parent context being canceled, and signals that
 	// the checksum should resume from the current watermark after releasing
 	// long-running transactions to reduce HLL (history list length) growth.
 	ErrYieldTimeout = errors.New("checksum yield timeout")
 
+	// errThrottled is returned by runChecksum when it stopped dispatching
+	// chunks because the throttler was throttled. Like ErrYieldTimeout, the
+	// checksum resumes from the current watermark.
+	errThrottled = errors.New("checksum throttled")
+
+	// ErrChecksumMismatch is returned when a chunk differs between source
+	// and target and the differences are not being fixed.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
 	// DefaultYieldTimeout is the default maximum duration for a single checksum
 	// pass before yielding to release long-running REPEATABLE READ transactions.
 	DefaultYieldTimeout = 24 * time.Hour
@@ -50,6 +58,74 @@ type Checker interface {
 	// checksum loop uses it to decide whether a sentinel-drop swallow is
 	// safe.
 	DifferencesFound() uint64
+	// Mismatches returns every chunk where a mismatch was detected,
+	// across all attempts, in the order they were found.
+	Mismatches() []Mismatch
+	// SetThrottler sets the throttler that the checker consults between
+	// chunks. It replaces CheckerConfig.Throttler, and is used when the
+	// throttlers are created after the checker.
+	SetThrottler(throttler throttler.Throttler)
+}
+
+// Mismatch describes a chunk whose checksum differed between
+// source and target.
+type Mismatch struct {
+	Attempt        int    `json:"attempt"`
+	Table          string `json:"table"`
+	TargetTable    string `json:"target_table"`
+	Range          string `json:"range"` // the chunk as a WHERE clause
+	SourceChecksum uint64 `json:"source_checksum"`
+	TargetChecksum uint64 `json:"target_checksum"`
+	SourceRows     uint64 `json:"source_rows"`
+	TargetRows     uint64 `json:"target_rows"`
+	Fixed          bool   `json:"fixed"`
+}
+
+// mismatchLog records mismatches for a checker. It is shared by the
+// chunk workers, so it has its own lock.
+type mismatchLog struct {
+	sync.Mutex
+	attempt int
+	entries []Mismatch
+}
+
+func (l *mismatchLog) setAttempt(attempt int) {
+	l.Lock()
+	defer l.Unlock()
+	l.attempt = attempt
+}
+
+// add records a mismatch and returns its index, for use with markFixed.
+func (l *mismatchLog) add(chunk *table.Chunk, sourceChecksum, targetChecksum, sourceRows, targetRows uint64) int {
+	l.Lock()
+	defer l.Unlock()
+	targetTable := chunk.Table.TableName
+	if chunk.NewTable != nil {
+		targetTable = chunk.NewTable.TableName
+	}
+	l.entries = append(l.entries, Mismatch{
+		Attempt:        l.attempt,
+		Table:          chunk.Table.TableName,
+		TargetTable:    targetTable,
+		Range:          chunk.String(),
+		SourceChecksum: sourceChecksum,
+		TargetChecksum: targetChecksum,
+		SourceRows:     sourceRows,
+		TargetRows:     targetRows,
+	})
+	return len(l.entries) - 1
+}
+
+func (l *mismatchLog) markFixed(i int) {
+	l.Lock()
+	defer l.Unlock()
+	l.entries[i].Fixed = true
+}
+
+func (l *mismatchLog) list() []Mismatch {
+	l.Lock()
+	defer l.Unlock()
+	return append([]Mismatch(nil), l.entries...)
 }
 
 type CheckerConfig struct {
@@ -62,6 +138,21 @@ type CheckerConfig struct {
 	MaxRetries      int
 	Applier         applier.Applier // optional; indicates it is a distributed checker
 	YieldTimeout    time.Duration   // maximum duration for a single checksum pass before yielding to release long-running transactions
+	// ContinueOnMismatch only applies when FixDifferences is false. Instead of
+	// failing on the first mismatched chunk, the checker records it and keeps
+	// going, so that Mismatches() covers the whole table. Run then returns
+	// ErrChecksumMismatch once the pass is complete.
+	ContinueOnMismatch bool
+	// Algorithm is the checksum function. Defaults to DefaultAlgorithm.
+	Algorithm Algorithm
+	// DiffReport is optional. When set, the rows of each mismatched chunk
+	// are compared and every differing row is written to it, before the
+	// chunk is fixed. The caller owns the report and must close it.
+	DiffReport *DiffReport
+	// Throttler is optional. While it is throttled, the single checker
+	// yields between chunks (releasing its read views) and waits before
+	// resuming, and the distributed checker waits between chunks.
+	Throttler throttler.Throttler
 }
 
 func NewCheckerDefaultConfig() *CheckerConfig {
@@ -81,12 +172,25 @@ func NewCheckerDefaultConfig() *CheckerConfig {
 // multiple for N:M moves). The distributed checker aggregates checksums across all sources.
 // The single checker uses sourceDBs[0].
 func NewChecker(sourceDBs []*sql.DB, chunker table.Chunker, feeds []*repl.Client, config *CheckerConfig) (Checker, error) {
-	if len(sourceDBs) == 0 {
-		return nil, errors.New("at least one source database must be provided")
-	}
 	if len(feeds) == 0 {
 		return nil, errors.New("at least one feed must be provided")
 	}
+	return newChecker(sourceDBs, chunker, feeds, config)
+}
+
+// NewStandaloneChecker creates a checksum object which is not paired with a
+// replication feed, for comparing tables outside of a migration or move.
+// Because there is no feed, changes made while the checksum runs are not
+// accounted for: the tables should not be receiving writes, or mismatches
+// may be reported for chunks that were modified.
+func NewStandaloneChecker(sourceDBs []*sql.DB, chunker table.Chunker, config *CheckerConfig) (Checker, error) {
+	return newChecker(sourceDBs, chunker, nil, config)
+}
+
+func newChecker(sourceDBs []*sql.DB, chunker table.Chunker, feeds []*repl.Client, config *CheckerConfig) (Checker, error) {
+	if len(sourceDBs) == 0 {
+		return nil, errors.New("at least one source database must be provided")
+	}
 	if chunker == nil {
 		return nil, errors.New("chunker must be non-nil")
 	}
@@ -99,28 +203,47 @@ func NewChecker(sourceDBs []*sql.DB, chunker table.Chunker, feeds []*repl.Client
 	if config.YieldTimeout == 0 {
 		config.YieldTimeout = DefaultYieldTimeout
 	}
+	if config.Throttler == nil {
+		config.Throttler = &throttler.Noop{}
+	}
+	algorithm, err := ParseAlgorithm(string(config.Algorithm))
+	if err != nil {
+		return nil, err
+	}
 	if config.Applier != nil {
 		return &DistributedChecker{
-			concurrency:    config.Concurrency,
-			sourceDBs:      sourceDBs,
-			feeds:          feeds,
-			chunker:        chunker,
-			dbConfig:       config.DBConfig,
-			logger:         config.Logger,
-			fixDifferences: config.FixDifferences,
-			maxRetries:     config.MaxRetries,
-			applier:        config.Applier,
+			concurrency:        config.Concurrency,
+			sourceDBs:          sourceDBs,
+			feeds:              feeds,
+			chunker:            chunker,
+			dbConfig:           config.DBConfig,
+			logger:             config.Logger,
+			fixDifferences:     config.FixDifferences,
+			continueOnMismatch: config.ContinueOnMismatch,
+			algorithm:          algorithm,
+			diffReport:         config.DiffReport,
+			maxRetries:         config.MaxRetries,
+			applier:            config.Applier,
+			throttler:          config.Throttler,
 		}, nil
 	}
+	var feed *repl.Client
+	if len(feeds) > 0 {
+		feed = feeds[0]
+	}
 	return &SingleChecker{
-		concurrency:    config.Concurrency,
-		db:             sourceDBs[0],
-		feed:           feeds[0],
-		chunker:        chunker,
-		dbConfig:       config.DBConfig,
-		logger:         config.Logger,
-		fixDifferences: config.FixDifferences,
-		maxRetries:     config.MaxRetries,
-		yieldTimeout:   config.YieldTimeout,
+		concurrency:        config.Concurrency,
+		db:                 sourceDBs[0],
+		feed:               feed,
+		chunker:            chunker,
+		dbConfig:           config.DBConfig,
+		logger:             config.Logger,
+		fixDifferences:     config.FixDifferences,
+		continueOnMismatch: config.ContinueOnMismatch,
+		algorithm:          algorithm,
+		diffReport:         config.DiffReport,
+		maxRetries:         config.MaxRetries,
+		yieldTimeout:       config.YieldTimeout,
+		throttler:          config.Throttler,
 	}, nil
 }
@@ -0,0 +1,323 @@
+package checksum
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/block/spirit/pkg/applier"
+	"github.com/block/spirit/pkg/dbconn"
+	"github.com/block/spirit/pkg/table"
+	"github.com/block/spirit/pkg/utils"
+	"github.com/go-sql-driver/mysql"
+)
+
+// ChecksumCmd is the Kong CLI struct for the checksum command.
+// It compares tables on a source against a target outside of a migration
+// or move, for example to audit a replica, a restore or a previous move.
+type ChecksumCmd struct {
+	SourceDSN       string            `name:"source-dsn" help:"DSN of the database to compare from" required:""`
+	TargetDSN       string            `name:"target-dsn" help:"DSN of the database to compare against" optional:""`
+	TargetShards    map[string]string `name:"target-shard" help:"A sharded target as key-range=DSN (repeatable), e.g. -80=user:pass@tcp(shard1:3306)/db" optional:""`
+	Tables          []string          `name:"table" help:"Table to compare (repeatable); defaults to all tables in the source database" optional:""`
+	TargetTable     string            `name:"target-table" help:"Compare against this table in the source database instead of a target DSN; requires exactly one --table" optional:""`
+	Threads         int               `name:"threads" help:"How many chunks to checksum in parallel" default:"4"`
+	TargetChunkTime time.Duration     `name:"target-chunk-time" help:"How long each chunk should take to checksum" default:"1s"`
+	Fix             bool              `name:"fix" help:"Recopy mismatching chunks from the source to the target" default:"false"`
+	Algorithm       string            `name:"checksum-algorithm" help:"Checksum function: crc32, md5 or sha256" optional:"" default:"crc32"`
+	ReportFile      string            `name:"report-file" help:"Write the JSON report to this file instead of stdout" optional:""`
+	DiffFile        string            `name:"diff-file" help:"Write every row that differs in a mismatched chunk to this file (JSON lines)" optional:""`
+	DiffRedact      bool              `name:"diff-redact" help:"Replace column values in the --diff-file with a placeholder; primary keys are kept" default:"false"`
+
+	// ShardingProvider optionally provides the sharding column and hash
+	// function for each table. It is required to --fix sharded targets,
+	// since recopied rows have to be routed to the right shard.
+	ShardingProvider table.ShardingMetadataProvider `kong:"-"`
+}
+
+// Report is the machine-readable result of the checksum command.
+type Report struct {
+	Source     string     `json:"source"`
+	Targets    []string   `json:"targets"`
+	Tables     []string   `json:"tables"`
+	Passed     bool       `json:"passed"`
+	Error      string     `json:"error,omitempty"`
+	Mismatches []Mismatch `json:"mismatches"`
+}
+
+// Run executes the checksum command. It is called by Kong.
+func (cmd *ChecksumCmd) Run() error {
+	if err := cmd.validate(); err != nil {
+		return err
+	}
+	report, err := cmd.compare(context.Background())
+	if report != nil {
+		if writeErr := cmd.writeReport(report); writeErr != nil {
+			return errors.Join(err, writeErr)
+		}
+	}
+	return err
+}
+
+func (cmd *ChecksumCmd) validate() error {
+	if cmd.TargetDSN != "" && len(cmd.TargetShards) > 0 {
+		return errors.New("--target-dsn and --target-shard are mutually exclusive")
+	}
+	if cmd.TargetTable != "" {
+		if cmd.TargetDSN != "" || len(cmd.TargetShards) > 0 {
+			return errors.New("--target-table compares within the source database, and can not be used with --target-dsn or --target-shard")
+		}
+		if len(cmd.Tables) != 1 {
+			return errors.New("--target-table requires exactly one --table")
+		}
+		if cmd.TargetTable == cmd.Tables[0] {
+			return errors.New("--target-table must be different from --table")
+		}
+	} else if cmd.TargetDSN == "" && len(cmd.TargetShards) == 0 {
+		return errors.New("one of --target-dsn, --target-shard or --target-table is required")
+	}
+	if cmd.Threads <= 0 {
+		return fmt.Errorf("--threads must be positive, got %d", cmd.Threads)
+	}
+	if cmd.TargetChunkTime < 0 {
+		return fmt.Errorf("--target-chunk-time must be non-negative, got %s", cmd.TargetChunkTime)
+	}
+	if _, err := ParseAlgorithm(cmd.Algorithm); err != nil {
+		return fmt.Errorf("--checksum-algorithm: %w", err)
+	}
+	if cmd.DiffRedact && cmd.DiffFile == "" {
+		return errors.New("--diff-redact requires --diff-file")
+	}
+	if cmd.Fix && len(cmd.TargetShards) > 0 && cmd.ShardingProvider == nil {
+		return errors.New("--fix with sharded targets requires a sharding provider to route recopied rows")
+	}
+	return nil
+}
+
+// compare runs the checksum. The report is returned whenever the
+// checksum itself ran, including when it found differences.
+func (cmd *ChecksumCmd) compare(ctx context.Context) (*Report, error) {
+	logger := slog.Default()
+	dbConfig := dbconn.NewDBConfig()
+	// The trx pool uses one connection per thread, plus the table lock
+	// and the statements which recopy chunks on --fix.
+	dbConfig.MaxOpenConnections = cmd.Threads + 4
+
+	sourceCfg, err := mysql.ParseDSN(cmd.SourceDSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse source DSN: %w", err)
+	}
+	if sourceCfg.DBName == "" {
+		return nil, errors.New("source DSN must include a database")
+	}
+	src, err := dbconn.NewWithConnectionType(cmd.SourceDSN, dbConfig, "source database")
+	if err != nil {
+		return nil, err
+	}
+	defer utils.CloseAndLog(src)
+
+	targets, err := cmd.openTargets(dbConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, target := range targets {
+			utils.CloseAndLog(target.DB)
+		}
+	}()
+
+	tableNames := cmd.Tables
+	if len(tableNames) == 0 {
+		if tableNames, err = baseTables(ctx, src); err != nil {
+			return nil, err
+		}
+		if len(tableNames) == 0 {
+			return nil, fmt.Errorf("no tables found in source database %q", sourceCfg.DBName)
+		}
+	}
+
+	chunkers := make([]table.Chunker, 0, len(tableNames))
+	for _, name := range tableNames {
+		chunker, err := cmd.newChunker(ctx, src, sourceCfg.DBName, name, logger)
+		if err != nil {
+			return nil, err
+		}
+		chunkers = append(chunkers, chunker)
+	}
+	chunker := table.NewMultiChunker(chunkers...)
+	if err := chunker.Open(); err != nil {
+		return nil, err
+	}
+	defer utils.CloseAndLog(chunker)
+
+	config := &CheckerConfig{
+		Concurrency:        cmd.Threads,
+		TargetChunkTime:    cmd.TargetChunkTime,
+		DBConfig:           dbConfig,
+		Logger:             logger,
+		FixDifferences:     cmd.Fix,
+		ContinueOnMismatch: true,
+		MaxRetries:         3,
+		Algorithm:          Algorithm(cmd.Algorithm),
+	}
+	if cmd.DiffFile != "" {
+		if config.DiffReport, err = NewDiffReport(cmd.DiffFile, cmd.DiffRedact); err != nil {
+			return nil, err
+		}
+		defer utils.CloseAndLog(config.DiffReport)
+	}
+	// Comparing against other servers uses the distributed checker,
+	// which recopies chunks through an applier.
+	if len(targets) > 0 {
+		if config.Applier, err = newApplier(targets, dbConfig, logger); err != nil {
+			return nil, err
+		}
+	}
+	checker, err := NewStandaloneChecker([]*sql.DB{src}, chunker, config)
+	if err != nil {
+		return nil, err
+	}
+	runErr := checker.Run(ctx)
+
+	report := &Report{
+		Source:     sourceCfg.Addr + "/" + sourceCfg.DBName,
+		Targets:    make([]string, 0, len(targets)),
+		Tables:     tableNames,
+		Passed:     runErr == nil,
+		Mismatches: checker.Mismatches(),
+	}
+	for _, target := range targets {
+		report.Targets = append(report.Targets, target.Config.Addr+"/"+target.Config.DBName)
+	}
+	if cmd.TargetTable != "" {
+		report.Targets = append(report.Targets, sourceCfg.Addr+"/"+sourceCfg.DBName+"."+cmd.TargetTable)
+	}
+	if report.Mismatches == nil {
+		report.Mismatches = []Mismatch{}
+	}
+	if runErr != nil {
+		report.Error = runErr.Error()
+	}
+	return report, runErr
+}
+
+// openTargets connects to the target DSN or to each target shard.
+// It returns no targets when comparing within the source database.
+func (cmd *ChecksumCmd) openTargets(dbConfig *dbconn.DBConfig) ([]applier.Target, error) {
+	dsns := cmd.TargetShards
+	if cmd.TargetDSN != "" {
+		dsns = map[string]string{"0": cmd.TargetDSN}
+	}
+	// Sort by key range so the report and logs are deterministic.
+	keyRanges := make([]string, 0, len(dsns))
+	for keyRange := range dsns {
+		keyRanges = append(keyRanges, keyRange)
+	}
+	slices.Sort(keyRanges)
+
+	targets := make([]applier.Target, 0, len(dsns))
+	for _, keyRange := range keyRanges {
+		dsn := dsns[keyRange]
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			err = fmt.Errorf("failed to parse target DSN for key range %q: %w", keyRange, err)
+		} else if cfg.DBName == "" {
+			err = fmt.Errorf("target DSN for key range %q must include a database", keyRange)
+		}
+		var db *sql.DB
+		if err == nil {
+			db, err = dbconn.NewWithConnectionType(dsn, dbConfig, "target database")
+		}
+		if err != nil {
+			for _, target := range targets {
+				utils.CloseAndLog(target.DB)
+			}
+			return nil, err
+		}
+		targets = append(targets, applier.Target{DB: db, Config: cfg, KeyRange: keyRange})
+	}
+	return targets, nil
+}
+
+// newChunker returns a chunker for the named source table. When comparing
+// within the source database, the chunker maps it to --target-table.
+func (cmd *ChecksumCmd) newChunker(ctx context.Context, src *sql.DB, schema, name string, logger *slog.Logger) (table.Chunker, error) {
+	tbl := table.NewTableInfo(src, schema, name)
+	if err := tbl.SetInfo(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read table %q: %w", name, err)
+	}
+	if len(cmd.TargetShards) > 0 && cmd.ShardingProvider != nil {
+		shardingColumn, hashFunc, err := cmd.ShardingProvider.GetShardingMetadata(schema, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sharding metadata for table %s: %w", name, err)
+		}
+		if cmd.Fix && (shardingColumn == "" || hashFunc == nil) {
+			return nil, fmt.Errorf("can not fix table %s on sharded targets: it has no sharding metadata", name)
+		}
+		tbl.ShardingColumn = shardingColumn
+		tbl.HashFunc = hashFunc
+	}
+	chunkerCfg := table.ChunkerConfig{
+		TargetChunkTime: cmd.TargetChunkTime,
+		Logger:          logger,
+	}
+	if cmd.TargetTable != "" {
+		newTable := table.NewTableInfo(src, schema, cmd.TargetTable)
+		if err := newTable.SetInfo(ctx); err != nil {
+			return nil, fmt.Errorf("failed to read table %q: %w", cmd.TargetTable, err)
+		}
+		chunkerCfg.NewTable = newTable
+	}
+	return table.NewChunker(tbl, chunkerCfg)
+}
+
+// baseTables returns the names of all base tables in the current database.
+func baseTables(ctx context.Context, db *sql.DB) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE' ORDER BY table_name")
+	if err != nil {
+		return nil, err
+	}
+	defer utils.CloseAndLog(rows)
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func newApplier(targets []applier.Target, dbConfig *dbconn.DBConfig, logger *slog.Logger) (applier.Applier, error) {
+	cfg := &applier.ApplierConfig{
+		DBConfig: dbConfig,
+		Logger:   logger,
+	}
+	if len(targets) == 1 && targets[0].KeyRange == "0" {
+		return applier.NewSingleTargetApplier(targets[0], cfg)
+	}
+	return applier.NewShardedApplier(targets, cfg)
+}
+
+func (cmd *ChecksumCmd) writeReport(report *Report) error {
+	var w io.Writer = os.Stdout
+	if cmd.ReportFile != "" {
+		f, err := os.Create(cmd.ReportFile)
+		if err != nil {
+			return fmt.Errorf("failed to create report file: %w", err)
+		}
+		defer utils.CloseAndLog(f)
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
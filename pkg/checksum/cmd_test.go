@@ -0,0 +1,128 @@
+package checksum
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/block/spirit/pkg/testutils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksumCmdValidate(t *testing.T) {
+	cmd := &ChecksumCmd{SourceDSN: testutils.DSN(), Threads: 4}
+	require.ErrorContains(t, cmd.validate(), "one of --target-dsn, --target-shard or --target-table is required")
+
+	cmd = &ChecksumCmd{SourceDSN: testutils.DSN(), TargetDSN: testutils.DSN(), TargetShards: map[string]string{"-80": testutils.DSN()}, Threads: 4}
+	require.ErrorContains(t, cmd.validate(), "mutually exclusive")
+
+	cmd = &ChecksumCmd{SourceDSN: testutils.DSN(), TargetDSN: testutils.DSN(), TargetTable: "t2", Tables: []string{"t1"}, Threads: 4}
+	require.ErrorContains(t, cmd.validate(), "can not be used with --target-dsn")
+
+	cmd = &ChecksumCmd{SourceDSN: testutils.DSN(), TargetTable: "t2", Threads: 4}
+	require.ErrorContains(t, cmd.validate(), "requires exactly one --table")
+
+	cmd = &ChecksumCmd{SourceDSN: testutils.DSN(), TargetTable: "t1", Tables: []string{"t1"}, Threads: 4}
+	require.ErrorContains(t, cmd.validate(), "must be different")
+
+	cmd = &ChecksumCmd{SourceDSN: testutils.DSN(), TargetDSN: testutils.DSN()}
+	require.ErrorContains(t, cmd.validate(), "--threads must be positive")
+
+	cmd = &ChecksumCmd{SourceDSN: testutils.DSN(), TargetShards: map[string]string{"-80": testutils.DSN(), "80-": testutils.DSN()}, Threads: 4, Fix: true}
+	require.ErrorContains(t, cmd.validate(), "requires a sharding provider")
+
+	cmd = &ChecksumCmd{SourceDSN: testutils.DSN(), TargetDSN: testutils.DSN(), Threads: 4, Algorithm: "crc64"}
+	require.ErrorContains(t, cmd.validate(), "unknown checksum algorithm")
+
+	cmd = &ChecksumCmd{SourceDSN: testutils.DSN(), TargetDSN: testutils.DSN(), Threads: 4, DiffRedact: true}
+	require.ErrorContains(t, cmd.validate(), "--diff-redact requires --diff-file")
+
+	cmd = &ChecksumCmd{SourceDSN: testutils.DSN(), TargetTable: "t2", Tables: []string{"t1"}, Threads: 4, TargetChunkTime: time.Second}
+	require.NoError(t, cmd.validate())
+}
+
+func readReport(t *testing.T, path string) Report {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var report Report
+	require.NoError(t, json.Unmarshal(data, &report))
+	return report
+}
+
+func TestChecksumCmdSameDatabase(t *testing.T) {
+	testutils.RunSQL(t, "DROP TABLE IF EXISTS cmdchecksum_src, cmdchecksum_dst")
+	testutils.RunSQL(t, "CREATE TABLE cmdchecksum_src (id INT NOT NULL PRIMARY KEY, b VARCHAR(10))")
+	testutils.RunSQL(t, "CREATE TABLE cmdchecksum_dst (id INT NOT NULL PRIMARY KEY, b VARCHAR(10))")
+	testutils.RunSQL(t, "INSERT INTO cmdchecksum_src VALUES (1, 'a'), (2, 'b'), (3, 'c')")
+	testutils.RunSQL(t, "INSERT INTO cmdchecksum_dst VALUES (1, 'a'), (2, 'x')") // 2 differs, 3 missing
+
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+	cmd := &ChecksumCmd{
+		SourceDSN:       testutils.DSN(),
+		Tables:          []string{"cmdchecksum_src"},
+		TargetTable:     "cmdchecksum_dst",
+		Threads:         2,
+		TargetChunkTime: time.Second,
+		ReportFile:      reportFile,
+	}
+	require.ErrorIs(t, cmd.Run(), ErrChecksumMismatch)
+	report := readReport(t, reportFile)
+	require.False(t, report.Passed)
+	require.Equal(t, []string{"cmdchecksum_src"}, report.Tables)
+	require.Len(t, report.Mismatches, 1)
+	require.Equal(t, "cmdchecksum_src", report.Mismatches[0].Table)
+	require.Equal(t, "cmdchecksum_dst", report.Mismatches[0].TargetTable)
+	require.Equal(t, uint64(3), report.Mismatches[0].SourceRows)
+	require.Equal(t, uint64(2), report.Mismatches[0].TargetRows)
+	require.False(t, report.Mismatches[0].Fixed)
+
+	// With --fix the chunk is recopied and the next attempt passes.
+	cmd.Fix = true
+	require.NoError(t, cmd.Run())
+	report = readReport(t, reportFile)
+	require.True(t, report.Passed)
+	require.Len(t, report.Mismatches, 1)
+	require.True(t, report.Mismatches[0].Fixed)
+
+	// It is now clean.
+	cmd.Fix = false
+	require.NoError(t, cmd.Run())
+	report = readReport(t, reportFile)
+	require.True(t, report.Passed)
+	require.Empty(t, report.Mismatches)
+}
+
+func TestChecksumCmdAcrossDatabases(t *testing.T) {
+	srcDB, _ := testutils.CreateUniqueTestDatabase(t)
+	dstDB, _ := testutils.CreateUniqueTestDatabase(t)
+	for _, db := range []string{srcDB, dstDB} {
+		testutils.RunSQLInDatabase(t, db, "CREATE TABLE t1 (id INT NOT NULL AUTO_INCREMENT PRIMARY KEY, b INT)")
+		testutils.RunSQLInDatabase(t, db, "CREATE TABLE t2 (id INT NOT NULL AUTO_INCREMENT PRIMARY KEY, b INT)")
+		testutils.RunSQLInDatabase(t, db, "INSERT INTO t1 (b) VALUES (1), (2), (3)")
+		testutils.RunSQLInDatabase(t, db, "INSERT INTO t2 (b) VALUES (1), (2), (3)")
+	}
+	testutils.RunSQLInDatabase(t, dstDB, "DELETE FROM t2 WHERE id = 2")
+
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+	cmd := &ChecksumCmd{
+		SourceDSN:       testutils.DSNForDatabase(srcDB),
+		TargetDSN:       testutils.DSNForDatabase(dstDB),
+		Threads:         2,
+		TargetChunkTime: time.Second,
+		ReportFile:      reportFile,
+	}
+	require.ErrorIs(t, cmd.Run(), ErrChecksumMismatch)
+	report := readReport(t, reportFile)
+	require.Equal(t, []string{"t1", "t2"}, report.Tables) // all tables by default
+	require.Len(t, report.Mismatches, 1)
+	require.Equal(t, "t2", report.Mismatches[0].Table)
+
+	cmd.Fix = true
+	require.NoError(t, cmd.Run())
+	cmd.Fix = false
+	require.NoError(t, cmd.Run())
+	require.Empty(t, readReport(t, reportFile).Mismatches)
+}
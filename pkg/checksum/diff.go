@@ -0,0 +1,278 @@
+package checksum
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/block/spirit/pkg/table"
+	"github.com/block/spirit/pkg/utils"
+)
+
+// RowDiff kinds.
+const (
+	// RowChanged is a row which exists on both sides with different values.
+	RowChanged = "changed"
+	// RowMissingInTarget is a row which exists in the source only.
+	RowMissingInTarget = "missing_in_target"
+	// RowExtraInTarget is a row which exists in the target only.
+	RowExtraInTarget = "extra_in_target"
+)
+
+// redactedValue replaces non-NULL values when the report is redacted.
+const redactedValue = "[redacted]"
+
+// RowDiff describes a single row which differs between source and target.
+// Values are keyed by source column name and are nil for NULL. Binary
+// values which are not valid UTF-8 are hex encoded with a 0x prefix.
+type RowDiff struct {
+	Table       string             `json:"table"`
+	TargetTable string             `json:"target_table"`
+	Key         string             `json:"key"` // the primary key values, comma-separated
+	Kind        string             `json:"kind"`
+	Columns     []string           `json:"columns"` // the columns which differ
+	Source      map[string]*string `json:"source,omitempty"`
+	Target      map[string]*string `json:"target,omitempty"`
+}
+
+// DiffReport writes every row which differs in a mismatched chunk to a
+// file, as one JSON object per line. The rows are read before a chunk is
+// fixed, so the target values are the ones before the recopy.
+// It is safe for concurrent use by the chunk workers.
+type DiffReport struct {
+	sync.Mutex
+	f      *os.File
+	enc    *json.Encoder
+	redact bool
+}
+
+// NewDiffReport opens the report file at path for appending, creating it
+// if it does not exist. Existing entries are kept, so that resuming a
+// migration does not lose the diffs of the earlier run. When redact is
+// true column values are replaced by a placeholder (NULL is still reported
+// as null), but the primary key is always included so that differing rows
+// can be found.
+func NewDiffReport(path string, redact bool) (*DiffReport, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open diff report: %w", err)
+	}
+	return &DiffReport{f: f, enc: json.NewEncoder(f), redact: redact}, nil
+}
+
+// Close closes the report file.
+func (r *DiffReport) Close() error {
+	r.Lock()
+	defer r.Unlock()
+	return r.f.Close()
+}
+
+func (r *DiffReport) write(diffs []RowDiff) error {
+	r.Lock()
+	defer r.Unlock()
+	for _, diff := range diffs {
+		if r.redact {
+			redactValues(diff.Source)
+			redactValues(diff.Target)
+		}
+		if err := r.enc.Encode(diff); err != nil {
+			return fmt.Errorf("failed to write diff report: %w", err)
+		}
+	}
+	return nil
+}
+
+func redactValues(values map[string]*string) {
+	for col, v := range values {
+		if v != nil {
+			redacted := redactedValue
+			values[col] = &redacted
+		}
+	}
+}
+
+// diffSide is how the rows of a chunk are read from one side.
+type diffSide struct {
+	tableName  string   // quoted
+	columns    []string // unquoted, in the same order on both sides
+	exprs      []string // the checksum expression of each column
+	keyColumns []string
+}
+
+// diffRow is a row read for the diff. The normalized values are the
+// checksum expressions, so that values are compared the same way the
+// checksum compares them (e.g. after type conversions).
+type diffRow struct {
+	values     []*string
+	normalized []string
+}
+
+// diffSides returns how to read a chunk from the source and target.
+// When sameTable is true the target is the same table on other servers
+// (the distributed case), so both sides use the source names.
+func diffSides(chunk *table.Chunk, sameTable bool) (source, target diffSide, err error) {
+	sourceCols, targetCols := chunk.ColumnMapping.ColumnsSlice()
+	sourceExprs, targetExprs, err := chunk.ColumnMapping.ChecksumColumnExprs()
+	if err != nil {
+		return source, target, err
+	}
+	source = diffSide{
+		tableName:  chunk.Table.QuotedTableName,
+		columns:    sourceCols,
+		exprs:      sourceExprs,
+		keyColumns: chunk.Table.KeyColumns,
+	}
+	if sameTable || chunk.NewTable == nil {
+		return source, source, nil
+	}
+	target = diffSide{
+		tableName:  chunk.NewTable.QuotedTableName,
+		columns:    targetCols,
+		exprs:      targetExprs,
+		keyColumns: chunk.NewTable.KeyColumns,
+	}
+	return source, target, nil
+}
+
+// readDiffRows reads every row of the chunk from trx, keyed by primary key.
+// It adds to rows, so that rows can be merged from several shards.
+func readDiffRows(ctx context.Context, trx *sql.Tx, side diffSide, where string, rows map[string]diffRow) error {
+	exprs := make([]string, 0, len(side.columns)*2)
+	for _, col := range side.columns {
+		exprs = append(exprs, "`"+col+"`")
+	}
+	for _, expr := range side.exprs {
+		exprs = append(exprs, "CONCAT("+expr+")")
+	}
+	query := fmt.Sprintf("SELECT CONCAT_WS(',', %s) as pk, %s FROM %s WHERE %s",
+		table.QuoteColumns(side.keyColumns),
+		strings.Join(exprs, ", "),
+		side.tableName,
+		where,
+	)
+	res, err := trx.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to query rows for diff: %w", err)
+	}
+	defer utils.CloseAndLog(res)
+	n := len(side.columns)
+	for res.Next() {
+		var pk string
+		raw := make([]sql.RawBytes, n*2)
+		dest := make([]any, 0, n*2+1)
+		dest = append(dest, &pk)
+		for i := range raw {
+			dest = append(dest, &raw[i])
+		}
+		if err := res.Scan(dest...); err != nil {
+			return fmt.Errorf("failed to scan row for diff: %w", err)
+		}
+		row := diffRow{values: make([]*string, n), normalized: make([]string, n)}
+		for i := range n {
+			if raw[i] != nil {
+				v := displayValue(raw[i])
+				row.values[i] = &v
+			}
+			row.normalized[i] = string(raw[n+i])
+		}
+		rows[pk] = row
+	}
+	return res.Err()
+}
+
+func displayValue(b []byte) string {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	return "0x" + hex.EncodeToString(b)
+}
+
+// diffRows compares the rows read from the source and target, and
+// returns the differences ordered by key.
+func diffRows(chunk *table.Chunk, columns []string, sourceRows, targetRows map[string]diffRow) []RowDiff {
+	targetTable := chunk.Table.TableName
+	if chunk.NewTable != nil {
+		targetTable = chunk.NewTable.TableName
+	}
+	keys := slices.Collect(maps.Keys(sourceRows))
+	for key := range targetRows {
+		if _, ok := sourceRows[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	var diffs []RowDiff
+	for _, key := range keys {
+		src, inSource := sourceRows[key]
+		tgt, inTarget := targetRows[key]
+		diff := RowDiff{Table: chunk.Table.TableName, TargetTable: targetTable, Key: key}
+		switch {
+		case !inTarget:
+			diff.Kind = RowMissingInTarget
+			diff.Columns = columns
+			diff.Source = rowValues(columns, src, columns)
+		case !inSource:
+			diff.Kind = RowExtraInTarget
+			diff.Columns = columns
+			diff.Target = rowValues(columns, tgt, columns)
+		default:
+			for i, col := range columns {
+				if src.normalized[i] != tgt.normalized[i] {
+					diff.Columns = append(diff.Columns, col)
+				}
+			}
+			if len(diff.Columns) == 0 {
+				continue
+			}
+			diff.Kind = RowChanged
+			diff.Source = rowValues(columns, src, diff.Columns)
+			diff.Target = rowValues(columns, tgt, diff.Columns)
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+// rowValues returns the values of the named columns in row.
+func rowValues(columns []string, row diffRow, names []string) map[string]*string {
+	values := make(map[string]*string, len(names))
+	for i, col := range columns {
+		if slices.Contains(names, col) {
+			values[col] = row.values[i]
+		}
+	}
+	return values
+}
+
+// reportChunk reads the chunk from every source and target transaction,
+// and writes the rows which differ to the report. Rows read from several
+// transactions on the same side are merged, which is correct as long as
+// each row is only on one of them (i.e. the sides are shards).
+func (r *DiffReport) reportChunk(ctx context.Context, chunk *table.Chunk, sources, targets []*sql.Tx, sameTable bool) error {
+	sourceSide, targetSide, err := diffSides(chunk, sameTable)
+	if err != nil {
+		return err
+	}
+	sourceRows := make(map[string]diffRow)
+	for _, trx := range sources {
+		if err := readDiffRows(ctx, trx, sourceSide, chunk.String(), sourceRows); err != nil {
+			return err
+		}
+	}
+	targetRows := make(map[string]diffRow)
+	for _, trx := range targets {
+		if err := readDiffRows(ctx, trx, targetSide, chunk.String(), targetRows); err != nil {
+			return err
+		}
+	}
+	return r.write(diffRows(chunk, sourceSide.columns, sourceRows, targetRows))
+}
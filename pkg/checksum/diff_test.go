@@ -0,0 +1,205 @@
+package checksum
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/block/spirit/pkg/dbconn"
+	"github.com/block/spirit/pkg/table"
+	"github.com/block/spirit/pkg/testutils"
+	"github.com/block/spirit/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func readDiffs(t *testing.T, path string) []RowDiff {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer utils.CloseAndLog(f)
+	var diffs []RowDiff
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var diff RowDiff
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &diff))
+		diffs = append(diffs, diff)
+	}
+	require.NoError(t, scanner.Err())
+	return diffs
+}
+
+func TestDiffRows(t *testing.T) {
+	chunk := &table.Chunk{
+		Table:    table.NewTableInfo(nil, "test", "t1"),
+		NewTable: table.NewTableInfo(nil, "test", "_t1_new"),
+	}
+	columns := []string{"id", "a", "b"}
+	row := func(values ...*string) diffRow {
+		r := diffRow{values: values}
+		for _, v := range values {
+			if v == nil {
+				r.normalized = append(r.normalized, "1")
+			} else {
+				r.normalized = append(r.normalized, *v+"0")
+			}
+		}
+		return r
+	}
+	sourceRows := map[string]diffRow{
+		"1": row(strPtr("1"), strPtr("x"), strPtr("y")),
+		"2": row(strPtr("2"), strPtr("x"), nil),
+		"3": row(strPtr("3"), strPtr("x"), strPtr("y")),
+	}
+	targetRows := map[string]diffRow{
+		"1": row(strPtr("1"), strPtr("x"), strPtr("y")), // same
+		"2": row(strPtr("2"), strPtr("z"), strPtr("")),  // both differ
+		"4": row(strPtr("4"), nil, nil),
+	}
+	diffs := diffRows(chunk, columns, sourceRows, targetRows)
+	require.Equal(t, []RowDiff{
+		{
+			Table: "t1", TargetTable: "_t1_new", Key: "2", Kind: RowChanged,
+			Columns: []string{"a", "b"},
+			Source:  map[string]*string{"a": strPtr("x"), "b": nil},
+			Target:  map[string]*string{"a": strPtr("z"), "b": strPtr("")},
+		},
+		{
+			Table: "t1", TargetTable: "_t1_new", Key: "3", Kind: RowMissingInTarget,
+			Columns: columns,
+			Source:  map[string]*string{"id": strPtr("3"), "a": strPtr("x"), "b": strPtr("y")},
+		},
+		{
+			Table: "t1", TargetTable: "_t1_new", Key: "4", Kind: RowExtraInTarget,
+			Columns: columns,
+			Target:  map[string]*string{"id": strPtr("4"), "a": nil, "b": nil},
+		},
+	}, diffs)
+}
+
+func TestDiffReportRedact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "diff.jsonl")
+	report, err := NewDiffReport(path, true)
+	require.NoError(t, err)
+	require.NoError(t, report.write([]RowDiff{{
+		Table: "t1", TargetTable: "t1", Key: "7", Kind: RowChanged,
+		Columns: []string{"a", "b"},
+		Source:  map[string]*string{"a": strPtr("secret"), "b": nil},
+		Target:  map[string]*string{"a": strPtr("other"), "b": strPtr("")},
+	}}))
+	require.NoError(t, report.Close())
+
+	diffs := readDiffs(t, path)
+	require.Len(t, diffs, 1)
+	require.Equal(t, "7", diffs[0].Key) // the key is not redacted
+	require.Equal(t, map[string]*string{"a": strPtr(redactedValue), "b": nil}, diffs[0].Source)
+	require.Equal(t, map[string]*string{"a": strPtr(redactedValue), "b": strPtr(redactedValue)}, diffs[0].Target)
+}
+
+func TestDiffReportAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "diff.jsonl")
+	for _, key := range []string{"1", "2"} {
+		report, err := NewDiffReport(path, false)
+		require.NoError(t, err)
+		require.NoError(t, report.write([]RowDiff{{Table: "t1", TargetTable: "t1", Key: key, Kind: RowChanged}}))
+		require.NoError(t, report.Close())
+	}
+
+	// Opening the report again keeps the earlier entries.
+	diffs := readDiffs(t, path)
+	require.Len(t, diffs, 2)
+	require.Equal(t, "1", diffs[0].Key)
+	require.Equal(t, "2", diffs[1].Key)
+}
+
+func TestDisplayValue(t *testing.T) {
+	require.Equal(t, "abc", displayValue([]byte("abc")))
+	require.Equal(t, "0xff00", displayValue([]byte{0xff, 0x00}))
+}
+
+func TestDiffReportSingleChecker(t *testing.T) {
+	testutils.RunSQL(t, "DROP TABLE IF EXISTS difft1, _difft1_new")
+	testutils.RunSQL(t, "CREATE TABLE difft1 (id INT NOT NULL PRIMARY KEY, a VARCHAR(10), b INT)")
+	testutils.RunSQL(t, "CREATE TABLE _difft1_new (id BIGINT NOT NULL PRIMARY KEY, a VARCHAR(10), b INT)")
+	testutils.RunSQL(t, "INSERT INTO difft1 VALUES (1, 'a', 1), (2, 'b', 2), (3, 'c', 3)")
+	testutils.RunSQL(t, "INSERT INTO _difft1_new VALUES (1, 'a', 1), (2, 'x', NULL), (4, 'd', 4)")
+
+	db, err := dbconn.New(testutils.DSN(), dbconn.NewDBConfig())
+	require.NoError(t, err)
+	defer utils.CloseAndLog(db)
+	t1 := table.NewTableInfo(db, "test", "difft1")
+	require.NoError(t, t1.SetInfo(t.Context()))
+	t2 := table.NewTableInfo(db, "test", "_difft1_new")
+	require.NoError(t, t2.SetInfo(t.Context()))
+	chunker, err := table.NewChunker(t1, table.ChunkerConfig{NewTable: t2})
+	require.NoError(t, err)
+	require.NoError(t, chunker.Open())
+	defer utils.CloseAndLog(chunker)
+
+	path := filepath.Join(t.TempDir(), "diff.jsonl")
+	report, err := NewDiffReport(path, false)
+	require.NoError(t, err)
+	config := NewCheckerDefaultConfig()
+	config.FixDifferences = true
+	config.DiffReport = report
+	checker, err := NewStandaloneChecker([]*sql.DB{db}, chunker, config)
+	require.NoError(t, err)
+	require.NoError(t, checker.Run(t.Context())) // fixed
+	require.NoError(t, report.Close())
+
+	// The rows are reported as they were before the fix.
+	diffs := readDiffs(t, path)
+	require.Len(t, diffs, 3)
+	require.Equal(t, "2", diffs[0].Key)
+	require.Equal(t, RowChanged, diffs[0].Kind)
+	require.Equal(t, []string{"a", "b"}, diffs[0].Columns)
+	require.Equal(t, map[string]*string{"a": strPtr("b"), "b": strPtr("2")}, diffs[0].Source)
+	require.Equal(t, map[string]*string{"a": strPtr("x"), "b": nil}, diffs[0].Target)
+	require.Equal(t, "3", diffs[1].Key)
+	require.Equal(t, RowMissingInTarget, diffs[1].Kind)
+	require.Equal(t, "4", diffs[2].Key)
+	require.Equal(t, RowExtraInTarget, diffs[2].Kind)
+	require.Equal(t, "_difft1_new", diffs[2].TargetTable)
+}
+
+func TestDiffReportAcrossDatabases(t *testing.T) {
+	srcDB, _ := testutils.CreateUniqueTestDatabase(t)
+	dstDB, _ := testutils.CreateUniqueTestDatabase(t)
+	for _, db := range []string{srcDB, dstDB} {
+		testutils.RunSQLInDatabase(t, db, "CREATE TABLE t1 (id INT NOT NULL PRIMARY KEY, b VARBINARY(10))")
+		testutils.RunSQLInDatabase(t, db, "INSERT INTO t1 VALUES (1, 'a'), (2, 'b')")
+	}
+	testutils.RunSQLInDatabase(t, dstDB, "UPDATE t1 SET b = 0xFF WHERE id = 2")
+
+	diffFile := filepath.Join(t.TempDir(), "diff.jsonl")
+	cmd := &ChecksumCmd{
+		SourceDSN:       testutils.DSNForDatabase(srcDB),
+		TargetDSN:       testutils.DSNForDatabase(dstDB),
+		Threads:         2,
+		TargetChunkTime: time.Second,
+		ReportFile:      filepath.Join(t.TempDir(), "report.json"),
+		DiffFile:        diffFile,
+	}
+	require.ErrorIs(t, cmd.Run(), ErrChecksumMismatch)
+	diffs := readDiffs(t, diffFile)
+	require.Len(t, diffs, 1)
+	require.Equal(t, "2", diffs[0].Key)
+	require.Equal(t, []string{"b"}, diffs[0].Columns)
+	require.Equal(t, map[string]*string{"b": strPtr("b")}, diffs[0].Source)
+	require.Equal(t, map[string]*string{"b": strPtr("0xff")}, diffs[0].Target)
+
+	// Redacted, the values are hidden but the key is not.
+	cmd.DiffRedact = true
+	require.ErrorIs(t, cmd.Run(), ErrChecksumMismatch)
+	diffs = readDiffs(t, diffFile)
+	require.Len(t, diffs, 1)
+	require.Equal(t, "2", diffs[0].Key)
+	require.Equal(t, map[string]*string{"b": strPtr(redactedValue)}, diffs[0].Target)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/block/spirit/pkg/dbconn"
 	"github.com/block/spirit/pkg/repl"
 	"github.com/block/spirit/pkg/table"
+	"github.com/block/spirit/pkg/throttler"
 	"github.com/block/spirit/pkg/utils"
 	"golang.org/x/sync/errgroup"
 )
@@ -32,22 +33,27 @@ type sourcePool struct {
 type DistributedChecker struct {
 	sync.Mutex
 
-	concurrency      int
-	feeds            []*repl.Client
-	sourceDBs        []*sql.DB // all source database connections
-	applier          applier.Applier
-	sourcePools      []sourcePool      // one per source DB, created during initConnPool
-	targetTrxPools   []*dbconn.TrxPool // transaction pools for each target
-	isInvalid        bool
-	chunker          table.Chunker
-	startTime        time.Time
-	execTime         time.Duration
-	dbConfig         *dbconn.DBConfig
-	logger           *slog.Logger
-	fixDifferences   bool
-	differencesFound atomic.Uint64
-	recopyLock       sync.Mutex
-	maxRetries       int
+	concurrency        int
+	feeds              []*repl.Client // empty for a standalone checker
+	sourceDBs          []*sql.DB      // all source database connections
+	applier            applier.Applier
+	sourcePools        []sourcePool      // one per source DB, created during initConnPool
+	targetTrxPools     []*dbconn.TrxPool // transaction pools for each target
+	isInvalid          bool
+	chunker            table.Chunker
+	startTime          time.Time
+	execTime           time.Duration
+	dbConfig           *dbconn.DBConfig
+	logger             *slog.Logger
+	fixDifferences     bool
+	continueOnMismatch bool
+	algorithm          Algorithm
+	diffReport         *DiffReport // optional
+	differencesFound   atomic.Uint64
+	mismatches         mismatchLog
+	recopyLock         sync.Mutex
+	maxRetries         int
+	throttler          throttler.Throttler
 }
 
 var _ Checker = (*DistributedChecker)(nil)
@@ -66,55 +72,50 @@ func (c *DistributedChecker) ChecksumChunk(ctx context.Context, chunk *table.Chu
 	whereClause := chunk.String()
 
 	// Query ALL sources and aggregate results.
-	// BIT_XOR is associative/commutative, so XOR-ing per-source checksums
-	// produces the same result as checksumming all rows in one table.
-	// The count is simply summed.
-	var sourceChecksum int64
+	// Every algorithm aggregates rows with an associative/commutative
+	// operation (BIT_XOR or a sum modulo 2^64), so combining per-source
+	// checksums produces the same result as checksumming all rows in one
+	// table. The count is simply summed.
+	var sourceChecksum uint64
 	var sourceCount uint64
+	sourceTrxs := make([]*sql.Tx, 0, len(c.sourcePools))
 	for i := range c.sourcePools {
 		srcTrx, err := c.sourcePools[i].trxPool.Get()
 		if err != nil {
 			return fmt.Errorf("failed to get transaction for source %d: %w", i, err)
 		}
 		defer c.sourcePools[i].trxPool.Put(srcTrx)
+		sourceTrxs = append(sourceTrxs, srcTrx)
 
-		sourceQuery := fmt.Sprintf("SELECT BIT_XOR(CRC32(CONCAT(%s))) as checksum, count(*) as c FROM %s WHERE %s",
-			checksumColumns,
-			chunk.Table.QuotedTableName,
-			whereClause,
-		)
-		var cs int64
-		var cnt uint64
+		sourceQuery := c.algorithm.chunkQuery(checksumColumns, chunk.Table.QuotedTableName, whereClause)
+		var cs, cnt uint64
 		if err := srcTrx.QueryRowContext(ctx, sourceQuery).Scan(&cs, &cnt); err != nil {
 			return fmt.Errorf("failed to query source %d: %w", i, err)
 		}
-		sourceChecksum ^= cs
+		sourceChecksum = c.algorithm.combine(sourceChecksum, cs)
 		sourceCount += cnt
 		c.logger.Debug("source checksum", "sourceID", i, "checksum", cs, "count", cnt)
 	}
 
 	// Query ALL targets and aggregate results.
-	// Same aggregation logic: XOR checksums, sum counts.
-	var targetChecksum int64
+	// Same aggregation logic: combine checksums, sum counts.
+	var targetChecksum uint64
 	var targetCount uint64
+	targetTrxs := make([]*sql.Tx, 0, len(c.targetTrxPools))
 	for i, targetTrxPool := range c.targetTrxPools {
 		targetTrx, err := targetTrxPool.Get()
 		if err != nil {
 			return fmt.Errorf("failed to get transaction for target %d: %w", i, err)
 		}
 		defer targetTrxPool.Put(targetTrx)
+		targetTrxs = append(targetTrxs, targetTrx)
 
-		targetQuery := fmt.Sprintf("SELECT BIT_XOR(CRC32(CONCAT(%s))) as checksum, count(*) as c FROM %s WHERE %s",
-			checksumColumns,
-			chunk.Table.QuotedTableName,
-			whereClause,
-		)
-		var cs int64
-		var cnt uint64
+		targetQuery := c.algorithm.chunkQuery(checksumColumns, chunk.Table.QuotedTableName, whereClause)
+		var cs, cnt uint64
 		if err := targetTrx.QueryRowContext(ctx, targetQuery).Scan(&cs, &cnt); err != nil {
 			return fmt.Errorf("failed to query target %d: %w", i, err)
 		}
-		targetChecksum ^= cs
+		targetChecksum = c.algorithm.combine(targetChecksum, cs)
 		targetCount += cnt
 		c.logger.Debug("target checksum", "targetID", i, "checksum", cs, "count", cnt)
 	}
@@ -127,22 +128,33 @@ func (c *DistributedChecker) ChecksumChunk(ctx context.Context, chunk *table.Chu
 		// The checksums do not match, so we first need
 		// to inspect closely and report on the differences.
 		c.differencesFound.Add(1)
+		mismatch := c.mismatches.add(chunk, sourceChecksum, targetChecksum, sourceCount, targetCount)
 		c.logger.Warn("checksum mismatch for chunk", "chunk", chunk.String(),
 			"sourceChecksum", sourceChecksum, "targetChecksum", targetChecksum,
 			"sourceCount", sourceCount, "targetCount", targetCount)
 
 		// For distributed case, we can't easily inspect differences across multiple sources/targets
-		// So we'll just log the mismatch and proceed to fix
+		// So we'll just log the mismatch and proceed to fix. A diff report, if requested,
+		// compares the rows merged from all sources against those merged from all targets.
 		c.logger.Warn("distributed checksum mismatch detected, will recopy chunk")
+		if c.diffReport != nil {
+			if err := c.diffReport.reportChunk(ctx, chunk, sourceTrxs, targetTrxs, true); err != nil {
+				return err
+			}
+		}
 
-		// Are we allowed to fix the differences? If not, return an error.
-		// This is mostly used by the test-suite.
+		// Are we allowed to fix the differences? If not, return an error
+		// unless we have been asked to report on every chunk.
 		if !c.fixDifferences {
-			return errors.New("checksum mismatch")
-		}
-		// Since we can fix differences, replace the chunk.
-		if err := c.replaceChunk(ctx, chunk); err != nil {
-			return err
+			if !c.continueOnMismatch {
+				return ErrChecksumMismatch
+			}
+		} else {
+			// Since we can fix differences, replace the chunk.
+			if err := c.replaceChunk(ctx, chunk); err != nil {
+				return err
+			}
+			c.mismatches.markFixed(mismatch)
 		}
 	}
 	// When we give feedback, we need to say how many rows were in the chunk.
@@ -308,6 +320,24 @@ func (c *DistributedChecker) DifferencesFound() uint64 {
 	return c.differencesFound.Load()
 }
 
+// Mismatches returns every chunk where a mismatch was detected,
+// across all attempts.
+func (c *DistributedChecker) Mismatches() []Mismatch {
+	return c.mismatches.list()
+}
+
+func (c *DistributedChecker) SetThrottler(throttler throttler.Throttler) {
+	c.Lock()
+	defer c.Unlock()
+	c.throttler = throttler
+}
+
+func (c *DistributedChecker) getThrottler() throttler.Throttler {
+	c.Lock()
+	defer c.Unlock()
+	return c.throttler
+}
+
 func (c *DistributedChecker) setInvalid(newVal bool) {
 	c.Lock()
 	defer c.Unlock()
@@ -482,6 +512,7 @@ func (c *DistributedChecker) Run(ctx context.Context) error {
 			// Reset differences found counter
 			c.differencesFound.Store(0)
 		}
+		c.mismatches.setAttempt(attempt)
 
 		// Run the actual checksum
 		if err := c.runChecksum(ctx); err != nil {
@@ -497,6 +528,10 @@ func (c *DistributedChecker) Run(ctx context.Context) error {
 			c.logger.Info("checksum passed")
 			return nil
 		}
+		// Differences were found, but we were only asked to report them.
+		if !c.fixDifferences {
+			return fmt.Errorf("%w: %d chunks differ", ErrChecksumMismatch, c.differencesFound.Load())
+		}
 	}
 
 	// Retries exhausted:
@@ -534,7 +569,13 @@ func (c *DistributedChecker) runChecksum(ctx context.Context) error {
 
 	g, errGrpCtx := errgroup.WithContext(ctx)
 	g.SetLimit(c.concurrency)
+	throttler := c.getThrottler()
 	for !c.chunker.IsRead() && c.isHealthy(errGrpCtx) {
+		// The distributed checker does not yield, so it can only pause
+		// between chunks while throttled.
+		if throttler.IsThrottled() {
+			throttler.BlockWait(errGrpCtx)
+		}
 		g.Go(func() error {
 			chunk, err := c.chunker.Next()
 			if err != nil {
@@ -17,6 +17,7 @@ import (
 	"github.com/block/spirit/pkg/dbconn"
 	"github.com/block/spirit/pkg/repl"
 	"github.com/block/spirit/pkg/table"
+	"github.com/block/spirit/pkg/throttler"
 	"github.com/block/spirit/pkg/utils"
 	"golang.org/x/sync/errgroup"
 )
@@ -24,22 +25,27 @@ import (
 type SingleChecker struct {
 	sync.Mutex
 
-	concurrency      int
-	feed             *repl.Client
-	db               *sql.DB
-	trxPool          *dbconn.TrxPool // reader trx pool
-	isInvalid        bool
-	chunker          table.Chunker
-	startTime        time.Time
-	execTime         time.Duration
-	dbConfig         *dbconn.DBConfig
-	logger           *slog.Logger
-	fixDifferences   bool
-	differencesFound atomic.Uint64
-	recopyLock       sync.Mutex
-	maxRetries       int
-	yieldTimeout     time.Duration
-	yieldsPerformed  atomic.Uint64 // number of yield/resume cycles performed
+	concurrency        int
+	feed               *repl.Client // nil for a standalone checker
+	db                 *sql.DB
+	trxPool            *dbconn.TrxPool // reader trx pool
+	isInvalid          bool
+	chunker            table.Chunker
+	startTime          time.Time
+	execTime           time.Duration
+	dbConfig           *dbconn.DBConfig
+	logger             *slog.Logger
+	fixDifferences     bool
+	continueOnMismatch bool
+	algorithm          Algorithm
+	diffReport         *DiffReport // optional
+	differencesFound   atomic.Uint64
+	mismatches         mismatchLog
+	recopyLock         sync.Mutex
+	maxRetries         int
+	yieldTimeout       time.Duration
+	yieldsPerformed    atomic.Uint64 // number of yield/resume cycles performed
+	throttler          throttler.Throttler
 }
 
 var _ Checker = (*SingleChecker)(nil)
@@ -56,17 +62,9 @@ func (c *SingleChecker) ChecksumChunk(ctx context.Context, trxPool *dbconn.TrxPo
 	if err != nil {
 		return err
 	}
-	source := fmt.Sprintf("SELECT BIT_XOR(CRC32(CONCAT(%s))) as checksum, count(*) as c FROM %s WHERE %s",
-		sourceChecksumCols,
-		chunk.Table.QuotedTableName,
-		chunk.String(),
-	)
-	target := fmt.Sprintf("SELECT BIT_XOR(CRC32(CONCAT(%s))) as checksum, count(*) as c FROM %s WHERE %s",
-		targetChecksumCols,
-		chunk.NewTable.QuotedTableName,
-		chunk.String(),
-	)
-	var sourceChecksum, targetChecksum int64
+	source := c.algorithm.chunkQuery(sourceChecksumCols, chunk.Table.QuotedTableName, chunk.String())
+	target := c.algorithm.chunkQuery(targetChecksumCols, chunk.NewTable.QuotedTableName, chunk.String())
+	var sourceChecksum, targetChecksum uint64
 	var sourceCount, targetCount uint64
 	err = trx.QueryRowContext(ctx, source).Scan(&sourceChecksum, &sourceCount)
 	if err != nil {
@@ -80,18 +78,28 @@ func (c *SingleChecker) ChecksumChunk(ctx context.Context, trxPool *dbconn.TrxPo
 		// The checksums do not match, so we first need
 		// to inspect closely and report on the differences.
 		c.differencesFound.Add(1)
+		mismatch := c.mismatches.add(chunk, sourceChecksum, targetChecksum, sourceCount, targetCount)
 		c.logger.Warn("checksum mismatch for chunk", "chunk", chunk.String(), "sourceChecksum", sourceChecksum, "targetChecksum", targetChecksum, "sourceCount", sourceCount, "targetCount", targetCount)
 		if err := c.inspectDifferences(ctx, trx, chunk); err != nil {
 			return err
 		}
-		// Are we allowed to fix the differences? If not, return an error.
-		// This is mostly used by the test-suite.
-		if !c.fixDifferences {
-			return errors.New("checksum mismatch")
+		if c.diffReport != nil {
+			if err := c.diffReport.reportChunk(ctx, chunk, []*sql.Tx{trx}, []*sql.Tx{trx}, false); err != nil {
+				return err
+			}
 		}
-		// Since we can fix differences, replace the chunk.
-		if err = c.replaceChunk(ctx, chunk); err != nil {
-			return err
+		// Are we allowed to fix the differences? If not, return an error
+		// unless we have been asked to report on every chunk.
+		if !c.fixDifferences {
+			if !c.continueOnMismatch {
+				return ErrChecksumMismatch
+			}
+		} else {
+			// Since we can fix differences, replace the chunk.
+			if err = c.replaceChunk(ctx, chunk); err != nil {
+				return err
+			}
+			c.mismatches.markFixed(mismatch)
 		}
 	}
 	// When we give feedback, we need to say how many rows were in the chunk.
@@ -119,7 +127,7 @@ func (c *SingleChecker) inspectDifferences(ctx context.Context, trx *sql.Tx, chu
 	if err != nil {
 		return err
 	}
-	sourceRows, err := trx.QueryContext(ctx, fmt.Sprintf(queryTemplate,
+	sourceRows, err := trx.QueryContext(ctx, c.algorithm.rowsQuery(
 		sourceChecksumCols,
 		table.QuoteColumns(chunk.Table.KeyColumns),
 		chunk.Table.QuotedTableName,
@@ -143,7 +151,7 @@ func (c *SingleChecker) inspectDifferences(ctx context.Context, trx *sql.Tx, chu
 		return fmt.Errorf("error iterating source rows: %w", err)
 	}
 
-	targetRows, err := trx.QueryContext(ctx, fmt.Sprintf(queryTemplate,
+	targetRows, err := trx.QueryContext(ctx, c.algorithm.rowsQuery(
 		targetChecksumCols,
 		table.QuoteColumns(chunk.NewTable.KeyColumns),
 		chunk.NewTable.QuotedTableName,
@@ -319,6 +327,24 @@ func (c *SingleChecker) DifferencesFound() uint64 {
 	return c.differencesFound.Load()
 }
 
+// Mismatches returns every chunk where a mismatch was detected,
+// across all attempts.
+func (c *SingleChecker) Mismatches() []Mismatch {
+	return c.mismatches.list()
+}
+
+func (c *SingleChecker) SetThrottler(throttler throttler.Throttler) {
+	c.Lock()
+	defer c.Unlock()
+	c.throttler = throttler
+}
+
+func (c *SingleChecker) getThrottler() throttler.Throttler {
+	c.Lock()
+	defer c.Unlock()
+	return c.throttler
+}
+
 func (c *SingleChecker) setInvalid(newVal bool) {
 	c.Lock()
 	defer c.Unlock()
@@ -329,8 +355,10 @@ func (c *SingleChecker) initConnPool(ctx context.Context) error {
 	// Try and catch up before we apply a table lock,
 	// since we will need to catch up again with the lock held
 	// and we want to minimize that.
-	if err := c.feed.Flush(ctx); err != nil {
-		return err
+	if c.feed != nil {
+		if err := c.feed.Flush(ctx); err != nil {
+			return err
+		}
 	}
 	// Lock the source and target table in a trx
 	// so the connection is not used by others
@@ -342,15 +370,17 @@ func (c *SingleChecker) initConnPool(ctx context.Context) error {
 		return err
 	}
 	defer utils.CloseAndLogWithContext(ctx, tableLock)
-	// We only have a reader, so flush the read connection.
-	if err := c.feed.FlushUnderTableLock(ctx, tableLock); err != nil {
-		return err
-	}
+	if c.feed != nil {
+		// We only have a reader, so flush the read connection.
+		if err := c.feed.FlushUnderTableLock(ctx, tableLock); err != nil {
+			return err
+		}
 
-	// Assert that the change set is empty. This should always
-	// be the case because we are under a lock.
-	if !c.feed.AllChangesFlushed() {
-		return repl.ErrChangesNotFlushed
+		// Assert that the change set is empty. This should always
+		// be the case because we are under a lock.
+		if !c.feed.AllChangesFlushed() {
+			return repl.ErrChangesNotFlushed
+		}
 	}
 	// Create a set of connections which can be used to checksum
 	// The table. They MUST be created before the lock is released
@@ -387,6 +417,7 @@ func (c *SingleChecker) Run(ctx context.Context) error {
 			// Reset differences found counter
 			c.differencesFound.Store(0)
 		}
+		c.mismatches.setAttempt(attempt)
 
 		// If the parent context is already cancelled, retrying is pointless —
 		// every subsequent attempt will fail the same way at the first
@@ -415,6 +446,10 @@ func (c *SingleChecker) Run(ctx context.Context) error {
 			c.logger.Info("checksum passed")
 			return nil
 		}
+		// Differences were found, but we were only asked to report them.
+		if !c.fixDifferences {
+			return fmt.Errorf("%w: %d chunks differ", ErrChecksumMismatch, c.differencesFound.Load())
+		}
 		// Differences were found and (because we got here) recopied. Record
 		// this as the "last attempt outcome" so the exhausted-retries error
 		// below can distinguish it from a hard error path.
@@ -439,16 +474,17 @@ func (c *SingleChecker) Run(ctx context.Context) error {
 }
 
 // runChecksumWithYield runs the checksum, automatically yielding and resuming
-// when the yield timeout expires. Each yield releases the long-running
-// REPEATABLE READ transactions (reducing HLL pressure), then re-acquires a
-// table lock and fresh snapshot before resuming from the low watermark.
+// when the yield timeout expires or the throttler is throttled. Each yield
+// releases the long-running REPEATABLE READ transactions (reducing HLL
+// pressure), then re-acquires a table lock and fresh snapshot before resuming
+// from the low watermark.
 func (c *SingleChecker) runChecksumWithYield(ctx context.Context) error {
 	for {
 		err := c.runChecksum(ctx)
-		if !errors.Is(err, ErrYieldTimeout) {
+		if !errors.Is(err, ErrYieldTimeout) && !errors.Is(err, errThrottled) {
 			return err
 		}
-		// The yield timeout fired. Get the low watermark so we can resume.
+		// We yielded. Get the low watermark so we can resume.
 		watermark, wmErr := c.chunker.GetLowWatermark()
 		if wmErr != nil {
 			// If the watermark isn't ready (e.g. the timeout fired before any
@@ -467,10 +503,16 @@ func (c *SingleChecker) runChecksumWithYield(ctx context.Context) error {
 			return fmt.Errorf("failed to get low watermark after yield: %w", wmErr)
 		}
 		c.yieldsPerformed.Add(1)
-		c.logger.Info("checksum yielding to release long-running transactions",
-			"watermark", watermark,
-			"yieldTimeout", c.yieldTimeout,
-		)
+		if errors.Is(err, errThrottled) {
+			c.logger.Info("checksum yielding while throttled",
+				"watermark", watermark,
+			)
+		} else {
+			c.logger.Info("checksum yielding to release long-running transactions",
+				"watermark", watermark,
+				"yieldTimeout", c.yieldTimeout,
+			)
+		}
 		// Reset the isInvalid flag since we are resuming, not failing.
 		c.setInvalid(false)
 		// Re-open the chunker at the watermark position.
@@ -483,6 +525,13 @@ func (c *SingleChecker) runChecksumWithYield(ctx context.Context) error {
 }
 
 func (c *SingleChecker) runChecksum(ctx context.Context) error {
+	// Wait for the throttler before taking a new snapshot, so that
+	// resuming after a throttled yield does not immediately add load.
+	throttler := c.getThrottler()
+	if throttler.IsThrottled() {
+		throttler.BlockWait(ctx)
+	}
+
 	// initConnPool initialize the connection pool.
 	// This is done under a table lock which is acquired in this func.
 	// It is released as the func is returned.
@@ -495,8 +544,10 @@ func (c *SingleChecker) runChecksum(ctx context.Context) error {
 	// This must not run while initConnPool holds the table lock, because
 	// the periodic flush executes DML (INSERT/DELETE) against the locked
 	// table, which would deadlock with the lock holder.
-	go c.feed.StartPeriodicFlush(ctx, repl.DefaultFlushInterval)
-	defer c.feed.StopPeriodicFlush()
+	if c.feed != nil {
+		go c.feed.StartPeriodicFlush(ctx, repl.DefaultFlushInterval)
+		defer c.feed.StopPeriodicFlush()
+	}
 
 	// Create a yield-timeout context to limit how long a single checksum pass
 	// can hold REPEATABLE READ transactions open. Long-running read views cause
@@ -507,7 +558,17 @@ func (c *SingleChecker) runChecksum(ctx context.Context) error {
 
 	g, errGrpCtx := errgroup.WithContext(yieldCtx)
 	g.SetLimit(c.concurrency)
+	var dispatched, throttled bool
 	for !c.chunker.IsRead() && c.isHealthy(errGrpCtx) {
+		// Stop dispatching chunks while throttled, so that the read views
+		// are released instead of being held open while we wait. At least
+		// one chunk is checksummed per pass so that the checksum always
+		// makes progress, even if BlockWait times out.
+		if dispatched && throttler.IsThrottled() {
+			throttled = true
+			break
+		}
+		dispatched = true
 		g.Go(func() error {
 			chunk, err := c.chunker.Next()
 			if err != nil {
@@ -546,5 +607,8 @@ func (c *SingleChecker) runChecksum(ctx context.Context) error {
 		c.logger.Error("checksum failed")
 		return err1
 	}
+	if throttled && !c.chunker.IsRead() {
+		return errThrottled
+	}
 	return nil
 }
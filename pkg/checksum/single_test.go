@@ -1,7 +1,9 @@
 package checksum
 
 import (
+	"context"
 	"database/sql"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -10,6 +12,7 @@ import (
 	"github.com/block/spirit/pkg/repl"
 	"github.com/block/spirit/pkg/table"
 	"github.com/block/spirit/pkg/testutils"
+	"github.com/block/spirit/pkg/throttler"
 	"github.com/block/spirit/pkg/utils"
 	mysql "github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/require"
@@ -384,6 +387,64 @@ func TestYieldTimeout(t *testing.T) {
 	t.Logf("yields performed: %d", singleChecker.yieldsPerformed.Load())
 }
 
+// flappingThrottler is throttled on every third check, like a history list
+// length throttler whose value hovers around its limit.
+type flappingThrottler struct {
+	throttler.Noop
+	checks atomic.Int64
+	waits  atomic.Int64
+}
+
+func (t *flappingThrottler) IsThrottled() bool {
+	return t.checks.Add(1)%3 == 0
+}
+
+func (t *flappingThrottler) BlockWait(_ context.Context) {
+	t.waits.Add(1)
+}
+
+func TestThrottledChecksumYields(t *testing.T) {
+	testutils.RunSQL(t, "DROP TABLE IF EXISTS throttled_t1, _throttled_t1_new, _throttled_t1_chkpnt")
+	testutils.RunSQL(t, "CREATE TABLE throttled_t1 (a INT NOT NULL AUTO_INCREMENT, b VARCHAR(255), PRIMARY KEY (a))")
+	testutils.RunSQL(t, "CREATE TABLE _throttled_t1_new (a INT NOT NULL AUTO_INCREMENT, b VARCHAR(255), PRIMARY KEY (a))")
+	testutils.RunSQL(t, "CREATE TABLE _throttled_t1_chkpnt (a INT)") // for binlog advancement
+	// Starting chunk size is 1000, so this produces several chunks.
+	testutils.RunSQL(t, "INSERT INTO throttled_t1 (b) SELECT REPEAT('x', 200) FROM information_schema.columns a, information_schema.columns b LIMIT 10000")
+	testutils.RunSQL(t, "INSERT INTO _throttled_t1_new SELECT * FROM throttled_t1")
+
+	db, err := dbconn.New(testutils.DSN(), dbconn.NewDBConfig())
+	require.NoError(t, err)
+	defer utils.CloseAndLog(db)
+
+	t1 := table.NewTableInfo(db, "test", "throttled_t1")
+	require.NoError(t, t1.SetInfo(t.Context()))
+	t2 := table.NewTableInfo(db, "test", "_throttled_t1_new")
+	require.NoError(t, t2.SetInfo(t.Context()))
+
+	cfg, err := mysql.ParseDSN(testutils.DSN())
+	require.NoError(t, err)
+	feed := repl.NewClient(db, cfg.Addr, cfg.User, cfg.Passwd, applier.NewSingleTargetForTest(t, db), repl.NewClientDefaultConfig())
+	defer feed.Close()
+	chunker, err := table.NewChunker(t1, table.ChunkerConfig{NewTable: t2})
+	require.NoError(t, err)
+	require.NoError(t, feed.AddSubscription(t1, t2, chunker))
+	require.NoError(t, feed.Run(t.Context()))
+	require.NoError(t, chunker.Open())
+
+	config := NewCheckerDefaultConfig()
+	config.Concurrency = 1
+	checker, err := NewChecker([]*sql.DB{db}, chunker, []*repl.Client{feed}, config)
+	require.NoError(t, err)
+	// The throttler is set after the checker is created, as in migrations.
+	thr := &flappingThrottler{}
+	checker.SetThrottler(thr)
+
+	// The checksum pauses while throttled, but still passes.
+	require.NoError(t, checker.Run(t.Context()))
+	require.Positive(t, thr.waits.Load(), "expected the checksum to wait on the throttler")
+	require.Positive(t, checker.(*SingleChecker).yieldsPerformed.Load(), "expected the checksum to yield while throttled")
+}
+
 func TestFromWatermark(t *testing.T) {
 	testutils.RunSQL(t, "DROP TABLE IF EXISTS tfromwatermark, _tfromwatermark_new, _tfromwatermark_chkpnt")
 	testutils.RunSQL(t, "CREATE TABLE tfromwatermark (a INT NOT NULL, b INT, c INT, PRIMARY KEY (a))")
@@ -0,0 +1,26 @@
+// Package coordinator pauses application traffic at a proxy layer while
+// the cutover holds its table lock. With traffic paused, the lock is
+// acquired against a quiet server, so it does not queue behind (or need
+// to kill) application transactions.
+package coordinator
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultPauseTimeout is how long a Coordinator waits for traffic to
+// pause when no timeout is given.
+const DefaultPauseTimeout = 5 * time.Second
+
+// Coordinator pauses and resumes traffic to the database.
+type Coordinator interface {
+	// Pause stops new queries from reaching the database, and waits for
+	// in-flight queries to finish. If it returns an error, traffic may be
+	// partially paused, and Resume should still be called.
+	Pause(ctx context.Context) error
+	// Resume lets traffic reach the database again. It is safe to call
+	// when traffic is not paused.
+	Resume(ctx context.Context) error
+	Close() error
+}
@@ -0,0 +1,95 @@
+package coordinator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/block/spirit/pkg/utils"
+)
+
+// HTTP pauses traffic by calling an endpoint on a connection pool or
+// proxy, such as a sidecar in front of the application. Pause and Resume
+// each send a POST to their URL, and any 2xx response is success. The
+// pause endpoint should only respond once in-flight queries are done.
+type HTTP struct {
+	pauseURL  string
+	resumeURL string
+	client    *http.Client
+	logger    *slog.Logger
+}
+
+var _ Coordinator = (*HTTP)(nil)
+
+// NewHTTP returns a Coordinator which POSTs to pauseURL and resumeURL,
+// with timeout for each request. If timeout is zero, DefaultPauseTimeout
+// is used.
+func NewHTTP(pauseURL, resumeURL string, timeout time.Duration, logger *slog.Logger) (*HTTP, error) {
+	if pauseURL == "" || resumeURL == "" {
+		return nil, errors.New("http coordinator requires both a pause and a resume URL")
+	}
+	for _, rawURL := range []string{pauseURL, resumeURL} {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid coordinator URL: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("invalid coordinator URL %q: scheme must be http or https", rawURL)
+		}
+	}
+	if timeout < 0 {
+		return nil, errors.New("http coordinator requires a non-negative timeout")
+	}
+	if timeout == 0 {
+		timeout = DefaultPauseTimeout
+	}
+	return &HTTP{
+		pauseURL:  pauseURL,
+		resumeURL: resumeURL,
+		client:    &http.Client{Timeout: timeout},
+		logger:    logger,
+	}, nil
+}
+
+func (h *HTTP) Pause(ctx context.Context) error {
+	if err := h.post(ctx, h.pauseURL); err != nil {
+		return fmt.Errorf("could not pause traffic: %w", err)
+	}
+	h.logger.Warn("paused traffic", "url", h.pauseURL)
+	return nil
+}
+
+func (h *HTTP) Resume(ctx context.Context) error {
+	if err := h.post(ctx, h.resumeURL); err != nil {
+		return fmt.Errorf("could not resume traffic: %w", err)
+	}
+	h.logger.Warn("resumed traffic", "url", h.resumeURL)
+	return nil
+}
+
+func (h *HTTP) Close() error {
+	h.client.CloseIdleConnections()
+	return nil
+}
+
+func (h *HTTP) post(ctx context.Context, endpoint string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer utils.CloseAndLog(resp.Body)
+	_, _ = io.Copy(io.Discard, resp.Body) // drain so the connection can be reused
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status from %s: %s", endpoint, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package coordinator
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPCoordinator(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	resumeStatus := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		if r.URL.Path == "/resume" {
+			w.WriteHeader(resumeStatus)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	h, err := NewHTTP(srv.URL+"/pause", srv.URL+"/resume", time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	defer h.Close()
+	require.NoError(t, h.Pause(t.Context()))
+	require.NoError(t, h.Resume(t.Context()))
+	mu.Lock()
+	require.Equal(t, []string{"POST /pause", "POST /resume"}, calls)
+	resumeStatus = http.StatusServiceUnavailable
+	mu.Unlock()
+	require.ErrorContains(t, h.Resume(t.Context()), "could not resume traffic: unexpected status")
+}
+
+func TestNewHTTPCoordinator(t *testing.T) {
+	logger := slog.Default()
+	_, err := NewHTTP("http://proxy/pause", "", 0, logger)
+	require.ErrorContains(t, err, "requires both a pause and a resume URL")
+	_, err = NewHTTP("http://proxy/pause", "ftp://proxy/resume", 0, logger)
+	require.ErrorContains(t, err, "scheme must be http or https")
+	_, err = NewHTTP("http://proxy/pause", "http://proxy/resume", -time.Second, logger)
+	require.ErrorContains(t, err, "non-negative timeout")
+	h, err := NewHTTP("http://proxy/pause", "https://proxy/resume", 0, logger)
+	require.NoError(t, err)
+	require.Equal(t, DefaultPauseTimeout, h.client.Timeout)
+}
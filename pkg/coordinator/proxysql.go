@@ -0,0 +1,198 @@
+package coordinator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/block/spirit/pkg/utils"
+	_ "github.com/go-sql-driver/mysql" // the admin interface speaks the MySQL protocol
+)
+
+// drainPollInterval is how often the connection pool is checked while
+// waiting for in-flight queries to finish.
+const drainPollInterval = 50 * time.Millisecond
+
+// proxySQLServer is a backend server in a ProxySQL hostgroup.
+type proxySQLServer struct {
+	hostgroup int
+	hostname  string
+	port      int
+}
+
+// ProxySQL pauses traffic through ProxySQL by setting the ONLINE servers
+// in the given hostgroups to OFFLINE_SOFT. ProxySQL then sends no new
+// queries to them: queries which arrive while paused wait for a server to
+// come back, for up to mysql-connect_timeout_server_max. Resume sets the
+// same servers ONLINE again. Only the runtime configuration is changed;
+// nothing is saved to disk.
+type ProxySQL struct {
+	db           *sql.DB // the admin interface
+	hostgroups   []int
+	drainTimeout time.Duration
+	logger       *slog.Logger
+
+	sync.Mutex
+	paused []proxySQLServer // the servers set OFFLINE_SOFT by Pause
+}
+
+var _ Coordinator = (*ProxySQL)(nil)
+
+// NewProxySQL returns a Coordinator for the ProxySQL admin interface at
+// adminDSN (e.g. admin:admin@tcp(proxysql:6032)/). hostgroups are the
+// hostgroups which route to the database being migrated. If drainTimeout
+// is zero, DefaultPauseTimeout is used.
+func NewProxySQL(adminDSN string, hostgroups []int, drainTimeout time.Duration, logger *slog.Logger) (*ProxySQL, error) {
+	if adminDSN == "" {
+		return nil, errors.New("proxysql coordinator requires an admin DSN")
+	}
+	if len(hostgroups) == 0 {
+		return nil, errors.New("proxysql coordinator requires at least one hostgroup")
+	}
+	if drainTimeout < 0 {
+		return nil, errors.New("proxysql coordinator requires a non-negative drain timeout")
+	}
+	if drainTimeout == 0 {
+		drainTimeout = DefaultPauseTimeout
+	}
+	// The admin interface does not support the session setup that
+	// dbconn.New performs, so it is opened directly.
+	db, err := sql.Open("mysql", adminDSN)
+	if err != nil {
+		return nil, fmt.Errorf("could not open proxysql admin DSN: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	return &ProxySQL{
+		db:           db,
+		hostgroups:   hostgroups,
+		drainTimeout: drainTimeout,
+		logger:       logger,
+	}, nil
+}
+
+// Pause sets the ONLINE servers in the hostgroups to OFFLINE_SOFT, and
+// waits up to the drain timeout for their connections to become idle.
+// If they do not, Pause logs a warning and returns, since the table lock
+// can still be acquired (it may just have to wait or kill).
+func (p *ProxySQL) Pause(ctx context.Context) error {
+	p.Lock()
+	defer p.Unlock()
+	servers, err := p.onlineServers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, server := range servers {
+		if _, err := p.db.ExecContext(ctx, setStatusQuery(server, "OFFLINE_SOFT")); err != nil {
+			return fmt.Errorf("could not set proxysql server %s:%d offline: %w", server.hostname, server.port, err)
+		}
+		p.paused = append(p.paused, server)
+	}
+	if err := p.loadToRuntime(ctx); err != nil {
+		return err
+	}
+	p.logger.Warn("paused traffic in proxysql", "hostgroups", p.hostgroups, "servers", len(servers))
+	return p.waitForDrain(ctx)
+}
+
+// Resume sets the servers paused by Pause back to ONLINE.
+func (p *ProxySQL) Resume(ctx context.Context) error {
+	p.Lock()
+	defer p.Unlock()
+	if len(p.paused) == 0 {
+		return nil
+	}
+	for _, server := range p.paused {
+		if _, err := p.db.ExecContext(ctx, setStatusQuery(server, "ONLINE")); err != nil {
+			return fmt.Errorf("could not set proxysql server %s:%d online: %w", server.hostname, server.port, err)
+		}
+	}
+	if err := p.loadToRuntime(ctx); err != nil {
+		return err
+	}
+	p.logger.Warn("resumed traffic in proxysql", "hostgroups", p.hostgroups, "servers", len(p.paused))
+	p.paused = nil
+	return nil
+}
+
+func (p *ProxySQL) Close() error {
+	return p.db.Close()
+}
+
+func (p *ProxySQL) onlineServers(ctx context.Context) ([]proxySQLServer, error) {
+	query := fmt.Sprintf("SELECT hostgroup_id, hostname, port FROM mysql_servers WHERE status = 'ONLINE' AND hostgroup_id IN (%s)", joinInts(p.hostgroups))
+	rows, err := p.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("could not read proxysql servers: %w", err)
+	}
+	defer utils.CloseAndLog(rows)
+	var servers []proxySQLServer
+	for rows.Next() {
+		var server proxySQLServer
+		if err := rows.Scan(&server.hostgroup, &server.hostname, &server.port); err != nil {
+			return nil, err
+		}
+		servers = append(servers, server)
+	}
+	return servers, rows.Err()
+}
+
+func (p *ProxySQL) loadToRuntime(ctx context.Context) error {
+	if _, err := p.db.ExecContext(ctx, "LOAD MYSQL SERVERS TO RUNTIME"); err != nil {
+		return fmt.Errorf("could not load proxysql servers to runtime: %w", err)
+	}
+	return nil
+}
+
+// waitForDrain waits until no backend connections in the hostgroups are
+// in use, or the drain timeout expires.
+func (p *ProxySQL) waitForDrain(ctx context.Context) error {
+	query := fmt.Sprintf("SELECT COALESCE(SUM(ConnUsed), 0) FROM stats_mysql_connection_pool WHERE hostgroup IN (%s)", joinInts(p.hostgroups))
+	deadline := time.Now().Add(p.drainTimeout)
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		var inUse int64
+		if err := p.db.QueryRowContext(ctx, query).Scan(&inUse); err != nil {
+			return fmt.Errorf("could not read proxysql connection pool: %w", err)
+		}
+		if inUse == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			p.logger.Warn("proxysql connections did not drain before the timeout", "in_use", inUse, "timeout", p.drainTimeout)
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// setStatusQuery returns the statement which sets the status of server.
+// The admin interface does not support prepared statements, so the
+// hostname is quoted here.
+func setStatusQuery(server proxySQLServer, status string) string {
+	return fmt.Sprintf("UPDATE mysql_servers SET status = '%s' WHERE hostgroup_id = %d AND hostname = %s AND port = %d",
+		status, server.hostgroup, quoteString(server.hostname), server.port)
+}
+
+func quoteString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func joinInts(values []int) string {
+	strs := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = strconv.Itoa(v)
+	}
+	return strings.Join(strs, ", ")
+}
@@ -0,0 +1,61 @@
+package coordinator
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/block/spirit/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetStatusQuery(t *testing.T) {
+	server := proxySQLServer{hostgroup: 10, hostname: "db-1.example.com", port: 3306}
+	require.Equal(t, "UPDATE mysql_servers SET status = 'OFFLINE_SOFT' WHERE hostgroup_id = 10 AND hostname = 'db-1.example.com' AND port = 3306",
+		setStatusQuery(server, "OFFLINE_SOFT"))
+	require.Equal(t, `'it''s\\'`, quoteString(`it's\`))
+	require.Equal(t, "1, 2, 30", joinInts([]int{1, 2, 30}))
+}
+
+func TestNewProxySQL(t *testing.T) {
+	logger := slog.Default()
+	_, err := NewProxySQL("", []int{1}, 0, logger)
+	require.ErrorContains(t, err, "requires an admin DSN")
+	_, err = NewProxySQL("admin:admin@tcp(127.0.0.1:6032)/", nil, 0, logger)
+	require.ErrorContains(t, err, "at least one hostgroup")
+	p, err := NewProxySQL("admin:admin@tcp(127.0.0.1:6032)/", []int{1}, 0, logger)
+	require.NoError(t, err)
+	require.Equal(t, DefaultPauseTimeout, p.drainTimeout)
+	require.NoError(t, p.Close())
+}
+
+// TestProxySQLPauseResume requires a ProxySQL admin interface, with at
+// least one ONLINE server in hostgroup 0.
+func TestProxySQLPauseResume(t *testing.T) {
+	adminDSN := os.Getenv("PROXYSQL_ADMIN_DSN")
+	if adminDSN == "" {
+		t.Skip("skipping proxysql tests because PROXYSQL_ADMIN_DSN not set")
+	}
+	p, err := NewProxySQL(adminDSN, []int{0}, time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	defer utils.CloseAndLog(p)
+
+	online, err := p.onlineServers(t.Context())
+	require.NoError(t, err)
+	require.NotEmpty(t, online)
+
+	require.NoError(t, p.Pause(t.Context()))
+	require.Equal(t, online, p.paused)
+	stillOnline, err := p.onlineServers(t.Context())
+	require.NoError(t, err)
+	require.Empty(t, stillOnline)
+
+	require.NoError(t, p.Resume(t.Context()))
+	require.Empty(t, p.paused)
+	onlineAgain, err := p.onlineServers(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, online, onlineAgain)
+	require.NoError(t, p.Resume(t.Context())) // not paused
+}
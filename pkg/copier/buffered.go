@@ -41,6 +41,7 @@ type buffered struct {
 	logger           *slog.Logger
 	metricsSink      metrics.Sink
 	copierEtaHistory *copierEtaHistory
+	snapshotPool     *dbconn.TrxPool
 }
 
 // Assert that buffered implements the Copier interface
@@ -61,7 +62,20 @@ func (c *buffered) readChunkData(ctx context.Context, chunk *table.Chunk) ([][]a
 	// Use the chunk's table DB connection so each chunk reads from its own source.
 	// This is important for N:M moves where chunks from different sources
 	// need to read from different database connections.
-	rows, err := chunk.Table.DB().QueryContext(ctx, query)
+	// If a snapshot pool is set, read from one of its transactions instead
+	// so that every chunk sees the same read-view.
+	var rows *sql.Rows
+	var err error
+	if c.snapshotPool != nil {
+		trx, poolErr := c.snapshotPool.Get()
+		if poolErr != nil {
+			return nil, poolErr
+		}
+		defer c.snapshotPool.Put(trx)
+		rows, err = trx.QueryContext(ctx, query)
+	} else {
+		rows, err = chunk.Table.DB().QueryContext(ctx, query)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query chunk data: %w", err)
 	}
@@ -55,6 +55,12 @@ type CopierConfig struct {
 	// INSERT IGNORE INTO _new ... SELECT FROM original directly). Defaults to
 	// false (unbuffered).
 	Buffered bool
+	// SnapshotPool optionally makes the buffered copier read every chunk
+	// from a transaction in the pool instead of from the chunk's table DB.
+	// When all transactions share a read-view, the copy is a consistent
+	// snapshot of the source. The pool must hold at least Concurrency
+	// transactions. It is ignored by the unbuffered copier.
+	SnapshotPool *dbconn.TrxPool
 }
 
 // NewCopierDefaultConfig returns a default config for the copier.
@@ -94,6 +100,7 @@ func NewCopier(db *sql.DB, chunker table.Chunker, config *CopierConfig) (Copier,
 			dbConfig:         config.DBConfig,
 			copierEtaHistory: newcopierEtaHistory(),
 			applier:          config.Applier,
+			snapshotPool:     config.SnapshotPool,
 		}, nil
 	}
 	return &Unbuffered{
@@ -0,0 +1,172 @@
+package dbconn
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/block/spirit/pkg/table"
+	"github.com/block/spirit/pkg/utils"
+)
+
+const (
+	// BlockerQuery finds the connections which hold or are waiting for
+	// metadata locks on tables, along with the age of their transaction.
+	BlockerQuery = `SELECT
+    t.processlist_id,
+    t.processlist_user,
+    t.processlist_host,
+    t.processlist_info,
+    TIMESTAMPDIFF(SECOND, trx.trx_started, NOW()),
+    ml.object_schema,
+    ml.object_name,
+    ml.lock_type,
+    ml.lock_duration,
+    ml.lock_status
+FROM
+    performance_schema.metadata_locks ml
+    JOIN performance_schema.threads t
+        ON ml.owner_thread_id = t.thread_id
+    LEFT JOIN information_schema.innodb_trx trx
+        ON t.processlist_id = trx.trx_mysql_thread_id
+WHERE t.processlist_id IS NOT NULL
+    AND ml.object_type = 'TABLE' `
+
+	// blockerQueryMaxLen is how much of a blocker's query is included
+	// in its String(). The Blocker itself keeps the full query.
+	blockerQueryMaxLen = 100
+)
+
+// BlockerLock is a row of performance_schema.metadata_locks held or
+// requested by a Blocker.
+type BlockerLock struct {
+	ObjectSchema string
+	ObjectName   string
+	LockType     string // e.g. "SHARED_READ", "SHARED_NO_READ_WRITE"
+	LockDuration string // e.g. "STATEMENT", "TRANSACTION"
+	LockStatus   string // "GRANTED" or "PENDING"
+}
+
+// Blocker is a connection which holds or is waiting for metadata locks
+// on tables that we tried to lock.
+type Blocker struct {
+	PID    int
+	User   string
+	Host   string
+	Query  string        // empty if the connection is idle
+	TrxAge time.Duration // zero if the connection has no open transaction
+	Locks  []BlockerLock
+}
+
+func (b Blocker) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "pid %d (%s@%s", b.PID, b.User, b.Host)
+	if b.TrxAge > 0 {
+		fmt.Fprintf(&builder, ", transaction open for %s", b.TrxAge)
+	}
+	builder.WriteString(")")
+	for i, lock := range b.Locks {
+		if i == 0 {
+			builder.WriteString(" ")
+		} else {
+			builder.WriteString(", ")
+		}
+		fmt.Fprintf(&builder, "%s %s on %s.%s", strings.ToLower(lock.LockStatus), lock.LockType, lock.ObjectSchema, lock.ObjectName)
+	}
+	if b.Query != "" {
+		query := b.Query
+		if len(query) > blockerQueryMaxLen {
+			query = query[:blockerQueryMaxLen] + "..."
+		}
+		fmt.Fprintf(&builder, " running %q", query)
+	}
+	return builder.String()
+}
+
+// LockWaitError is returned by NewTableLock when the tables could not be
+// locked. Blockers is a snapshot of the connections which were holding
+// or waiting for locks on the tables when the attempt failed.
+type LockWaitError struct {
+	Err      error
+	Blockers []Blocker
+}
+
+func (e *LockWaitError) Error() string {
+	if len(e.Blockers) == 0 {
+		return e.Err.Error()
+	}
+	blockers := make([]string, 0, len(e.Blockers))
+	for _, b := range e.Blockers {
+		blockers = append(blockers, b.String())
+	}
+	return fmt.Sprintf("%v; blocked by: %s", e.Err, strings.Join(blockers, "; "))
+}
+
+func (e *LockWaitError) Unwrap() error {
+	return e.Err
+}
+
+// GetLockBlockers returns the connections which hold or are waiting for
+// metadata locks on the specified tables, ordered by PID. Our own
+// connection and ignorePIDs are excluded.
+func GetLockBlockers(ctx context.Context, db *sql.DB, tables []*table.TableInfo, logger *slog.Logger, ignorePIDs []int) ([]Blocker, error) {
+	query := BlockerQuery
+	var params []any
+	inList, inParams := sliceToInList(ignorePIDs)
+	if len(inList) > 0 {
+		inList = ", " + inList
+	}
+	query += fmt.Sprintf(processIDClause, inList)
+	params = append(params, inParams...)
+	if len(tables) > 0 {
+		inList, inParams := tablesToInList(tables, logger)
+		query += fmt.Sprintf(queryTableClause, inList)
+		params = append(params, inParams...)
+	}
+	query += " ORDER BY t.processlist_id"
+
+	rows, err := db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.CloseAndLog(rows)
+
+	var blockers []Blocker
+	for rows.Next() {
+		var (
+			pid                                          int
+			user, host, info                             sql.NullString
+			trxAge                                       sql.NullInt64
+			objectSchema, objectName, lockType, duration sql.NullString
+			status                                       sql.NullString
+		)
+		if err := rows.Scan(&pid, &user, &host, &info, &trxAge,
+			&objectSchema, &objectName, &lockType, &duration, &status); err != nil {
+			return nil, err
+		}
+		if len(blockers) == 0 || blockers[len(blockers)-1].PID != pid {
+			blockers = append(blockers, Blocker{
+				PID:    pid,
+				User:   user.String,
+				Host:   host.String,
+				Query:  info.String,
+				TrxAge: time.Duration(trxAge.Int64) * time.Second,
+			})
+		}
+		b := &blockers[len(blockers)-1]
+		b.Locks = append(b.Locks, BlockerLock{
+			ObjectSchema: objectSchema.String,
+			ObjectName:   objectName.String,
+			LockType:     lockType.String,
+			LockDuration: duration.String,
+			LockStatus:   status.String,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return blockers, nil
+}
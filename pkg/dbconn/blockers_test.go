@@ -0,0 +1,86 @@
+package dbconn
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/block/spirit/pkg/table"
+	"github.com/block/spirit/pkg/testutils"
+	"github.com/block/spirit/pkg/utils"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockWaitError(t *testing.T) {
+	lockErr := &mysql.MySQLError{Number: errLockWaitTimeout, Message: "Lock wait timeout exceeded; try restarting transaction"}
+	err := &LockWaitError{Err: lockErr}
+	require.Equal(t, lockErr.Error(), err.Error())
+
+	err.Blockers = []Blocker{
+		{
+			PID:    12,
+			User:   "app",
+			Host:   "10.0.0.1:5123",
+			Query:  "SELECT * FROM t1 WHERE id = " + strings.Repeat("1", 200),
+			TrxAge: 35 * time.Second,
+			Locks: []BlockerLock{
+				{ObjectSchema: "test", ObjectName: "t1", LockType: "SHARED_READ", LockDuration: "TRANSACTION", LockStatus: "GRANTED"},
+			},
+		},
+		{
+			PID:  13,
+			User: "app",
+			Host: "10.0.0.2:5124",
+			Locks: []BlockerLock{
+				{ObjectSchema: "test", ObjectName: "t1", LockType: "SHARED_WRITE", LockDuration: "TRANSACTION", LockStatus: "PENDING"},
+				{ObjectSchema: "test", ObjectName: "t2", LockType: "SHARED_WRITE", LockDuration: "TRANSACTION", LockStatus: "GRANTED"},
+			},
+		},
+	}
+	require.Equal(t, lockErr.Error()+"; blocked by: "+
+		`pid 12 (app@10.0.0.1:5123, transaction open for 35s) granted SHARED_READ on test.t1 running "SELECT * FROM t1 WHERE id = `+strings.Repeat("1", 72)+`..."; `+
+		"pid 13 (app@10.0.0.2:5124) pending SHARED_WRITE on test.t1, granted SHARED_WRITE on test.t2",
+		err.Error())
+
+	var myErr *mysql.MySQLError
+	require.ErrorAs(t, err, &myErr)
+	require.True(t, canRetryError(err))
+	require.False(t, canRetryError(&LockWaitError{Err: errors.New("other")}))
+}
+
+func TestTableLockBlockers(t *testing.T) {
+	db, err := New(testutils.DSN(), testConfig())
+	require.NoError(t, err)
+	defer utils.CloseAndLog(db)
+	err = Exec(t.Context(), db, "DROP TABLE IF EXISTS testblockers")
+	require.NoError(t, err)
+	err = Exec(t.Context(), db, "CREATE TABLE testblockers (id INT NOT NULL PRIMARY KEY, colb int)")
+	require.NoError(t, err)
+
+	// Hold a metadata lock on the table in an open transaction.
+	trx, err := db.BeginTx(t.Context(), nil)
+	require.NoError(t, err)
+	defer trx.Rollback() //nolint:errcheck
+	_, err = trx.ExecContext(t.Context(), "SELECT * FROM testblockers")
+	require.NoError(t, err)
+	var pid int
+	require.NoError(t, trx.QueryRowContext(t.Context(), "SELECT CONNECTION_ID()").Scan(&pid))
+
+	tbl := &table.TableInfo{SchemaName: "test", TableName: "testblockers", QuotedTableName: "`testblockers`"}
+	config := testConfig()
+	config.ForceKill = false
+	_, err = NewTableLock(t.Context(), db, []*table.TableInfo{tbl}, config, slog.Default())
+	var lockErr *LockWaitError
+	require.ErrorAs(t, err, &lockErr)
+	require.Len(t, lockErr.Blockers, 1)
+	blocker := lockErr.Blockers[0]
+	require.Equal(t, pid, blocker.PID)
+	require.NotEmpty(t, blocker.User)
+	require.Equal(t, []BlockerLock{
+		{ObjectSchema: "test", ObjectName: "testblockers", LockType: "SHARED_READ", LockDuration: "TRANSACTION", LockStatus: "GRANTED"},
+	}, blocker.Locks)
+	require.Contains(t, err.Error(), "blocked by: pid ")
+}
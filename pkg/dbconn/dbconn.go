@@ -35,7 +35,8 @@ type DBConfig struct {
 	MaxOpenConnections       int
 	RangeOptimizerMaxMemSize int64
 	InterpolateParams        bool
-	ForceKill                bool // If true, kill locking transactions to acquire metadata locks (default: true)
+	ForceKill                bool          // If true, kill locking transactions to acquire metadata locks (default: true)
+	MaxLockTime              time.Duration // Maximum time work may run under a cutover table lock before it is released (0 is unlimited)
 	// TLS Configuration
 	TLSMode            string // TLS connection mode (DISABLED, PREFERRED, REQUIRED, VERIFY_CA, VERIFY_IDENTITY)
 	TLSCertificatePath string // Path to custom TLS certificate file
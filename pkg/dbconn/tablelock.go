@@ -3,6 +3,7 @@ package dbconn
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"log/slog"
 	"strings"
 	"sync"
@@ -11,7 +12,17 @@ import (
 	"github.com/block/spirit/pkg/table"
 )
 
+// ErrMaxLockTime is returned when work under a table lock does not
+// complete within DBConfig.MaxLockTime.
+var ErrMaxLockTime = errors.New("table lock held for longer than the maximum lock time")
+
+// killLockTimeout bounds the KILL of a lock's connection whose
+// deadline has passed.
+const killLockTimeout = 10 * time.Second
+
 type TableLock struct {
+	db      *sql.DB
+	pid     int // the connection holding the lock
 	tables  []*table.TableInfo
 	lockTxn *sql.Tx
 	logger  *slog.Logger
@@ -84,6 +95,26 @@ func NewTableLock(ctx context.Context, db *sql.DB, tables []*table.TableInfo, co
 	_, err = lockTxn.ExecContext(ctx, lockStmt)
 	if err != nil {
 		logger.Warn("failed to acquire table lock(s), ensure --skip-force-kill is not set and try again", "error", err)
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		// Take a snapshot of who is holding the locks, so that the
+		// operator can find the application which is blocking us.
+		blockers, blockerErr := GetLockBlockers(ctx, db, tables, logger, []int{pid})
+		if blockerErr != nil {
+			logger.Warn("could not find the connections blocking the table lock", "error", blockerErr)
+		}
+		for _, b := range blockers {
+			logger.Warn("table lock blocked by connection",
+				"pid", b.PID,
+				"user", b.User,
+				"host", b.Host,
+				"trxAge", b.TrxAge,
+				"query", b.Query,
+				"locks", b.Locks,
+			)
+		}
+		err = &LockWaitError{Err: err, Blockers: blockers}
 		return nil, err
 	}
 
@@ -91,6 +122,8 @@ func NewTableLock(ctx context.Context, db *sql.DB, tables []*table.TableInfo, co
 	// it's a critical function.
 	logger.Warn("table lock(s) acquired")
 	return &TableLock{
+		db:      db,
+		pid:     pid,
 		tables:  tables,
 		lockTxn: lockTxn,
 		logger:  logger,
@@ -111,10 +144,35 @@ func (s *TableLock) ExecUnderLock(ctx context.Context, stmts ...string) error {
 	return nil
 }
 
+// KillOnDeadline kills the connection holding the lock if ctx's deadline
+// passes. The go driver only closes its side of the connection when a
+// context ends, so without this the server would keep running the
+// statement in progress, and hold the lock, until it completes.
+//
+// stop must be called before running a statement that must not be
+// interrupted, such as a rename. It returns false if the connection has
+// already been killed, or is being killed.
+func (s *TableLock) KillOnDeadline(ctx context.Context) (stop func() bool) {
+	return context.AfterFunc(ctx, func() {
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return
+		}
+		s.logger.Warn("table lock held past its deadline, killing the connection to release it", "pid", s.pid)
+		killCtx, cancel := context.WithTimeout(context.Background(), killLockTimeout)
+		defer cancel()
+		if err := KillTransaction(killCtx, s.db, s.pid); err != nil {
+			s.logger.Warn("could not kill the connection holding the table lock", "pid", s.pid, "error", err)
+		}
+	})
+}
+
 // Close closes the table lock
 func (s *TableLock) Close(ctx context.Context) error {
 	_, err := s.lockTxn.ExecContext(ctx, "UNLOCK TABLES")
 	if err != nil {
+		// The connection may have been killed, which released the lock.
+		// The transaction is still rolled back to return it to the pool.
+		_ = s.lockTxn.Rollback()
 		return err
 	}
 	err = s.lockTxn.Rollback()
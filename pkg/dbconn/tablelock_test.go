@@ -1,10 +1,12 @@
 package dbconn
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/block/spirit/pkg/table"
 	"github.com/block/spirit/pkg/testutils"
@@ -229,3 +231,38 @@ func TestTableLockCrossSchema(t *testing.T) {
 		})
 	}
 }
+
+func TestTableLockKillOnDeadline(t *testing.T) {
+	db, err := New(testutils.DSN(), testConfig())
+	require.NoError(t, err)
+	defer utils.CloseAndLog(db)
+	err = Exec(t.Context(), db, "DROP TABLE IF EXISTS testlockdeadline")
+	require.NoError(t, err)
+	err = Exec(t.Context(), db, "CREATE TABLE testlockdeadline (id INT NOT NULL PRIMARY KEY, colb int)")
+	require.NoError(t, err)
+	tbl := &table.TableInfo{SchemaName: "test", TableName: "testlockdeadline", QuotedTableName: "`testlockdeadline`"}
+
+	// Stopped before the deadline, the lock is left alone.
+	lock, err := NewTableLock(t.Context(), db, []*table.TableInfo{tbl}, testConfig(), slog.Default())
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(t.Context(), time.Minute)
+	stop := lock.KillOnDeadline(ctx)
+	require.True(t, stop())
+	cancel()
+	require.NoError(t, lock.ExecUnderLock(t.Context(), "INSERT INTO testlockdeadline VALUES (1, 1)"))
+	require.NoError(t, lock.Close(t.Context()))
+
+	// Once the deadline passes, the connection is killed and the lock released.
+	lock, err = NewTableLock(t.Context(), db, []*table.TableInfo{tbl}, testConfig(), slog.Default())
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	stop = lock.KillOnDeadline(ctx)
+	require.Eventually(t, func() bool {
+		var n int
+		err := db.QueryRowContext(t.Context(), "SELECT COUNT(*) FROM testlockdeadline").Scan(&n)
+		return err == nil && n == 1
+	}, 10*time.Second, 50*time.Millisecond)
+	require.False(t, stop())
+	require.Error(t, lock.Close(t.Context()))
+}
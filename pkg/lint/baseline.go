@@ -0,0 +1,140 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// baselineVersion is the version of the baseline file format.
+const baselineVersion = 1
+
+var (
+	numberRe     = regexp.MustCompile(`[0-9]+(\.[0-9]+)?`)
+	whitespaceRe = regexp.MustCompile(`\s+`)
+)
+
+// ViolationID is the stable identity of a violation, used to match
+// violations against a baseline. It deliberately leaves out the severity
+// and source position, which change without the violation changing.
+type ViolationID struct {
+	Linter     string `json:"linter"`
+	Table      string `json:"table,omitempty"`
+	Column     string `json:"column,omitempty"`
+	Index      string `json:"index,omitempty"`
+	Constraint string `json:"constraint,omitempty"`
+
+	// Message is normalized so that numbers which drift over time (such as
+	// an AUTO_INCREMENT value) do not make a known violation look new.
+	Message string `json:"message"`
+}
+
+// ID returns the stable identity of the violation.
+func (v Violation) ID() ViolationID {
+	id := ViolationID{
+		Linter:  v.Linter.Name(),
+		Message: normalizeMessage(v.Message),
+	}
+	if v.Location != nil {
+		id.Table = v.Location.Table
+		if v.Location.Column != nil {
+			id.Column = *v.Location.Column
+		}
+		if v.Location.Index != nil {
+			id.Index = *v.Location.Index
+		}
+		if v.Location.Constraint != nil {
+			id.Constraint = *v.Location.Constraint
+		}
+	}
+	return id
+}
+
+// normalizeMessage replaces numbers with N and collapses whitespace.
+func normalizeMessage(msg string) string {
+	msg = numberRe.ReplaceAllString(msg, "N")
+	return strings.TrimSpace(whitespaceRe.ReplaceAllString(msg, " "))
+}
+
+// Baseline is a set of known violations. Violations in the baseline are
+// not reported, so that linting can gate CI on new violations only.
+type Baseline struct {
+	Version    int           `json:"version"`
+	Violations []ViolationID `json:"violations"`
+}
+
+// NewBaseline creates a baseline from the given violations.
+func NewBaseline(violations []Violation) *Baseline {
+	b := &Baseline{Version: baselineVersion, Violations: make([]ViolationID, 0, len(violations))}
+	for _, v := range violations {
+		b.Violations = append(b.Violations, v.ID())
+	}
+	// Sorted so that the file diffs cleanly when it is regenerated.
+	sort.Slice(b.Violations, func(i, j int) bool {
+		a, c := b.Violations[i], b.Violations[j]
+		for _, pair := range [][2]string{
+			{a.Table, c.Table},
+			{a.Linter, c.Linter},
+			{a.Column, c.Column},
+			{a.Index, c.Index},
+			{a.Constraint, c.Constraint},
+		} {
+			if pair[0] != pair[1] {
+				return pair[0] < pair[1]
+			}
+		}
+		return a.Message < c.Message
+	})
+	return b
+}
+
+// LoadBaseline reads a baseline file written by Baseline.Write.
+func LoadBaseline(path string) (*Baseline, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline %s: %w", path, err)
+	}
+	var b Baseline
+	if err := json.Unmarshal(content, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	if b.Version != baselineVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d in %s (expected %d)", b.Version, path, baselineVersion)
+	}
+	return &b, nil
+}
+
+// Write writes the baseline to a file.
+func (b *Baseline) Write(path string) error {
+	content, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(content, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write baseline %s: %w", path, err)
+	}
+	return nil
+}
+
+// Filter returns the violations that are not in the baseline, and the
+// number that were. Each baseline entry matches at most one violation, so
+// a second identical violation is still reported as new.
+func (b *Baseline) Filter(violations []Violation) (unknown []Violation, known int) {
+	remaining := make(map[ViolationID]int, len(b.Violations))
+	for _, id := range b.Violations {
+		remaining[id]++
+	}
+	for _, v := range violations {
+		id := v.ID()
+		if remaining[id] > 0 {
+			remaining[id]--
+			known++
+			continue
+		}
+		unknown = append(unknown, v)
+	}
+	return unknown, known
+}
@@ -0,0 +1,66 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestViolationID(t *testing.T) {
+	column := "id"
+	v := Violation{
+		Linter:   &AutoIncCapacityLinter{},
+		Severity: SeverityError,
+		Message:  "AUTO_INCREMENT value 3000000000 is above 85% of the capacity  (4294967295)",
+		Location: &Location{Table: "users", Column: &column, File: "users.sql", Line: 2},
+	}
+	id := v.ID()
+	require.Equal(t, ViolationID{
+		Linter:  "auto_inc_capacity",
+		Table:   "users",
+		Column:  "id",
+		Message: "AUTO_INCREMENT value N is above N% of the capacity (N)",
+	}, id)
+
+	// Numbers, severity and position do not change the identity.
+	v.Message = "AUTO_INCREMENT value 3500000000 is above 85% of the capacity (4294967295)"
+	v.Severity = SeverityWarning
+	v.Location.Line = 7
+	require.Equal(t, id, v.ID())
+}
+
+func TestBaseline(t *testing.T) {
+	users, orders := "users", "orders"
+	balance := "balance"
+	floatIn := func(table string) Violation {
+		return Violation{
+			Linter:   &HasFloatLinter{},
+			Severity: SeverityWarning,
+			Message:  "Column \"balance\" in table \"" + table + "\" uses float data type",
+			Location: &Location{Table: table, Column: &balance},
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	require.NoError(t, NewBaseline([]Violation{floatIn(users)}).Write(path))
+	baseline, err := LoadBaseline(path)
+	require.NoError(t, err)
+	require.Len(t, baseline.Violations, 1)
+
+	// Only the violation that is not in the baseline is reported, and a
+	// duplicate of a known violation is reported as new.
+	unknown, known := baseline.Filter([]Violation{floatIn(users), floatIn(orders), floatIn(users)})
+	require.Equal(t, 1, known)
+	require.Len(t, unknown, 2)
+	require.Equal(t, orders, unknown[0].Location.Table)
+	require.Equal(t, users, unknown[1].Location.Table)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 99, "violations": []}`), 0o600))
+	_, err = LoadBaseline(path)
+	require.ErrorContains(t, err, "unsupported baseline version")
+
+	_, err = LoadBaseline(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
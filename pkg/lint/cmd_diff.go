@@ -25,6 +25,12 @@ type DiffCmd struct {
 
 	// Filtering
 	IgnoreTables string `help:"Regex pattern of table names to ignore" default:""`
+
+	// Configuration
+	LintConfig string `help:"Lint configuration file (default: .spirit-lint.yaml in the working directory)" type:"existingfile"`
+
+	// Output
+	Format string `help:"Output format: text, json, sarif or github" enum:"text,json,sarif,github" default:"text"`
 }
 
 // Run executes the diff command. It is called by Kong.
@@ -42,7 +48,7 @@ func (cmd *DiffCmd) Run() error {
 	}
 
 	// 2. Build lint config from flags.
-	config, err := buildIgnoreTablesConfig(cmd.IgnoreTables, source)
+	config, err := buildConfig(cmd.LintConfig, cmd.IgnoreTables, source)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error building config: %s\n", err)
 		os.Exit(2)
@@ -59,17 +65,24 @@ func (cmd *DiffCmd) Run() error {
 			os.Exit(2)
 		}
 		config.LintOnlyChanges = true
-		violations, err := RunLinters(source, changes, config)
+		result, err := Run(source, changes, config)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error running linters: %s\n", err)
 			os.Exit(2)
 		}
-		printViolationsAsSQL(violations)
-		if len(violations) > 0 && len(changes) > 0 {
-			fmt.Println()
+		resolvePositions(append(result.Violations, result.Suppressed...), source)
+		if cmd.Format == FormatText {
+			printViolationsAsSQL(result.Violations)
+			printSuppressed(result.Suppressed, "-- ")
+			if len(result.Violations)+len(result.Suppressed) > 0 && len(changes) > 0 {
+				fmt.Println()
+			}
+			printDiff(changes)
+		} else if err := writeReport(os.Stdout, cmd.Format, result.Violations, result.Suppressed, diffStatements(changes)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %s\n", err)
+			os.Exit(2)
 		}
-		printDiff(changes)
-		if HasErrors(violations) {
+		if HasErrors(result.Violations) {
 			os.Exit(1)
 		}
 	} else {
@@ -79,22 +92,24 @@ func (cmd *DiffCmd) Run() error {
 			fmt.Fprintf(os.Stderr, "Error loading target schema: %s\n", err)
 			os.Exit(2)
 		}
-		currentSchemas, err := createTablesToTableSchemas(source)
+		plan, err := planDiff(source, target, &config)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error converting source schema: %s\n", err)
+			fmt.Fprintf(os.Stderr, "Error planning changes: %s\n", err)
 			os.Exit(2)
 		}
-		targetSchemas, err := createTablesToTableSchemas(target)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error converting target schema: %s\n", err)
-			os.Exit(2)
+		// Violations are reported against the target files, which
+		// hold the proposed changes.
+		var violations []Violation
+		for _, ch := range plan.Changes {
+			violations = append(violations, ch.Violations...)
 		}
-		plan, err := PlanChanges(currentSchemas, targetSchemas, nil, &config)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error planning changes: %s\n", err)
+		resolvePositions(append(violations, plan.Suppressed...), target)
+		if cmd.Format == FormatText {
+			printPlan(plan)
+		} else if err := writeReport(os.Stdout, cmd.Format, violations, plan.Suppressed, plan.Statements()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %s\n", err)
 			os.Exit(2)
 		}
-		printPlan(plan)
 		if plan.HasErrors() {
 			os.Exit(1)
 		}
@@ -123,6 +138,21 @@ func loadAlterChanges(alters []string) ([]*statement.AbstractStatement, error) {
 	return changes, nil
 }
 
+// planDiff diffs the source schema against the target and lints the
+// changes. The loaded tables are passed to the linters as they are, so that
+// the spirit-lint:ignore comments in their files still apply.
+func planDiff(source, target []*statement.CreateTable, config *Config) (*Plan, error) {
+	currentSchemas, err := createTablesToTableSchemas(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert source schema: %w", err)
+	}
+	targetSchemas, err := createTablesToTableSchemas(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert target schema: %w", err)
+	}
+	return planChanges(currentSchemas, targetSchemas, source, target, nil, config)
+}
+
 // createTablesToTableSchemas converts parsed CreateTable objects to
 // table.TableSchema values for use with PlanChanges.
 func createTablesToTableSchemas(tables []*statement.CreateTable) ([]table.TableSchema, error) {
@@ -149,6 +179,10 @@ func printPlan(plan *Plan) {
 			hasViolations = true
 		}
 	}
+	if len(plan.Suppressed) > 0 {
+		printSuppressed(plan.Suppressed, "-- ")
+		hasViolations = true
+	}
 
 	if hasViolations && plan.HasChanges() {
 		fmt.Println()
@@ -182,14 +216,23 @@ func printDiff(changes []*statement.AbstractStatement) {
 		return
 	}
 
-	// Sort changes by table name for consistent output
+	for _, stmt := range diffStatements(changes) {
+		fmt.Println(stmt)
+	}
+}
+
+// diffStatements returns the DDL statements sorted by table name for
+// consistent output, each terminated with a semicolon.
+func diffStatements(changes []*statement.AbstractStatement) []string {
 	sorted := make([]*statement.AbstractStatement, len(changes))
 	copy(sorted, changes)
 	sort.Slice(sorted, func(i, j int) bool {
 		return sorted[i].Table < sorted[j].Table
 	})
 
-	for _, ch := range sorted {
-		fmt.Printf("%s;\n", strings.TrimSuffix(ch.Statement, ";"))
+	stmts := make([]string, len(sorted))
+	for i, ch := range sorted {
+		stmts[i] = strings.TrimSuffix(ch.Statement, ";") + ";"
 	}
+	return stmts
 }
@@ -241,6 +241,39 @@ func TestDiff_DirToDirIntegration(t *testing.T) {
 	}
 }
 
+func TestDiff_DirSuppressions(t *testing.T) {
+	sourceDir := t.TempDir()
+	writeFile(t, sourceDir, "t1.sql", `CREATE TABLE t1 (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		PRIMARY KEY (id)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`)
+
+	targetDir := t.TempDir()
+	writeFile(t, targetDir, "t1.sql", `CREATE TABLE t1 (
+		id bigint unsigned NOT NULL AUTO_INCREMENT,
+		-- spirit-lint:ignore has_float
+		balance float DEFAULT NULL,
+		PRIMARY KEY (id)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`)
+
+	source, err := LoadSchemaFromDir(sourceDir)
+	require.NoError(t, err)
+	target, err := LoadSchemaFromDir(targetDir)
+	require.NoError(t, err)
+	require.Len(t, target[0].Suppressions, 1)
+
+	cfg := &Config{Enabled: map[string]bool{}}
+	for _, name := range List() {
+		cfg.Enabled[name] = name == "has_float"
+	}
+	plan, err := planDiff(source, target, cfg)
+	require.NoError(t, err)
+	require.True(t, plan.HasChanges())
+	require.False(t, plan.HasWarnings())
+	require.Len(t, plan.Suppressed, 1)
+	require.Equal(t, "has_float", plan.Suppressed[0].Linter.Name())
+}
+
 func TestDiff_AlterIntegration(t *testing.T) {
 	source := parseCreateTables(t,
 		`CREATE TABLE users (
@@ -18,6 +18,16 @@ type LintCmd struct {
 
 	// Filtering
 	IgnoreTables string `help:"Regex pattern of table names to ignore" default:""`
+
+	// Configuration
+	LintConfig string `help:"Lint configuration file (default: .spirit-lint.yaml in the working directory)" type:"existingfile"`
+
+	// Output
+	Format string `help:"Output format: text, json, sarif or github" enum:"text,json,sarif,github" default:"text"`
+
+	// Baselines
+	Baseline      string `help:"Only report violations that are not recorded in this baseline file" type:"existingfile" xor:"baseline"`
+	WriteBaseline string `help:"Record all current violations in this baseline file and exit" xor:"baseline"`
 }
 
 // Run executes the lint command. It is called by Kong.
@@ -32,24 +42,53 @@ func (cmd *LintCmd) Run() error {
 	}
 
 	// 2. Build config — lint everything, no LintOnlyChanges
-	config, err := buildIgnoreTablesConfig(cmd.IgnoreTables, source)
+	config, err := buildConfig(cmd.LintConfig, cmd.IgnoreTables, source)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error building config: %s\n", err)
 		os.Exit(2)
 	}
 
 	// 3. Run linters with no changes (lint entire schema)
-	violations, err := RunLinters(source, nil, config)
+	result, err := Run(source, nil, config)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running linters: %s\n", err)
 		os.Exit(2)
 	}
 
-	// 4. Print violations
-	printViolations(violations)
+	// 4. Record or apply the baseline
+	if cmd.WriteBaseline != "" {
+		if err := NewBaseline(result.Violations).Write(cmd.WriteBaseline); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing baseline: %s\n", err)
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %d violation(s) to baseline %s\n", len(result.Violations), cmd.WriteBaseline)
+		return nil
+	}
+	var known int
+	if cmd.Baseline != "" {
+		baseline, err := LoadBaseline(cmd.Baseline)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading baseline: %s\n", err)
+			os.Exit(2)
+		}
+		result.Violations, known = baseline.Filter(result.Violations)
+	}
+
+	// 5. Print violations, and those silenced by spirit-lint:ignore
+	resolvePositions(append(result.Violations, result.Suppressed...), source)
+	if cmd.Format == FormatText {
+		printViolations(result.Violations)
+		printSuppressed(result.Suppressed, "")
+		if known > 0 {
+			fmt.Printf("Ignored %d violation(s) recorded in baseline %s\n", known, cmd.Baseline)
+		}
+	} else if err := writeReport(os.Stdout, cmd.Format, result.Violations, result.Suppressed, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %s\n", err)
+		os.Exit(2)
+	}
 
-	// 5. Exit code
-	if HasErrors(violations) {
+	// 6. Exit code
+	if HasErrors(result.Violations) {
 		os.Exit(1)
 	}
 
@@ -64,6 +103,23 @@ func loadSource(ctx context.Context, dsn, dir string) ([]*statement.CreateTable,
 	return LoadSchemaFromDir(dir)
 }
 
+// buildConfig constructs a Config from the lint configuration file (explicit
+// or discovered in the working directory) and the --ignore-tables pattern.
+func buildConfig(configPath, pattern string, source []*statement.CreateTable) (Config, error) {
+	config, err := buildIgnoreTablesConfig(pattern, source)
+	if err != nil {
+		return Config{}, err
+	}
+	fileConfig, err := LoadConfigFile(configPath)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := fileConfig.Apply(&config, source); err != nil {
+		return Config{}, fmt.Errorf("invalid lint config: %w", err)
+	}
+	return config, nil
+}
+
 // buildIgnoreTablesConfig constructs a Config with IgnoreTables populated
 // from a regex pattern matched against the source schema.
 func buildIgnoreTablesConfig(pattern string, source []*statement.CreateTable) (Config, error) {
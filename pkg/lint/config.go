@@ -0,0 +1,220 @@
+package lint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/block/spirit/pkg/statement"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileNames are the file names searched for in the working directory
+// when no lint configuration file is given explicitly, in order of preference.
+var ConfigFileNames = []string{".spirit-lint.yaml", ".spirit-lint.yml"}
+
+// FileConfig is the on-disk lint configuration shared by spirit lint,
+// spirit diff and spirit migrate --lint. For example:
+//
+//	ignore_tables: "^_"
+//	linters:
+//	  has_float:
+//	    enabled: false
+//	  invisible_index_before_drop:
+//	    settings:
+//	      raiseError: false
+//	  has_timestamp:
+//	    severity: error
+//	    tables:
+//	      legacy_events: warning
+//	plugins:
+//	  - name: pii_tagging
+//	    command: ["./bin/pii-linter", "--strict"]
+type FileConfig struct {
+	// IgnoreTables is a regex of table names whose violations are discarded.
+	IgnoreTables string `yaml:"ignore_tables"`
+
+	// Linters maps linter names to their configuration.
+	Linters map[string]LinterFileConfig `yaml:"linters"`
+
+	// Plugins are external linters (see ExternalLinter). They are
+	// registered before the linters section is applied, so they can be
+	// configured there like any built-in linter. Since they run arbitrary
+	// commands, they are refused in a file discovered in the working
+	// directory, and must be given explicitly with --lint-config.
+	Plugins []PluginFileConfig `yaml:"plugins"`
+
+	// discovered is set when the file was found in the working directory
+	// rather than given explicitly.
+	discovered bool
+
+	// dir is the directory of the file, which relative plugin commands
+	// are resolved against.
+	dir string
+}
+
+// PluginFileConfig is the configuration of an external linter.
+// A relative Command path (such as ./bin/linter) is resolved against the
+// directory of the configuration file; a bare name is looked up in PATH.
+type PluginFileConfig struct {
+	Name        string        `yaml:"name"`
+	Description string        `yaml:"description"`
+	Command     []string      `yaml:"command"`
+	Timeout     time.Duration `yaml:"timeout"`
+}
+
+// LinterFileConfig is the configuration of a single linter in a FileConfig.
+type LinterFileConfig struct {
+	// Enabled enables or disables the linter. If unset, the linter
+	// keeps its default enabled state.
+	Enabled *bool `yaml:"enabled"`
+
+	// Severity overrides the severity of every violation from this linter.
+	Severity string `yaml:"severity"`
+
+	// Settings are passed to the linter's Configure method and override
+	// its defaults key by key.
+	Settings map[string]string `yaml:"settings"`
+
+	// Tables overrides the severity for violations on specific tables.
+	// It takes precedence over Severity.
+	Tables map[string]string `yaml:"tables"`
+}
+
+// LoadConfigFile reads a lint configuration file. If path is empty, the
+// working directory is searched for one of ConfigFileNames. A nil FileConfig
+// is returned (without error) when path is empty and no file is found.
+func LoadConfigFile(path string) (*FileConfig, error) {
+	discovered := false
+	if path == "" {
+		for _, name := range ConfigFileNames {
+			if _, err := os.Stat(name); err == nil {
+				path = name
+				discovered = true
+				break
+			}
+		}
+		if path == "" {
+			return nil, nil
+		}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lint config %s: %w", path, err)
+	}
+	config, err := ParseConfig(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse lint config %s: %w", path, err)
+	}
+	config.discovered = discovered
+	config.dir = filepath.Dir(path)
+	return config, nil
+}
+
+// ParseConfig parses the YAML contents of a lint configuration file.
+// Unknown keys are rejected so that typos do not silently change policy.
+func ParseConfig(content []byte) (*FileConfig, error) {
+	var config FileConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Apply merges the file configuration into config. Linter settings are
+// merged key by key over any settings already present in config, so callers
+// can start from their own defaults. The tables are used to resolve the
+// ignore_tables regex. Plugins are registered with the global registry.
+// An error is returned for unknown linters, plugins in a discovered file,
+// and invalid plugins, severities or regexes.
+func (f *FileConfig) Apply(config *Config, tables []*statement.CreateTable) error {
+	if f == nil {
+		return nil
+	}
+	if f.IgnoreTables != "" {
+		re, err := regexp.Compile(f.IgnoreTables)
+		if err != nil {
+			return fmt.Errorf("invalid ignore_tables regex %q: %w", f.IgnoreTables, err)
+		}
+		for _, ct := range tables {
+			if re.MatchString(ct.TableName) {
+				if config.IgnoreTables == nil {
+					config.IgnoreTables = make(map[string]bool)
+				}
+				config.IgnoreTables[ct.TableName] = true
+			}
+		}
+	}
+	if len(f.Plugins) > 0 && f.discovered {
+		return errors.New("plugins are only loaded from a lint config given explicitly with --lint-config")
+	}
+	for _, pc := range f.Plugins {
+		if pc.Name == "" || len(pc.Command) == 0 {
+			return errors.New("plugins must have a name and a command")
+		}
+		command := slices.Clone(pc.Command)
+		if f.dir != "" && strings.ContainsRune(command[0], filepath.Separator) && !filepath.IsAbs(command[0]) {
+			command[0] = filepath.Join(f.dir, command[0])
+		}
+		if existing, err := Get(pc.Name); err == nil {
+			if _, ok := existing.(*ExternalLinter); !ok {
+				return fmt.Errorf("plugin %q has the same name as a built-in linter", pc.Name)
+			}
+		}
+		Register(NewExternalLinter(pc.Name, pc.Description, command, pc.Timeout))
+	}
+	for name, lc := range f.Linters {
+		if _, err := Get(name); err != nil {
+			return err
+		}
+		if lc.Enabled != nil {
+			if config.Enabled == nil {
+				config.Enabled = make(map[string]bool)
+			}
+			config.Enabled[name] = *lc.Enabled
+		}
+		if len(lc.Settings) > 0 {
+			if config.Settings == nil {
+				config.Settings = make(map[string]map[string]string)
+			}
+			merged := make(map[string]string)
+			maps.Copy(merged, config.Settings[name])
+			maps.Copy(merged, lc.Settings)
+			config.Settings[name] = merged
+		}
+		if lc.Severity != "" {
+			severity, err := ParseSeverity(lc.Severity)
+			if err != nil {
+				return fmt.Errorf("linter %s: %w", name, err)
+			}
+			if config.Severities == nil {
+				config.Severities = make(map[string]Severity)
+			}
+			config.Severities[name] = severity
+		}
+		for tbl, s := range lc.Tables {
+			severity, err := ParseSeverity(s)
+			if err != nil {
+				return fmt.Errorf("linter %s, table %s: %w", name, tbl, err)
+			}
+			if config.TableSeverities == nil {
+				config.TableSeverities = make(map[string]map[string]Severity)
+			}
+			if config.TableSeverities[name] == nil {
+				config.TableSeverities[name] = make(map[string]Severity)
+			}
+			config.TableSeverities[name][tbl] = severity
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig([]byte(`
+ignore_tables: "^_"
+linters:
+  has_float:
+    enabled: false
+  invisible_index_before_drop:
+    settings:
+      raiseError: false
+  has_timestamp:
+    severity: error
+    tables:
+      legacy_events: warning
+`))
+	require.NoError(t, err)
+	require.Equal(t, "^_", config.IgnoreTables)
+	require.NotNil(t, config.Linters["has_float"].Enabled)
+	require.False(t, *config.Linters["has_float"].Enabled)
+	require.Equal(t, "false", config.Linters["invisible_index_before_drop"].Settings["raiseError"])
+	require.Equal(t, "error", config.Linters["has_timestamp"].Severity)
+	require.Equal(t, "warning", config.Linters["has_timestamp"].Tables["legacy_events"])
+
+	// An empty file is valid.
+	config, err = ParseConfig(nil)
+	require.NoError(t, err)
+	require.Empty(t, config.Linters)
+
+	// Unknown keys are rejected.
+	_, err = ParseConfig([]byte("linters:\n  has_float:\n    enable: false\n"))
+	require.Error(t, err)
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	// Nothing to discover.
+	config, err := LoadConfigFile("")
+	require.NoError(t, err)
+	require.Nil(t, config)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".spirit-lint.yaml"), []byte("ignore_tables: \"^tmp\"\n"), 0o600))
+	config, err = LoadConfigFile("")
+	require.NoError(t, err)
+	require.Equal(t, "^tmp", config.IgnoreTables)
+
+	_, err = LoadConfigFile(filepath.Join(dir, "missing.yaml"))
+	require.Error(t, err)
+}
+
+func TestFileConfigApply(t *testing.T) {
+	source := parseCreateTables(t,
+		`CREATE TABLE _tmp (id bigint unsigned NOT NULL, PRIMARY KEY (id))`,
+		`CREATE TABLE users (id bigint unsigned NOT NULL, PRIMARY KEY (id))`,
+	)
+	fileConfig, err := ParseConfig([]byte(`
+ignore_tables: "^_"
+linters:
+  has_float:
+    enabled: false
+  invisible_index_before_drop:
+    settings:
+      raiseError: false
+  has_timestamp:
+    severity: error
+    tables:
+      legacy_events: info
+`))
+	require.NoError(t, err)
+
+	config := Config{Settings: map[string]map[string]string{
+		"invisible_index_before_drop": {"raiseError": "true"},
+	}}
+	require.NoError(t, fileConfig.Apply(&config, source))
+	require.True(t, config.IgnoreTables["_tmp"])
+	require.False(t, config.IgnoreTables["users"])
+	require.False(t, config.Enabled["has_float"])
+	require.Equal(t, "false", config.Settings["invisible_index_before_drop"]["raiseError"])
+	require.Equal(t, SeverityError, config.Severities["has_timestamp"])
+	require.Equal(t, SeverityInfo, config.TableSeverities["has_timestamp"]["legacy_events"])
+
+	// A nil FileConfig is a no-op.
+	var none *FileConfig
+	require.NoError(t, none.Apply(&config, source))
+
+	fileConfig, err = ParseConfig([]byte("linters:\n  no_such_linter:\n    enabled: true\n"))
+	require.NoError(t, err)
+	require.ErrorContains(t, fileConfig.Apply(&Config{}, nil), "no_such_linter")
+
+	fileConfig, err = ParseConfig([]byte("linters:\n  has_float:\n    severity: fatal\n"))
+	require.NoError(t, err)
+	require.ErrorContains(t, fileConfig.Apply(&Config{}, nil), "invalid severity")
+}
+
+func TestRunLinters_SeverityOverrides(t *testing.T) {
+	source := parseCreateTables(t,
+		`CREATE TABLE users (id bigint unsigned NOT NULL, balance float, PRIMARY KEY (id))`,
+		`CREATE TABLE orders (id bigint unsigned NOT NULL, total float, PRIMARY KEY (id))`,
+	)
+	config := Config{
+		Enabled:         map[string]bool{},
+		Severities:      map[string]Severity{"has_float": SeverityError},
+		TableSeverities: map[string]map[string]Severity{"has_float": {"orders": SeverityInfo}},
+	}
+	for _, name := range List() {
+		config.Enabled[name] = name == "has_float"
+	}
+	violations, err := RunLinters(source, nil, config)
+	require.NoError(t, err)
+	require.Len(t, violations, 2)
+	for _, v := range violations {
+		if v.Location.Table == "orders" {
+			require.Equal(t, SeverityInfo, v.Severity)
+		} else {
+			require.Equal(t, SeverityError, v.Severity)
+		}
+	}
+}
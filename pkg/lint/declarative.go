@@ -53,6 +53,10 @@ func (c *PlannedChange) filterBySeverity(severity Severity) []Violation {
 type Plan struct {
 	// Changes is the ordered list of DDL changes with per-statement lint results.
 	Changes []PlannedChange
+
+	// Suppressed holds the violations silenced by spirit-lint:ignore
+	// directives in the current or desired schema.
+	Suppressed []Violation
 }
 
 // HasChanges returns true if the plan contains any DDL statements.
@@ -113,33 +117,52 @@ func (p *Plan) Statements() []string {
 //   - diffOpts: options for the diff (nil uses defaults)
 //   - lintConfig: configuration for linting (nil uses defaults; LintOnlyChanges is always overridden to true)
 func PlanChanges(current, desired []table.TableSchema, diffOpts *statement.DiffOptions, lintConfig *Config) (*Plan, error) {
-	// 1. Compute the diff.
-	changes, err := statement.DeclarativeToImperative(current, desired, diffOpts)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compute schema diff: %w", err)
-	}
-
-	// 2. Parse the current schemas for linting.
-	var createTables []*statement.CreateTable
+	// Parse the schemas for linting.
+	var currentTables, desiredTables []*statement.CreateTable
 	for _, t := range current {
 		ct, err := statement.ParseCreateTable(t.Schema)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse current schema for table %q: %w", t.Name, err)
 		}
-		createTables = append(createTables, ct)
+		currentTables = append(currentTables, ct)
 	}
+	for _, t := range desired {
+		ct, err := statement.ParseCreateTable(t.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse desired schema for table %q: %w", t.Name, err)
+		}
+		desiredTables = append(desiredTables, ct)
+	}
+	return planChanges(current, desired, currentTables, desiredTables, diffOpts, lintConfig)
+}
 
-	// 3. Run linters.
+// planChanges is PlanChanges with the schemas already parsed. The parsed
+// tables supply the suppression directives, which is why callers that
+// loaded them from files pass them in: restoring a CREATE TABLE into a
+// table.TableSchema drops its SQL comments.
+func planChanges(current, desired []table.TableSchema, currentTables, desiredTables []*statement.CreateTable, diffOpts *statement.DiffOptions, lintConfig *Config) (*Plan, error) {
+	// 1. Compute the diff.
+	changes, err := statement.DeclarativeToImperative(current, desired, diffOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute schema diff: %w", err)
+	}
+
+	// 2. Run linters against the current schema.
 	cfg := Config{LintOnlyChanges: true}
 	if lintConfig != nil {
 		cfg = *lintConfig
 		cfg.LintOnlyChanges = true
 	}
-	violations, err := RunLinters(createTables, changes, cfg)
+	result, err := Run(currentTables, changes, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to run linters: %w", err)
 	}
 
+	// 3. The changes are generated ALTERs that carry no comments, so
+	// suppressions in the desired schema are applied here.
+	violations, suppressed := splitSuppressed(result.Violations, CreateTableStatements(desiredTables))
+	suppressed = append(result.Suppressed, suppressed...)
+
 	// 4. Build per-table violation map.
 	// Linters operate at the table level, so violations are grouped by table
 	// name. In step 5, they are attached only to the last statement for each
@@ -162,7 +185,7 @@ func PlanChanges(current, desired []table.TableSchema, diffOpts *statement.DiffO
 	// type changes require REMOVE PARTITIONING then PARTITION BY). Violations
 	// are attached only to the last statement for each table so they are not
 	// duplicated.
-	plan := &Plan{}
+	plan := &Plan{Suppressed: suppressed}
 	lastIndexByTable := make(map[string]int)
 	for i, ch := range changes {
 		plan.Changes = append(plan.Changes, PlannedChange{
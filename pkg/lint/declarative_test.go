@@ -149,7 +149,9 @@ func TestPlanChanges_StructuredViolations(t *testing.T) {
 			CONSTRAINT fk_parent FOREIGN KEY (parent_id) REFERENCES parent(id)
 		)`},
 	}
-	plan, err := PlanChanges(current, desired, nil, nil)
+	// foreign_key_compatibility also warns that parent_id is not indexed,
+	// so disable it.
+	plan, err := PlanChanges(current, desired, nil, &Config{Enabled: map[string]bool{"foreign_key_compatibility": false}})
 	require.NoError(t, err)
 	require.Len(t, plan.Changes, 1)
 
@@ -188,13 +190,16 @@ func TestPlanChanges_WithLintConfig(t *testing.T) {
 	cfg := &Config{
 		Enabled: map[string]bool{
 			"has_foreign_key": false,
+			// Also warns that parent_id is not indexed.
+			"foreign_key_compatibility": false,
 		},
 	}
 	plan, err := PlanChanges(current, desired, nil, cfg)
 	require.NoError(t, err)
 	require.True(t, plan.HasChanges())
-	// has_foreign_key is disabled and is the only linter that would fire
-	// on this schema, so there should be no warnings at all.
+	// has_foreign_key and foreign_key_compatibility are disabled and are
+	// the only linters that would fire on this schema, so there should be
+	// no warnings at all.
 	require.False(t, plan.HasWarnings(), "expected no warnings when has_foreign_key is disabled")
 	for _, ch := range plan.Changes {
 		require.Empty(t, ch.Warnings(), "expected no warnings on any change")
@@ -231,9 +236,9 @@ func TestPlanChanges_WithInfos(t *testing.T) {
 
 	// The test_info linter should produce an info for the ALTER TABLE change.
 	require.True(t, plan.HasInfos(), "expected lint infos from test_info linter")
-	require.NotEmpty(t, plan.Changes[0].Infos())
-	require.Equal(t, "test_info", plan.Changes[0].Infos()[0].Linter.Name())
-	require.Contains(t, plan.Changes[0].Infos()[0].Message, "informational suggestion")
+	infos := FilterByLinter(plan.Changes[0].Infos(), "test_info")
+	require.NotEmpty(t, infos)
+	require.Contains(t, infos[0].Message, "informational suggestion")
 
 	// Infos should not appear as warnings or errors.
 	require.Empty(t, plan.Changes[0].Errors())
@@ -246,10 +251,11 @@ func TestPlanChanges_HasInfosFalseWhenNone(t *testing.T) {
 	desired := []table.TableSchema{
 		{Name: "t1", Schema: "CREATE TABLE t1 (id BIGINT PRIMARY KEY, name VARCHAR(100))"},
 	}
-	plan, err := PlanChanges(current, desired, nil, nil)
+	// alter_algorithm reports every ALTER as info, so disable it.
+	plan, err := PlanChanges(current, desired, nil, &Config{Enabled: map[string]bool{"alter_algorithm": false}})
 	require.NoError(t, err)
 	require.True(t, plan.HasChanges())
-	// No info-producing linter is registered, so HasInfos should be false.
+	// No other info-producing linter is registered, so HasInfos should be false.
 	require.False(t, plan.HasInfos())
 	require.Empty(t, plan.Changes[0].Infos())
 }
@@ -333,3 +339,28 @@ func TestTerminatedStmt(t *testing.T) {
 	require.Equal(t, "", terminatedStmt(""))
 	require.Equal(t, "", terminatedStmt("   "))
 }
+
+func TestPlanChanges_Suppressed(t *testing.T) {
+	current := []table.TableSchema{
+		{Name: "t1", Schema: `CREATE TABLE t1 (
+			id BIGINT UNSIGNED PRIMARY KEY
+		)`},
+	}
+	desired := []table.TableSchema{
+		{Name: "t1", Schema: `CREATE TABLE t1 (
+			id BIGINT UNSIGNED PRIMARY KEY,
+			-- spirit-lint:ignore has_float
+			balance FLOAT
+		)`},
+	}
+	cfg := &Config{Enabled: map[string]bool{}}
+	for _, name := range List() {
+		cfg.Enabled[name] = name == "has_float"
+	}
+	plan, err := PlanChanges(current, desired, nil, cfg)
+	require.NoError(t, err)
+	require.True(t, plan.HasChanges())
+	require.False(t, plan.HasWarnings())
+	require.Len(t, plan.Suppressed, 1)
+	require.Equal(t, "has_float", plan.Suppressed[0].Linter.Name())
+}
@@ -54,6 +54,11 @@
 //	        },
 //	    },
 //	}
+//
+// Severities can be overridden per linter, or per linter and table, with
+// Config.Severities and Config.TableSeverities. The same configuration can be
+// read from a .spirit-lint.yaml file with LoadConfigFile and merged into a
+// Config with FileConfig.Apply.
 package lint
 
 import (
@@ -62,6 +67,7 @@ import (
 	"iter"
 	"maps"
 	"os"
+	"strings"
 
 	"github.com/block/spirit/pkg/statement"
 	"github.com/pingcap/tidb/pkg/parser/ast"
@@ -83,6 +89,13 @@ type Config struct {
 
 	// IgnoreTables can be used to discard violations for specific tables
 	IgnoreTables map[string]bool
+
+	// Severities overrides the severity of all violations from a linter
+	Severities map[string]Severity
+
+	// TableSeverities overrides the severity of a linter's violations
+	// on specific tables. It takes precedence over Severities.
+	TableSeverities map[string]map[string]Severity
 }
 
 // IsEnabled checks the config as well as the registry to see if
@@ -118,7 +131,28 @@ func (c *Config) IsEnabled(linterName string) bool {
 //
 // If a linter implements ConfigurableLinter and has settings in config.Settings,
 // those settings are applied before running the linter.
+//
+// Violations silenced by spirit-lint:ignore directives are dropped;
+// use Run to also get them.
 func RunLinters(existingSchema []*statement.CreateTable, changes []*statement.AbstractStatement, config Config) ([]Violation, error) {
+	result, err := Run(existingSchema, changes, config)
+	return result.Violations, err
+}
+
+// Result holds the outcome of Run.
+type Result struct {
+	// Violations are the violations that were not suppressed.
+	Violations []Violation
+
+	// Suppressed are the violations silenced by spirit-lint:ignore
+	// directives in the schema. They are kept so suppressions can be audited.
+	Suppressed []Violation
+}
+
+// Run is like RunLinters, but also returns the violations that were
+// silenced by spirit-lint:ignore directives (see statement.Suppression)
+// in the existing schema or in CREATE TABLE changes.
+func Run(existingSchema []*statement.CreateTable, changes []*statement.AbstractStatement, config Config) (Result, error) {
 	var errs []error
 
 	// Acquired as a writer (not a reader) because Configure() mutates the
@@ -169,7 +203,7 @@ func RunLinters(existingSchema []*statement.CreateTable, changes []*statement.Ab
 
 		// Run the linter
 		lintViolations := linter.l.Lint(existingSchema, changes)
-		violations = append(violations, lintViolations...)
+		violations = append(violations, config.overrideSeverities(name, lintViolations)...)
 	}
 
 	// The linters are agnostic to this, but depending on how RunLinters is called,
@@ -178,7 +212,7 @@ func RunLinters(existingSchema []*statement.CreateTable, changes []*statement.Ab
 		var filtered []Violation
 		tables, err := extractTablesFromChanges(changes)
 		if err != nil {
-			return nil, err
+			return Result{}, err
 		}
 		for _, v := range violations {
 			if v.Location != nil {
@@ -200,7 +234,53 @@ func RunLinters(existingSchema []*statement.CreateTable, changes []*statement.Ab
 		violations = filtered
 	}
 
-	return violations, errors.Join(errs...)
+	active, suppressed := splitSuppressed(violations, CreateTableStatements(existingSchema, changes))
+	return Result{Violations: active, Suppressed: suppressed}, errors.Join(errs...)
+}
+
+// splitSuppressed separates the violations silenced by the suppression
+// directives of the given tables from the rest.
+func splitSuppressed(violations []Violation, tables iter.Seq[*statement.CreateTable]) (active, suppressed []Violation) {
+	suppressions := make(map[string][]statement.Suppression)
+	for ct := range tables {
+		if ct != nil && len(ct.Suppressions) > 0 {
+			key := strings.ToLower(ct.TableName)
+			suppressions[key] = append(suppressions[key], ct.Suppressions...)
+		}
+	}
+	if len(suppressions) == 0 {
+		return violations, nil
+	}
+	for _, v := range violations {
+		if v.Location != nil && v.Location.suppressed(v.Linter.Name(), suppressions[strings.ToLower(v.Location.Table)]) {
+			suppressed = append(suppressed, v)
+			continue
+		}
+		active = append(active, v)
+	}
+	return active, suppressed
+}
+
+// overrideSeverities applies the Severities and TableSeverities
+// overrides for the named linter to its violations.
+func (c *Config) overrideSeverities(linterName string, violations []Violation) []Violation {
+	severity, hasOverride := c.Severities[linterName]
+	tableSeverities := c.TableSeverities[linterName]
+	if !hasOverride && len(tableSeverities) == 0 {
+		return violations
+	}
+	for i := range violations {
+		if violations[i].Location != nil {
+			if s, ok := tableSeverities[violations[i].Location.Table]; ok {
+				violations[i].Severity = s
+				continue
+			}
+		}
+		if hasOverride {
+			violations[i].Severity = severity
+		}
+	}
+	return violations
 }
 
 func extractTablesFromChanges(changes []*statement.AbstractStatement) (map[string]struct{}, error) {
@@ -0,0 +1,515 @@
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/block/spirit/pkg/statement"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
+)
+
+func init() {
+	Register(&AlterAlgorithmLinter{})
+}
+
+// Algorithms predicted by AlterAlgorithmLinter.
+const (
+	algorithmInstant = "INSTANT"
+	algorithmInplace = "INPLACE"
+	algorithmCopy    = "COPY"
+)
+
+// Versions in which MySQL extended the operations supported by
+// ALGORITHM=INSTANT.
+var (
+	instantIntroducedVersion = mysqlVersion{8, 0, 12}
+	instantRenameVersion     = mysqlVersion{8, 0, 28}
+	instantAnyColumnVersion  = mysqlVersion{8, 0, 29}
+)
+
+// maxInstantRowVersions is the number of INSTANT ADD/DROP COLUMN operations
+// a table supports (from 8.0.29) before it has to be rebuilt.
+const maxInstantRowVersions = 64
+
+// AlterAlgorithmLinter predicts how each ALTER TABLE will be applied, so that
+// developers learn at review time whether a change is a metadata-only
+// operation or a copy of the whole table.
+//
+// Spirit first attempts ALGORITHM=INSTANT. If that is not possible, it uses
+// ALGORITHM=INPLACE for the operations that AlgorithmInplaceConsideredSafe
+// accepts, and otherwise performs its own copy of the table. The linter
+// follows the same order, applying MySQL's INSTANT rules for the configured
+// server version. Where the rules depend on the current table definition
+// (for example, whether a MODIFY COLUMN only changes the default), the
+// existing tables are used; if the table is unknown the prediction is
+// conservative.
+type AlterAlgorithmLinter struct {
+	version mysqlVersion
+}
+
+func (l *AlterAlgorithmLinter) String() string {
+	return Stringer(l)
+}
+
+func (l *AlterAlgorithmLinter) Name() string {
+	return "alter_algorithm"
+}
+
+func (l *AlterAlgorithmLinter) Description() string {
+	return "Predicts whether each ALTER TABLE will run as INSTANT, INPLACE or a full table copy"
+}
+
+func (l *AlterAlgorithmLinter) Configure(config map[string]string) error {
+	for k, v := range config {
+		switch k {
+		case "mysqlVersion":
+			version, err := parseMySQLVersion(v)
+			if err != nil {
+				return fmt.Errorf("invalid value for %s: %w", k, err)
+			}
+			l.version = version
+		default:
+			return fmt.Errorf("unknown config key for %s: %s", l.Name(), k)
+		}
+	}
+	return nil
+}
+
+func (l *AlterAlgorithmLinter) DefaultConfig() map[string]string {
+	return map[string]string{
+		"mysqlVersion": instantAnyColumnVersion.String(),
+	}
+}
+
+func (l *AlterAlgorithmLinter) Lint(existingTables []*statement.CreateTable, changes []*statement.AbstractStatement) (violations []Violation) {
+	if l.version == (mysqlVersion{}) {
+		l.version = instantAnyColumnVersion
+	}
+	// Track the table definitions as the changes are applied, so that a
+	// second ALTER of the same table is judged against the first's result.
+	tables := make(map[string]*statement.CreateTable, len(existingTables))
+	for _, t := range existingTables {
+		tables[strings.ToLower(t.TableName)] = t
+	}
+	for _, change := range changes {
+		if change.IsCreateTable() {
+			if ct, err := change.ParseCreateTable(); err == nil {
+				tables[strings.ToLower(ct.TableName)] = ct
+			}
+			continue
+		}
+		alter, ok := change.AsAlterTable()
+		if !ok {
+			continue
+		}
+		key := strings.ToLower(change.Table)
+		table := tables[key]
+
+		var reasons []string
+		var instantClauses int
+		var usesRowVersion bool
+		for _, spec := range alter.Specs {
+			if spec.Tp == ast.AlterTableAlgorithm || spec.Tp == ast.AlterTableLock {
+				continue
+			}
+			if reason := l.notInstantReason(spec, table); reason != "" {
+				reasons = append(reasons, reason)
+				continue
+			}
+			instantClauses++
+			if spec.Tp == ast.AlterTableAddColumns || spec.Tp == ast.AlterTableDropColumn {
+				usesRowVersion = l.version.atLeast(instantAnyColumnVersion)
+			}
+		}
+		violations = append(violations, l.violation(change, table, reasons, instantClauses, usesRowVersion))
+
+		if table != nil {
+			delete(tables, key)
+			table = applyAlter(table, alter)
+			completeColumns(table)
+			tables[strings.ToLower(table.TableName)] = table
+		}
+	}
+	return violations
+}
+
+// violation reports the predicted algorithm for a change.
+func (l *AlterAlgorithmLinter) violation(change *statement.AbstractStatement, table *statement.CreateTable, reasons []string, instantClauses int, usesRowVersion bool) Violation {
+	v := Violation{
+		Linter:   l,
+		Severity: SeverityInfo,
+		Location: &Location{Table: change.Table},
+	}
+	if len(reasons) == 0 {
+		v.Message = fmt.Sprintf("ALTER TABLE %q is predicted to run with ALGORITHM=INSTANT", change.Table)
+		if usesRowVersion {
+			v.Message += fmt.Sprintf(". Each INSTANT ADD or DROP COLUMN uses one of the table's %d row versions; once they are used up, the table must be rebuilt before columns can be added or dropped instantly again", maxInstantRowVersions)
+		}
+		v.Context = map[string]any{"algorithm": algorithmInstant}
+		return v
+	}
+
+	algorithm := algorithmCopy
+	if change.AlgorithmInplaceConsideredSafe() == nil && !l.varcharChangeRequiresCopy(change, table) {
+		algorithm = algorithmInplace
+	}
+	if algorithm == algorithmInplace {
+		v.Message = fmt.Sprintf("ALTER TABLE %q is predicted to run with ALGORITHM=INPLACE because it cannot be INSTANT: %s", change.Table, strings.Join(reasons, "; "))
+	} else {
+		v.Message = fmt.Sprintf("ALTER TABLE %q is predicted to require a full table copy because it cannot be INSTANT: %s", change.Table, strings.Join(reasons, "; "))
+		if instantClauses > 0 {
+			v.Suggestion = strPtr("Run the clauses that can be INSTANT as a separate ALTER TABLE, so that they do not wait for the copy")
+		}
+	}
+	v.Context = map[string]any{"algorithm": algorithm, "reasons": reasons}
+	return v
+}
+
+// notInstantReason returns why spec cannot be applied with
+// ALGORITHM=INSTANT, or "" if it can. table is the current definition of
+// the table being altered, and may be nil if it is unknown.
+func (l *AlterAlgorithmLinter) notInstantReason(spec *ast.AlterTableSpec, table *statement.CreateTable) string {
+	if !l.version.atLeast(instantIntroducedVersion) {
+		return fmt.Sprintf("ALGORITHM=INSTANT requires MySQL %s or later", instantIntroducedVersion)
+	}
+	switch spec.Tp { //nolint:exhaustive
+	case ast.AlterTableAddColumns:
+		if reason := instantColumnTableReason(table, "ADD COLUMN"); reason != "" {
+			return reason
+		}
+		for _, colDef := range spec.NewColumns {
+			name := colDef.Name.Name.O
+			for _, opt := range colDef.Options {
+				switch opt.Tp { //nolint:exhaustive
+				case ast.ColumnOptionAutoIncrement:
+					return fmt.Sprintf("adding AUTO_INCREMENT column %q rebuilds the table", name)
+				case ast.ColumnOptionPrimaryKey, ast.ColumnOptionUniqKey:
+					return fmt.Sprintf("adding column %q also adds an index", name)
+				case ast.ColumnOptionGenerated:
+					if opt.Stored {
+						return fmt.Sprintf("adding STORED generated column %q rebuilds the table", name)
+					}
+				}
+			}
+		}
+		if spec.Position != nil && spec.Position.Tp != ast.ColumnPositionNone && !l.version.atLeast(instantAnyColumnVersion) {
+			return fmt.Sprintf("before MySQL %s, INSTANT ADD COLUMN can only add the last column", instantAnyColumnVersion)
+		}
+		return ""
+	case ast.AlterTableDropColumn:
+		if !l.version.atLeast(instantAnyColumnVersion) {
+			return fmt.Sprintf("INSTANT DROP COLUMN requires MySQL %s or later", instantAnyColumnVersion)
+		}
+		return instantColumnTableReason(table, "DROP COLUMN")
+	case ast.AlterTableRenameColumn:
+		if !l.version.atLeast(instantRenameVersion) {
+			return fmt.Sprintf("INSTANT RENAME COLUMN requires MySQL %s or later", instantRenameVersion)
+		}
+		return ""
+	case ast.AlterTableModifyColumn, ast.AlterTableChangeColumn:
+		return l.modifyColumnReason(spec, table)
+	case ast.AlterTableAlterColumn, // SET DEFAULT, DROP DEFAULT
+		ast.AlterTableRenameIndex,
+		ast.AlterTableIndexInvisible,
+		ast.AlterTableRenameTable:
+		return ""
+	case ast.AlterTableAddConstraint:
+		if spec.Constraint == nil {
+			return "adding a constraint is not an INSTANT operation"
+		}
+		switch spec.Constraint.Tp { //nolint:exhaustive
+		case ast.ConstraintPrimaryKey:
+			return "adding a primary key rebuilds the table"
+		case ast.ConstraintForeignKey:
+			return "adding a foreign key is not an INSTANT operation"
+		case ast.ConstraintCheck:
+			return "adding a CHECK constraint validates every row"
+		default:
+			return "adding an index is not an INSTANT operation"
+		}
+	case ast.AlterTableDropIndex:
+		return "dropping an index is not an INSTANT operation"
+	case ast.AlterTableDropPrimaryKey:
+		return "dropping the primary key rebuilds the table"
+	case ast.AlterTableOption:
+		return "changing table options is not an INSTANT operation"
+	default:
+		return AlterTableTypeToString(spec.Tp) + " is not an INSTANT operation"
+	}
+}
+
+// instantColumnTableReason returns why table does not support INSTANT
+// ADD or DROP COLUMN, or "" if it does (or the table is unknown).
+func instantColumnTableReason(table *statement.CreateTable, operation string) string {
+	if table == nil {
+		return ""
+	}
+	for _, idx := range table.GetIndexes() {
+		if idx.Type == "FULLTEXT" {
+			return fmt.Sprintf("INSTANT %s is not supported on tables with a FULLTEXT index", operation)
+		}
+	}
+	if table.TableOptions != nil && table.TableOptions.RowFormat != nil && strings.EqualFold(*table.TableOptions.RowFormat, "COMPRESSED") {
+		return fmt.Sprintf("INSTANT %s is not supported on tables with ROW_FORMAT=COMPRESSED", operation)
+	}
+	return ""
+}
+
+// modifyColumnReason returns why a MODIFY or CHANGE COLUMN cannot be
+// INSTANT. Only changing the default or comment, renaming the column and
+// appending ENUM/SET values (without changing the storage size) are INSTANT.
+func (l *AlterAlgorithmLinter) modifyColumnReason(spec *ast.AlterTableSpec, table *statement.CreateTable) string {
+	if len(spec.NewColumns) == 0 {
+		return AlterTableTypeToString(spec.Tp) + " is not an INSTANT operation"
+	}
+	newName := spec.NewColumns[0].Name.Name.O
+	oldName := newName
+	if spec.OldColumnName != nil {
+		oldName = spec.OldColumnName.Name.O
+	}
+	if spec.Position != nil && spec.Position.Tp != ast.ColumnPositionNone {
+		return fmt.Sprintf("reordering column %q rebuilds the table", oldName)
+	}
+	var oldCol *statement.Column
+	if table != nil {
+		oldCol = table.Columns.ByName(oldName)
+	}
+	newCol, err := parseColumnDef(spec.NewColumns[0])
+	if oldCol == nil || err != nil {
+		return fmt.Sprintf("the current definition of column %q is unknown; only changing its default, renaming it or appending ENUM/SET values is INSTANT", oldName)
+	}
+	if reason := columnDefinitionChange(oldCol, newCol, table); reason != "" {
+		return reason
+	}
+	if !strings.EqualFold(oldName, newName) && !l.version.atLeast(instantRenameVersion) {
+		return fmt.Sprintf("INSTANT RENAME COLUMN requires MySQL %s or later", instantRenameVersion)
+	}
+	return ""
+}
+
+// columnDefinitionChange describes the first change from oldCol to newCol that
+// cannot be made INSTANT, or returns "" if there is none.
+func columnDefinitionChange(oldCol, newCol *statement.Column, table *statement.CreateTable) string {
+	name := oldCol.Name
+	if oldCol.Type != newCol.Type || !sameBool(oldCol.Unsigned, newCol.Unsigned) {
+		return fmt.Sprintf("changing the type of column %q from %s to %s", name, columnTypeString(oldCol), columnTypeString(newCol))
+	}
+	if !isIntegerType(oldCol.Type) && (!sameInt(oldCol.Length, newCol.Length) || !sameInt(oldCol.Precision, newCol.Precision) || !sameInt(oldCol.Scale, newCol.Scale)) {
+		return fmt.Sprintf("changing the type of column %q from %s to %s", name, columnTypeString(oldCol), columnTypeString(newCol))
+	}
+	switch oldCol.Type {
+	case "enum":
+		if !appendsValues(oldCol.EnumValues, newCol.EnumValues) || enumStorageSize(len(oldCol.EnumValues)) != enumStorageSize(len(newCol.EnumValues)) {
+			return fmt.Sprintf("changing the ENUM values of column %q other than by appending values (without changing its storage size)", name)
+		}
+	case "set":
+		if !appendsValues(oldCol.SetValues, newCol.SetValues) || setStorageSize(len(oldCol.SetValues)) != setStorageSize(len(newCol.SetValues)) {
+			return fmt.Sprintf("changing the SET values of column %q other than by appending values (without changing its storage size)", name)
+		}
+	}
+	if oldCol.Nullable != newCol.Nullable {
+		if newCol.Nullable {
+			return fmt.Sprintf("changing column %q to NULL", name)
+		}
+		return fmt.Sprintf("changing column %q to NOT NULL", name)
+	}
+	if oldCol.AutoInc != newCol.AutoInc {
+		return fmt.Sprintf("changing AUTO_INCREMENT on column %q", name)
+	}
+	var tableCharset, tableCollation *string
+	if table.TableOptions != nil {
+		tableCharset, tableCollation = table.TableOptions.Charset, table.TableOptions.Collation
+	}
+	if !sameName(withDefault(oldCol.Charset, tableCharset), withDefault(newCol.Charset, tableCharset)) ||
+		!sameName(withDefault(oldCol.Collation, tableCollation), withDefault(newCol.Collation, tableCollation)) {
+		return fmt.Sprintf("changing the character set or collation of column %q", name)
+	}
+	if generatedExpression(oldCol) != generatedExpression(newCol) {
+		return fmt.Sprintf("changing the generated expression of column %q", name)
+	}
+	return ""
+}
+
+// varcharChangeRequiresCopy returns true if the change modifies a VARCHAR
+// column in a way that INPLACE does not support. AlgorithmInplaceConsideredSafe
+// accepts any VARCHAR MODIFY, because it cannot see the current definition;
+// MySQL only performs it INPLACE when the length grows without changing the
+// number of length bytes (1 byte up to 255 bytes, 2 bytes above).
+func (l *AlterAlgorithmLinter) varcharChangeRequiresCopy(change *statement.AbstractStatement, table *statement.CreateTable) bool {
+	alter, ok := change.AsAlterTable()
+	if !ok || table == nil {
+		return false
+	}
+	for _, spec := range alter.Specs {
+		if (spec.Tp != ast.AlterTableModifyColumn && spec.Tp != ast.AlterTableChangeColumn) || len(spec.NewColumns) == 0 {
+			continue
+		}
+		oldName := spec.NewColumns[0].Name.Name.O
+		if spec.OldColumnName != nil {
+			oldName = spec.OldColumnName.Name.O
+		}
+		oldCol := table.Columns.ByName(oldName)
+		newCol, err := parseColumnDef(spec.NewColumns[0])
+		if oldCol == nil || err != nil {
+			continue
+		}
+		if oldCol.Type != "varchar" || newCol.Type != "varchar" || oldCol.Nullable != newCol.Nullable {
+			return true
+		}
+		var tableCharset *string
+		if table.TableOptions != nil {
+			tableCharset = table.TableOptions.Charset
+		}
+		oldCharset, newCharset := withDefault(oldCol.Charset, tableCharset), withDefault(newCol.Charset, tableCharset)
+		if !sameName(oldCharset, newCharset) || oldCol.Length == nil || newCol.Length == nil || *newCol.Length < *oldCol.Length {
+			return true
+		}
+		bytesPerChar := charsetMaxBytes(oldCharset)
+		if (*oldCol.Length*bytesPerChar <= 255) != (*newCol.Length*bytesPerChar <= 255) {
+			return true
+		}
+	}
+	return false
+}
+
+// charsetMaxBytes returns the maximum number of bytes per character of a
+// character set. Unknown or unset character sets are assumed to be utf8mb4.
+func charsetMaxBytes(charset *string) int {
+	if charset == nil {
+		return 4
+	}
+	switch strings.ToLower(*charset) {
+	case "latin1", "ascii", "binary", "latin2", "latin5", "latin7", "cp1250", "cp1251", "cp1256", "cp1257", "cp850", "cp852", "cp866", "dec8", "greek", "hebrew", "hp8", "keybcs2", "koi8r", "koi8u", "macce", "macroman", "swe7", "tis620", "armscii8", "geostd8":
+		return 1
+	case "ucs2", "gbk", "big5", "sjis", "cp932", "euckr", "gb2312":
+		return 2
+	case "utf8", "utf8mb3", "ujis", "eucjpms":
+		return 3
+	default:
+		return 4
+	}
+}
+
+func isIntegerType(typ string) bool {
+	switch typ {
+	case "tinyint", "smallint", "mediumint", "int", "bigint":
+		return true
+	}
+	return false
+}
+
+// columnTypeString formats the type of a column for messages.
+func columnTypeString(col *statement.Column) string {
+	if isIntegerType(col.Type) {
+		// Display widths are deprecated and do not affect the type.
+		if col.Unsigned != nil && *col.Unsigned {
+			return col.Type + " unsigned"
+		}
+		return col.Type
+	}
+	if col.Raw != nil && col.Raw.Tp != nil {
+		return col.Raw.Tp.CompactStr()
+	}
+	return col.Type
+}
+
+// appendsValues returns true if newValues is oldValues with zero or more values appended.
+func appendsValues(oldValues, newValues []string) bool {
+	return len(newValues) >= len(oldValues) && slices.Equal(oldValues, newValues[:len(oldValues)])
+}
+
+// enumStorageSize returns the bytes used to store an ENUM with n values.
+func enumStorageSize(n int) int {
+	if n <= 255 {
+		return 1
+	}
+	return 2
+}
+
+// setStorageSize returns the bytes used to store a SET with n values.
+func setStorageSize(n int) int {
+	size := (n + 7) / 8
+	if size >= 5 {
+		return 8
+	}
+	return size
+}
+
+// generatedExpression returns the restored expression and storage of a
+// generated column, or "" for ordinary columns.
+func generatedExpression(col *statement.Column) string {
+	if col.Raw == nil {
+		return ""
+	}
+	for _, opt := range col.Raw.Options {
+		if opt.Tp != ast.ColumnOptionGenerated || opt.Expr == nil {
+			continue
+		}
+		var sb strings.Builder
+		if err := opt.Expr.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
+			return ""
+		}
+		return fmt.Sprintf("%s stored=%t", sb.String(), opt.Stored)
+	}
+	return ""
+}
+
+func withDefault(value, fallback *string) *string {
+	if value != nil {
+		return value
+	}
+	return fallback
+}
+
+// sameName compares two optional names case-insensitively. Unset values
+// match anything, since they inherit a default that is not known here.
+func sameName(a, b *string) bool {
+	return a == nil || b == nil || strings.EqualFold(*a, *b)
+}
+
+func sameBool(a, b *bool) bool {
+	return (a != nil && *a) == (b != nil && *b)
+}
+
+func sameInt(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// mysqlVersion is a MySQL server version (major, minor, patch).
+type mysqlVersion [3]int
+
+// parseMySQLVersion parses versions such as "8.0.29" or "8.4".
+func parseMySQLVersion(s string) (mysqlVersion, error) {
+	var v mysqlVersion
+	parts := strings.Split(strings.TrimSpace(s), ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return v, fmt.Errorf("%q is not a MySQL version such as 8.0.29", s)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("%q is not a MySQL version such as 8.0.29", s)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+func (v mysqlVersion) atLeast(other mysqlVersion) bool {
+	for i := range v {
+		if v[i] != other[i] {
+			return v[i] > other[i]
+		}
+	}
+	return true
+}
+
+func (v mysqlVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
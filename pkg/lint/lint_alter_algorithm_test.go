@@ -0,0 +1,185 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/block/spirit/pkg/statement"
+	"github.com/stretchr/testify/require"
+)
+
+const alterAlgorithmUsersTable = `CREATE TABLE users (
+  id bigint unsigned NOT NULL AUTO_INCREMENT,
+  name varchar(50) NOT NULL,
+  bio varchar(100) DEFAULT NULL,
+  status enum('active','inactive') NOT NULL DEFAULT 'active',
+  age int DEFAULT NULL,
+  PRIMARY KEY (id),
+  KEY idx_name (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci`
+
+func lintAlterAlgorithm(t *testing.T, linter *AlterAlgorithmLinter, existing []string, sql string) []Violation {
+	t.Helper()
+	stmts, err := statement.New(sql)
+	require.NoError(t, err)
+	return linter.Lint(parseCreateTables(t, existing...), stmts)
+}
+
+func TestAlterAlgorithmLinter_Instant(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+	}{
+		{"add column at end", "ALTER TABLE users ADD COLUMN email varchar(255)"},
+		{"add column in the middle", "ALTER TABLE users ADD COLUMN email varchar(255) AFTER name"},
+		{"add virtual column", "ALTER TABLE users ADD COLUMN name_len int AS (char_length(name)) VIRTUAL"},
+		{"drop column", "ALTER TABLE users DROP COLUMN age"},
+		{"rename column", "ALTER TABLE users RENAME COLUMN age TO years"},
+		{"set default", "ALTER TABLE users ALTER COLUMN age SET DEFAULT 18"},
+		{"modify default", "ALTER TABLE users MODIFY COLUMN age int DEFAULT 18"},
+		{"modify comment", "ALTER TABLE users MODIFY COLUMN age int COMMENT 'in years'"},
+		{"append enum value", "ALTER TABLE users MODIFY COLUMN status enum('active','inactive','banned') NOT NULL DEFAULT 'active'"},
+		{"rename index", "ALTER TABLE users RENAME INDEX idx_name TO idx_user_name"},
+		{"invisible index", "ALTER TABLE users ALTER INDEX idx_name INVISIBLE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := lintAlterAlgorithm(t, &AlterAlgorithmLinter{}, []string{alterAlgorithmUsersTable}, tt.sql)
+			require.Len(t, violations, 1)
+			require.Equal(t, SeverityInfo, violations[0].Severity)
+			require.Equal(t, algorithmInstant, violations[0].Context["algorithm"], violations[0].Message)
+			require.Equal(t, "users", violations[0].Location.Table)
+			require.Nil(t, violations[0].Suggestion)
+		})
+	}
+}
+
+func TestAlterAlgorithmLinter_NotInstant(t *testing.T) {
+	tests := []struct {
+		name      string
+		sql       string
+		algorithm string
+		reason    string
+	}{
+		{"add index", "ALTER TABLE users ADD INDEX idx_age (age)", algorithmCopy, "adding an index"},
+		{"drop index", "ALTER TABLE users DROP INDEX idx_name", algorithmInplace, "dropping an index"},
+		{"change type", "ALTER TABLE users MODIFY COLUMN age bigint DEFAULT NULL", algorithmCopy, `changing the type of column "age" from int to bigint`},
+		{"make not null", "ALTER TABLE users MODIFY COLUMN age int NOT NULL", algorithmCopy, `changing column "age" to NOT NULL`},
+		{"reorder column", "ALTER TABLE users MODIFY COLUMN age int DEFAULT NULL FIRST", algorithmCopy, `reordering column "age"`},
+		{"insert enum value", "ALTER TABLE users MODIFY COLUMN status enum('active','banned','inactive') NOT NULL", algorithmCopy, `ENUM values of column "status"`},
+		{"change charset", "ALTER TABLE users MODIFY COLUMN bio varchar(100) CHARACTER SET latin1", algorithmCopy, `character set or collation of column "bio"`},
+		{"add auto_increment column", "ALTER TABLE users ADD COLUMN seq int AUTO_INCREMENT", algorithmCopy, "AUTO_INCREMENT"},
+		{"add stored column", "ALTER TABLE users ADD COLUMN name_len int AS (char_length(name)) STORED", algorithmCopy, "STORED generated column"},
+		{"table option", "ALTER TABLE users ENGINE=InnoDB", algorithmCopy, "table options"},
+		// utf8mb4: 100 chars = 400 bytes, 200 chars = 800 bytes; both use 2 length bytes.
+		{"extend varchar", "ALTER TABLE users MODIFY COLUMN bio varchar(200) DEFAULT NULL", algorithmInplace, `changing the type of column "bio"`},
+		// 50 chars = 200 bytes (1 length byte), 100 chars = 400 bytes (2 length bytes).
+		{"extend varchar across length bytes", "ALTER TABLE users MODIFY COLUMN name varchar(100) NOT NULL", algorithmCopy, `changing the type of column "name"`},
+		{"shrink varchar", "ALTER TABLE users MODIFY COLUMN bio varchar(90) DEFAULT NULL", algorithmCopy, `changing the type of column "bio"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := lintAlterAlgorithm(t, &AlterAlgorithmLinter{}, []string{alterAlgorithmUsersTable}, tt.sql)
+			require.Len(t, violations, 1)
+			require.Equal(t, SeverityInfo, violations[0].Severity)
+			require.Equal(t, tt.algorithm, violations[0].Context["algorithm"], violations[0].Message)
+			require.Contains(t, violations[0].Message, tt.reason)
+		})
+	}
+}
+
+func TestAlterAlgorithmLinter_MixedClauses(t *testing.T) {
+	violations := lintAlterAlgorithm(t, &AlterAlgorithmLinter{}, []string{alterAlgorithmUsersTable},
+		"ALTER TABLE users ADD COLUMN email varchar(255), ADD INDEX idx_age (age)")
+	require.Len(t, violations, 1)
+	require.Equal(t, algorithmCopy, violations[0].Context["algorithm"])
+	require.Equal(t, []string{"adding an index is not an INSTANT operation"}, violations[0].Context["reasons"])
+	require.NotNil(t, violations[0].Suggestion)
+	require.Contains(t, *violations[0].Suggestion, "separate ALTER TABLE")
+}
+
+func TestAlterAlgorithmLinter_TableRestrictions(t *testing.T) {
+	fulltext := `CREATE TABLE posts (
+  id bigint unsigned NOT NULL AUTO_INCREMENT,
+  body text,
+  PRIMARY KEY (id),
+  FULLTEXT KEY ft_body (body)
+)`
+	violations := lintAlterAlgorithm(t, &AlterAlgorithmLinter{}, []string{fulltext}, "ALTER TABLE posts ADD COLUMN title varchar(255)")
+	require.Len(t, violations, 1)
+	require.Equal(t, algorithmCopy, violations[0].Context["algorithm"])
+	require.Contains(t, violations[0].Message, "FULLTEXT index")
+
+	compressed := `CREATE TABLE logs (
+  id bigint unsigned NOT NULL AUTO_INCREMENT,
+  msg text,
+  PRIMARY KEY (id)
+) ROW_FORMAT=COMPRESSED`
+	violations = lintAlterAlgorithm(t, &AlterAlgorithmLinter{}, []string{compressed}, "ALTER TABLE logs DROP COLUMN msg")
+	require.Len(t, violations, 1)
+	require.Contains(t, violations[0].Message, "ROW_FORMAT=COMPRESSED")
+}
+
+func TestAlterAlgorithmLinter_MySQLVersion(t *testing.T) {
+	linter := &AlterAlgorithmLinter{}
+	require.NoError(t, linter.Configure(map[string]string{"mysqlVersion": "8.0.28"}))
+
+	violations := lintAlterAlgorithm(t, linter, []string{alterAlgorithmUsersTable}, "ALTER TABLE users DROP COLUMN age")
+	require.Len(t, violations, 1)
+	require.NotEqual(t, algorithmInstant, violations[0].Context["algorithm"])
+	require.Contains(t, violations[0].Message, "INSTANT DROP COLUMN requires MySQL 8.0.29 or later")
+
+	violations = lintAlterAlgorithm(t, linter, []string{alterAlgorithmUsersTable}, "ALTER TABLE users ADD COLUMN email varchar(255) AFTER name")
+	require.Len(t, violations, 1)
+	require.Contains(t, violations[0].Message, "can only add the last column")
+
+	// Adding the last column is INSTANT, without row versions before 8.0.29.
+	violations = lintAlterAlgorithm(t, linter, []string{alterAlgorithmUsersTable}, "ALTER TABLE users ADD COLUMN email varchar(255)")
+	require.Len(t, violations, 1)
+	require.Equal(t, algorithmInstant, violations[0].Context["algorithm"])
+	require.NotContains(t, violations[0].Message, "row versions")
+
+	violations = lintAlterAlgorithm(t, linter, []string{alterAlgorithmUsersTable}, "ALTER TABLE users RENAME COLUMN age TO years")
+	require.Len(t, violations, 1)
+	require.Equal(t, algorithmInstant, violations[0].Context["algorithm"])
+
+	// The default version reports the row version limit.
+	violations = lintAlterAlgorithm(t, &AlterAlgorithmLinter{}, []string{alterAlgorithmUsersTable}, "ALTER TABLE users ADD COLUMN email varchar(255)")
+	require.Len(t, violations, 1)
+	require.Contains(t, violations[0].Message, "64 row versions")
+}
+
+func TestAlterAlgorithmLinter_UnknownTable(t *testing.T) {
+	violations := lintAlterAlgorithm(t, &AlterAlgorithmLinter{}, nil, "ALTER TABLE users MODIFY COLUMN age int DEFAULT 18")
+	require.Len(t, violations, 1)
+	require.Equal(t, algorithmCopy, violations[0].Context["algorithm"])
+	require.Contains(t, violations[0].Message, `the current definition of column "age" is unknown`)
+
+	// Operations that do not depend on the table are still classified.
+	violations = lintAlterAlgorithm(t, &AlterAlgorithmLinter{}, nil, "ALTER TABLE users ADD COLUMN email varchar(255)")
+	require.Len(t, violations, 1)
+	require.Equal(t, algorithmInstant, violations[0].Context["algorithm"])
+}
+
+func TestAlterAlgorithmLinter_SequentialChanges(t *testing.T) {
+	// The second ALTER is judged against the table as changed by the first,
+	// and CREATE TABLE statements are not classified.
+	stmts, err := statement.NewWithOptions(`CREATE TABLE t1 (id int NOT NULL PRIMARY KEY);
+ALTER TABLE t1 ADD COLUMN c1 int;
+ALTER TABLE t1 MODIFY COLUMN c1 int DEFAULT 5`, statement.Options{AllowMixedStatementTypes: true})
+	require.NoError(t, err)
+	violations := (&AlterAlgorithmLinter{}).Lint(nil, stmts)
+	require.Len(t, violations, 2)
+	require.Equal(t, algorithmInstant, violations[0].Context["algorithm"])
+	require.Equal(t, algorithmInstant, violations[1].Context["algorithm"], violations[1].Message)
+}
+
+func TestAlterAlgorithmLinter_Configure(t *testing.T) {
+	linter := &AlterAlgorithmLinter{}
+	require.Equal(t, map[string]string{"mysqlVersion": "8.0.29"}, linter.DefaultConfig())
+	require.NoError(t, linter.Configure(linter.DefaultConfig()))
+	require.NoError(t, linter.Configure(map[string]string{"mysqlVersion": "8.4"}))
+	require.Equal(t, mysqlVersion{8, 4, 0}, linter.version)
+
+	require.ErrorContains(t, linter.Configure(map[string]string{"mysqlVersion": "eight"}), "not a MySQL version")
+	require.ErrorContains(t, linter.Configure(map[string]string{"unknown": "x"}), "unknown config key")
+}
@@ -0,0 +1,317 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/block/spirit/pkg/statement"
+)
+
+func init() {
+	Register(&CollationConsistencyLinter{})
+}
+
+// CollationConsistencyLinter enforces character set and collation
+// consistency across tables in the same schema. Comparing or joining string
+// columns with different collations forces a conversion on one side, which
+// silently prevents the use of its index (or fails with "Illegal mix of
+// collations").
+//
+// Rule 1 (same_name): String columns sharing a name across tables should
+// share a collation.
+// Rule 2 (inferred_fk): String columns named like {table}_id are inferred to
+// reference {table}.id, as in type_pedantic, and should match its collation.
+// Rule 3 (table_default): Columns should not override the character set or
+// collation of their table.
+//
+// Like type_pedantic, all rules operate on the post-state of the schema.
+// Collations are compared after resolving defaults: a column without a
+// collation inherits the table's, and a character set without a collation
+// uses the default collation of that character set.
+type CollationConsistencyLinter struct {
+	checkSameName     bool
+	checkInferredFK   bool
+	checkTableDefault bool
+	ignoreColumns     map[string]struct{}
+}
+
+func (l *CollationConsistencyLinter) Name() string { return "collation_consistency" }
+func (l *CollationConsistencyLinter) Description() string {
+	return "Cross-table collation consistency: same-name columns, inferred {table}_id foreign keys and column overrides of the table default"
+}
+func (l *CollationConsistencyLinter) String() string { return Stringer(l) }
+
+func (l *CollationConsistencyLinter) DefaultConfig() map[string]string {
+	return map[string]string{
+		"checkSameName":     "true",
+		"checkInferredFK":   "true",
+		"checkTableDefault": "true",
+		"ignoreColumns":     "",
+	}
+}
+
+func (l *CollationConsistencyLinter) setDefaults() {
+	l.checkSameName = true
+	l.checkInferredFK = true
+	l.checkTableDefault = true
+	l.ignoreColumns = map[string]struct{}{}
+}
+
+func (l *CollationConsistencyLinter) Configure(config map[string]string) error {
+	l.setDefaults()
+	for k, v := range config {
+		switch k {
+		case "checkSameName":
+			b, err := ConfigBool(v, k)
+			if err != nil {
+				return err
+			}
+			l.checkSameName = b
+		case "checkInferredFK":
+			b, err := ConfigBool(v, k)
+			if err != nil {
+				return err
+			}
+			l.checkInferredFK = b
+		case "checkTableDefault":
+			b, err := ConfigBool(v, k)
+			if err != nil {
+				return err
+			}
+			l.checkTableDefault = b
+		case "ignoreColumns":
+			l.ignoreColumns = tpParseIgnoreList(v)
+		default:
+			return fmt.Errorf("unknown config key for %s: %s", l.Name(), k)
+		}
+	}
+	return nil
+}
+
+func (l *CollationConsistencyLinter) Lint(existingTables []*statement.CreateTable, changes []*statement.AbstractStatement) (violations []Violation) {
+	if l.ignoreColumns == nil {
+		l.setDefaults()
+	}
+
+	tables := PostState(existingTables, changes)
+	tableByName := make(map[string]*statement.CreateTable, len(tables))
+	for _, t := range tables {
+		completeColumns(t)
+		tableByName[strings.ToLower(t.TableName)] = t
+	}
+
+	if l.checkSameName {
+		violations = append(violations, l.lintSameName(tables)...)
+	}
+	if l.checkInferredFK {
+		violations = append(violations, l.lintInferredFK(tables, tableByName)...)
+	}
+	if l.checkTableDefault {
+		violations = append(violations, l.lintTableDefault(tables)...)
+	}
+	return violations
+}
+
+func (l *CollationConsistencyLinter) lintSameName(tables []*statement.CreateTable) []Violation {
+	type colRef struct {
+		table     *statement.CreateTable
+		col       *statement.Column
+		collation string
+	}
+	byName := make(map[string][]colRef)
+	for _, t := range tables {
+		for i := range t.Columns {
+			c := &t.Columns[i]
+			lower := strings.ToLower(c.Name)
+			if _, skip := l.ignoreColumns[lower]; skip || !isCharacterType(c.Type) {
+				continue
+			}
+			byName[lower] = append(byName[lower], colRef{table: t, col: c, collation: resolvedCollation(c, t)})
+		}
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var violations []Violation
+	for _, name := range names {
+		refs := byName[name]
+		counts := make(map[string]int)
+		tablesByCollation := make(map[string][]string)
+		for _, r := range refs {
+			counts[r.collation]++
+			tablesByCollation[r.collation] = append(tablesByCollation[r.collation], r.table.TableName)
+		}
+		if len(counts) < 2 {
+			continue
+		}
+
+		majority, clear := tpPickMajority(counts)
+		var distinct []string
+		if !clear {
+			for collation := range counts {
+				distinct = append(distinct, collation)
+			}
+			sort.Strings(distinct)
+		}
+		for _, r := range refs {
+			if clear && r.collation == majority {
+				continue
+			}
+			colName := r.col.Name
+			v := Violation{
+				Linter:   l,
+				Severity: SeverityWarning,
+				Location: &Location{Table: r.table.TableName, Column: &colName},
+				Context: map[string]any{
+					"collation": r.collation,
+					"rule":      "same_name",
+				},
+			}
+			if clear {
+				majorityTables := tpDedupeStrings(tablesByCollation[majority])
+				v.Message = fmt.Sprintf("Column %q in table %q has collation %q but %d other table(s) use collation %q (e.g. %s)",
+					r.col.Name, r.table.TableName, r.collation, len(majorityTables), majority, strings.Join(tpFirstN(majorityTables, 3), ", "))
+				v.Suggestion = strPtr(fmt.Sprintf("Align %s.%s to collation %q so that joins and comparisons can use indexes", r.table.TableName, r.col.Name, majority))
+				v.Context["expected_collation"] = majority
+			} else {
+				v.Message = fmt.Sprintf("Column %q in table %q has collation %q; inconsistent across schema (collations in use: %s)",
+					r.col.Name, r.table.TableName, r.collation, strings.Join(distinct, ", "))
+				v.Suggestion = strPtr(fmt.Sprintf("Pick one collation for column %q across all tables", r.col.Name))
+				v.Context["conflicting_collations"] = distinct
+			}
+			violations = append(violations, v)
+		}
+	}
+	return violations
+}
+
+func (l *CollationConsistencyLinter) lintInferredFK(tables []*statement.CreateTable, tableByName map[string]*statement.CreateTable) []Violation {
+	var violations []Violation
+	for _, t := range tables {
+		for i := range t.Columns {
+			c := &t.Columns[i]
+			lower := strings.ToLower(c.Name)
+			if _, skip := l.ignoreColumns[lower]; skip || !isCharacterType(c.Type) {
+				continue
+			}
+			base, ok := strings.CutSuffix(lower, "_id")
+			if !ok || base == "" {
+				continue
+			}
+			target := tpFindFKTarget(tableByName, base, t.TableName)
+			if target == nil {
+				continue
+			}
+			idCol := tpFindIDColumn(target)
+			if idCol == nil || !isCharacterType(idCol.Type) {
+				continue
+			}
+			collation, idCollation := resolvedCollation(c, t), resolvedCollation(idCol, target)
+			if collation == idCollation {
+				continue
+			}
+			colName := c.Name
+			violations = append(violations, Violation{
+				Linter:   l,
+				Severity: SeverityWarning,
+				Message: fmt.Sprintf("Column %q in table %q has collation %q but inferred FK target %q.id has collation %q",
+					c.Name, t.TableName, collation, target.TableName, idCollation),
+				Location:   &Location{Table: t.TableName, Column: &colName},
+				Suggestion: strPtr(fmt.Sprintf("Align %s.%s to collation %q so that joins on %s.id can use its index", t.TableName, c.Name, idCollation, target.TableName)),
+				Context: map[string]any{
+					"collation":          collation,
+					"expected_collation": idCollation,
+					"referenced_table":   target.TableName,
+					"rule":               "inferred_fk",
+				},
+			})
+		}
+	}
+	return violations
+}
+
+func (l *CollationConsistencyLinter) lintTableDefault(tables []*statement.CreateTable) []Violation {
+	var violations []Violation
+	for _, t := range tables {
+		if t.TableOptions == nil || t.TableOptions.Charset == nil && t.TableOptions.Collation == nil {
+			// The table default comes from the schema, which is not known.
+			continue
+		}
+		tableCollation := resolvedCollation(&statement.Column{}, t)
+		for i := range t.Columns {
+			c := &t.Columns[i]
+			if _, skip := l.ignoreColumns[strings.ToLower(c.Name)]; skip || !isCharacterType(c.Type) {
+				continue
+			}
+			if c.Charset == nil && c.Collation == nil {
+				continue
+			}
+			collation := resolvedCollation(c, t)
+			if collation == tableCollation {
+				continue
+			}
+			colName := c.Name
+			violations = append(violations, Violation{
+				Linter:   l,
+				Severity: SeverityWarning,
+				Message: fmt.Sprintf("Column %q in table %q has collation %q, which differs from the table default %q",
+					c.Name, t.TableName, collation, tableCollation),
+				Location:   &Location{Table: t.TableName, Column: &colName},
+				Suggestion: strPtr(fmt.Sprintf("Remove the CHARACTER SET and COLLATE clauses of %s.%s to inherit the table default", t.TableName, c.Name)),
+				Context: map[string]any{
+					"collation":          collation,
+					"expected_collation": tableCollation,
+					"rule":               "table_default",
+				},
+			})
+		}
+	}
+	return violations
+}
+
+// defaultCollations are the default collations of common character sets in
+// MySQL 8.0.
+var defaultCollations = map[string]string{
+	"utf8mb4": "utf8mb4_0900_ai_ci",
+	"utf8mb3": "utf8mb3_general_ci",
+	"latin1":  "latin1_swedish_ci",
+	"ascii":   "ascii_general_ci",
+	"binary":  "binary",
+	"ucs2":    "ucs2_general_ci",
+	"utf16":   "utf16_general_ci",
+	"utf32":   "utf32_general_ci",
+	"gbk":     "gbk_chinese_ci",
+	"sjis":    "sjis_japanese_ci",
+}
+
+// resolvedCollation returns the collation of a string column after applying
+// the table's defaults and the default collation of its character set. A
+// table without a character set is assumed to use utf8mb4, the server
+// default.
+func resolvedCollation(col *statement.Column, ct *statement.CreateTable) string {
+	charset, collation := effectiveCharsetCollation(col, ct)
+	if collation != "" {
+		return collation
+	}
+	if charset == "" {
+		charset = "utf8mb4"
+	}
+	if collation, ok := defaultCollations[charset]; ok {
+		return collation
+	}
+	return charset
+}
+
+// isCharacterType returns true for types that have a character set.
+func isCharacterType(typ string) bool {
+	switch typ {
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum", "set":
+		return true
+	}
+	return false
+}
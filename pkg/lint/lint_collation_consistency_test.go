@@ -0,0 +1,126 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/block/spirit/pkg/statement"
+	"github.com/stretchr/testify/require"
+)
+
+func newCollationConsistency(t *testing.T, config map[string]string) *CollationConsistencyLinter {
+	t.Helper()
+	l := &CollationConsistencyLinter{}
+	require.NoError(t, l.Configure(config))
+	return l
+}
+
+func TestCollationConsistency_NoViolations(t *testing.T) {
+	tables := parseTables(t,
+		`CREATE TABLE accounts (id CHAR(36) PRIMARY KEY, email VARCHAR(255)) CHARSET=utf8mb4`,
+		// utf8mb4 without a collation is utf8mb4_0900_ai_ci.
+		`CREATE TABLE sessions (id CHAR(36) PRIMARY KEY, account_id CHAR(36), email VARCHAR(255)) COLLATE=utf8mb4_0900_ai_ci`,
+		// Non-string columns are ignored.
+		`CREATE TABLE orders (id BIGINT PRIMARY KEY, email BIGINT) CHARSET=latin1`,
+	)
+	require.Empty(t, (&CollationConsistencyLinter{}).Lint(tables, nil))
+}
+
+func TestCollationConsistency_SameName(t *testing.T) {
+	tables := parseTables(t,
+		`CREATE TABLE a (id BIGINT PRIMARY KEY, email VARCHAR(255)) CHARSET=utf8mb4`,
+		`CREATE TABLE b (id BIGINT PRIMARY KEY, email VARCHAR(255)) CHARSET=utf8mb4`,
+		`CREATE TABLE c (id BIGINT PRIMARY KEY, email VARCHAR(255)) CHARSET=latin1`,
+	)
+	l := newCollationConsistency(t, map[string]string{"checkTableDefault": "false"})
+	violations := l.Lint(tables, nil)
+	require.Len(t, violations, 1)
+	v := violations[0]
+	require.Equal(t, "collation_consistency", v.Linter.Name())
+	require.Equal(t, SeverityWarning, v.Severity)
+	require.Equal(t, "c", v.Location.Table)
+	require.Equal(t, "email", *v.Location.Column)
+	require.Equal(t, "same_name", v.Context["rule"])
+	require.Equal(t, "latin1_swedish_ci", v.Context["collation"])
+	require.Equal(t, "utf8mb4_0900_ai_ci", v.Context["expected_collation"])
+	require.Contains(t, v.Message, "2 other table(s) use collation \"utf8mb4_0900_ai_ci\" (e.g. a, b)")
+
+	// With a tie, every occurrence is reported.
+	tables = parseTables(t,
+		`CREATE TABLE a (id BIGINT PRIMARY KEY, email VARCHAR(255) COLLATE utf8mb4_bin)`,
+		`CREATE TABLE b (id BIGINT PRIMARY KEY, email VARCHAR(255) COLLATE utf8mb4_unicode_ci)`,
+	)
+	violations = l.Lint(tables, nil)
+	require.Len(t, violations, 2)
+	require.Equal(t, []string{"utf8mb4_bin", "utf8mb4_unicode_ci"}, violations[0].Context["conflicting_collations"])
+
+	// Ignored columns are skipped.
+	l = newCollationConsistency(t, map[string]string{"checkTableDefault": "false", "ignoreColumns": "email"})
+	require.Empty(t, l.Lint(tables, nil))
+}
+
+func TestCollationConsistency_InferredFK(t *testing.T) {
+	tables := parseTables(t,
+		`CREATE TABLE accounts (id CHAR(36) PRIMARY KEY) CHARSET=utf8mb4 COLLATE=utf8mb4_bin`,
+		`CREATE TABLE sessions (id BIGINT PRIMARY KEY, account_id CHAR(36), KEY idx_account (account_id)) CHARSET=utf8mb4`,
+	)
+	l := newCollationConsistency(t, map[string]string{"checkSameName": "false"})
+	violations := l.Lint(tables, nil)
+	require.Len(t, violations, 1)
+	v := violations[0]
+	require.Equal(t, "sessions", v.Location.Table)
+	require.Equal(t, "account_id", *v.Location.Column)
+	require.Equal(t, "inferred_fk", v.Context["rule"])
+	require.Equal(t, "accounts", v.Context["referenced_table"])
+	require.Contains(t, v.Message, `has collation "utf8mb4_0900_ai_ci" but inferred FK target "accounts".id has collation "utf8mb4_bin"`)
+
+	// Integer ids have no collation.
+	tables = parseTables(t,
+		`CREATE TABLE accounts (id BIGINT PRIMARY KEY) CHARSET=utf8mb4 COLLATE=utf8mb4_bin`,
+		`CREATE TABLE sessions (id BIGINT PRIMARY KEY, account_id BIGINT) CHARSET=latin1`,
+	)
+	require.Empty(t, l.Lint(tables, nil))
+}
+
+func TestCollationConsistency_TableDefault(t *testing.T) {
+	tables := parseTables(t,
+		`CREATE TABLE t1 (
+			id BIGINT PRIMARY KEY,
+			a VARCHAR(100) COLLATE utf8mb4_bin,
+			b VARCHAR(100) CHARACTER SET latin1,
+			c VARCHAR(100) CHARACTER SET utf8mb4,
+			d VARCHAR(100) COLLATE utf8mb4_0900_ai_ci,
+			e VARCHAR(100)
+		) CHARSET=utf8mb4`,
+		// Without a table default, the schema default is not known.
+		`CREATE TABLE t2 (id BIGINT PRIMARY KEY, a VARCHAR(100) COLLATE utf8mb4_bin)`,
+	)
+	l := newCollationConsistency(t, map[string]string{"checkSameName": "false"})
+	violations := l.Lint(tables, nil)
+	require.Len(t, violations, 2)
+	require.Equal(t, "a", *violations[0].Location.Column)
+	require.Equal(t, "table_default", violations[0].Context["rule"])
+	require.Contains(t, violations[0].Message, `Column "a" in table "t1" has collation "utf8mb4_bin", which differs from the table default "utf8mb4_0900_ai_ci"`)
+	require.Equal(t, "b", *violations[1].Location.Column)
+	require.Equal(t, "latin1_swedish_ci", violations[1].Context["collation"])
+}
+
+func TestCollationConsistency_Alter(t *testing.T) {
+	existing := parseTables(t,
+		`CREATE TABLE accounts (id CHAR(36) PRIMARY KEY) CHARSET=utf8mb4`,
+		`CREATE TABLE sessions (id BIGINT PRIMARY KEY) CHARSET=utf8mb4`,
+	)
+	stmts, err := statement.New("ALTER TABLE sessions ADD COLUMN account_id CHAR(36) CHARACTER SET ascii")
+	require.NoError(t, err)
+	violations := (&CollationConsistencyLinter{}).Lint(existing, stmts)
+	require.Len(t, violations, 2)
+	require.Equal(t, "inferred_fk", violations[0].Context["rule"])
+	require.Equal(t, "table_default", violations[1].Context["rule"])
+	require.Equal(t, "ascii_general_ci", violations[1].Context["collation"])
+}
+
+func TestCollationConsistency_Configure(t *testing.T) {
+	l := &CollationConsistencyLinter{}
+	require.NoError(t, l.Configure(l.DefaultConfig()))
+	require.ErrorContains(t, l.Configure(map[string]string{"checkSameName": "maybe"}), "checkSameName")
+	require.ErrorContains(t, l.Configure(map[string]string{"unknown": "x"}), "unknown config key")
+}
@@ -0,0 +1,339 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/block/spirit/pkg/statement"
+	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
+)
+
+func init() {
+	Register(&ExpressionValidationLinter{})
+}
+
+// ExpressionValidationLinter validates the expressions in generated columns,
+// functional indexes and CHECK constraints. MySQL rejects many of them only
+// when the DDL is executed, with errors that are easy to miss in review:
+//
+//   - Generated columns may not use non-deterministic functions (such as
+//     NOW() or UUID()), variables, subqueries or stored functions, and may
+//     not reference AUTO_INCREMENT columns. A VIRTUAL generated column
+//     cannot be part of the PRIMARY KEY.
+//   - Functional index parts on JSON must CAST the value to a scalar type
+//     (or an ARRAY for multi-valued indexes), and JSON columns cannot be
+//     indexed directly.
+//   - CHECK constraints have the same restrictions as generated columns, may
+//     only reference columns of their own table (and a column-level CHECK
+//     only its own column), and may not reference AUTO_INCREMENT columns.
+//
+// Expressions are checked in the post-state of the schema.
+type ExpressionValidationLinter struct{}
+
+func (l *ExpressionValidationLinter) String() string {
+	return Stringer(l)
+}
+
+func (l *ExpressionValidationLinter) Name() string {
+	return "expression_validation"
+}
+
+func (l *ExpressionValidationLinter) Description() string {
+	return "Validates expressions in generated columns, functional indexes and CHECK constraints"
+}
+
+func (l *ExpressionValidationLinter) Lint(existingTables []*statement.CreateTable, changes []*statement.AbstractStatement) (violations []Violation) {
+	for _, ct := range PostState(existingTables, changes) {
+		completeColumns(ct)
+		violations = append(violations, l.lintColumns(ct)...)
+		violations = append(violations, l.lintIndexes(ct)...)
+		violations = append(violations, l.lintChecks(ct)...)
+	}
+	return violations
+}
+
+func (l *ExpressionValidationLinter) lintColumns(ct *statement.CreateTable) (violations []Violation) {
+	for _, col := range ct.Columns {
+		if col.Raw == nil {
+			continue
+		}
+		for _, opt := range col.Raw.Options {
+			if opt.Tp != ast.ColumnOptionGenerated || opt.Expr == nil {
+				continue
+			}
+			kind := "VIRTUAL"
+			if opt.Stored {
+				kind = "STORED"
+			}
+			refs := collectExprRefs(opt.Expr)
+			problems := refs.problems()
+			for _, name := range refs.columns(ct) {
+				if c := ct.Columns.ByName(name); c != nil && c.AutoInc {
+					problems = append(problems, fmt.Sprintf("references AUTO_INCREMENT column %q", c.Name))
+				}
+			}
+			for _, problem := range problems {
+				violations = append(violations, Violation{
+					Linter:     l,
+					Severity:   SeverityError,
+					Location:   &Location{Table: ct.TableName, Column: strPtr(col.Name)},
+					Message:    fmt.Sprintf("Generated column %q (%s) on table %q %s, which MySQL does not allow", col.Name, kind, ct.TableName, problem),
+					Suggestion: strPtr("Compute the value in the application, or use a deterministic expression over columns of the same row"),
+					Context:    map[string]any{"expression": restoreExpr(opt.Expr)},
+				})
+			}
+			if !opt.Stored && isPrimaryKeyColumn(ct, col.Name) {
+				violations = append(violations, Violation{
+					Linter:     l,
+					Severity:   SeverityError,
+					Location:   &Location{Table: ct.TableName, Column: strPtr(col.Name), Index: strPtr("PRIMARY")},
+					Message:    fmt.Sprintf("Virtual generated column %q on table %q is part of the PRIMARY KEY, which MySQL does not support", col.Name, ct.TableName),
+					Suggestion: strPtr(fmt.Sprintf("Define %q as STORED, or use a different primary key", col.Name)),
+				})
+			}
+		}
+	}
+	return violations
+}
+
+func (l *ExpressionValidationLinter) lintIndexes(ct *statement.CreateTable) (violations []Violation) {
+	for _, idx := range ct.GetIndexes() {
+		if idx.Type == "FULLTEXT" || idx.Type == "SPATIAL" {
+			continue
+		}
+		location := &Location{Table: ct.TableName, Index: strPtr(idx.Name)}
+		for _, part := range indexParts(idx) {
+			if part.Expression != nil || part.Name == "" {
+				continue
+			}
+			if col := ct.Columns.ByName(part.Name); col != nil && col.Type == "json" {
+				violations = append(violations, Violation{
+					Linter:     l,
+					Severity:   SeverityError,
+					Location:   &Location{Table: ct.TableName, Index: strPtr(idx.Name), Column: strPtr(col.Name)},
+					Message:    fmt.Sprintf("Index %q on table %q includes JSON column %q, which can only be indexed through a functional index or generated column", idx.Name, ct.TableName, col.Name),
+					Suggestion: strPtr(fmt.Sprintf("Index an expression such as (CAST(%s->>'$.path' AS CHAR(64)))", col.Name)),
+				})
+			}
+		}
+		if idx.Raw == nil {
+			continue
+		}
+		for _, key := range idx.Raw.Keys {
+			if key.Expr == nil || !returnsJSON(key.Expr, ct) {
+				continue
+			}
+			violations = append(violations, Violation{
+				Linter:     l,
+				Severity:   SeverityError,
+				Location:   location,
+				Message:    fmt.Sprintf("Functional index %q on table %q indexes JSON expression %s without a CAST; MySQL cannot index JSON or TEXT values", idx.Name, ct.TableName, restoreExpr(key.Expr)),
+				Suggestion: strPtr("CAST the value to a scalar type, e.g. CAST(... AS CHAR(64)) or CAST(... AS UNSIGNED ARRAY) for a multi-valued index"),
+			})
+		}
+	}
+	return violations
+}
+
+func (l *ExpressionValidationLinter) lintChecks(ct *statement.CreateTable) (violations []Violation) {
+	report := func(name string, column *string, expr ast.ExprNode, problem string) {
+		label := "CHECK constraint"
+		if name != "" {
+			label = fmt.Sprintf("CHECK constraint %q", name)
+		}
+		location := &Location{Table: ct.TableName, Column: column}
+		if name != "" {
+			location.Constraint = strPtr(name)
+		}
+		violations = append(violations, Violation{
+			Linter:     l,
+			Severity:   SeverityError,
+			Location:   location,
+			Message:    fmt.Sprintf("%s on table %q %s, which MySQL does not allow", label, ct.TableName, problem),
+			Suggestion: strPtr("CHECK constraints may only use deterministic expressions over the columns of their own row; enforce other rules in the application or with a trigger"),
+			Context:    map[string]any{"expression": restoreExpr(expr)},
+		})
+	}
+	check := func(name string, column *string, expr ast.ExprNode) {
+		refs := collectExprRefs(expr)
+		for _, problem := range refs.problems() {
+			report(name, column, expr, problem)
+		}
+		for _, ref := range refs.columnNames {
+			switch {
+			case ref.Table.L != "" && !strings.EqualFold(ref.Table.O, ct.TableName):
+				report(name, column, expr, fmt.Sprintf("references column %q of table %q", ref.Name.O, ref.Table.O))
+			case ct.Columns.ByName(ref.Name.O) == nil:
+				report(name, column, expr, fmt.Sprintf("references column %q, which does not exist", ref.Name.O))
+			case column != nil && !strings.EqualFold(ref.Name.O, *column):
+				report(name, column, expr, fmt.Sprintf("is a column-level CHECK on %q but references column %q", *column, ref.Name.O))
+			case ct.Columns.ByName(ref.Name.O).AutoInc:
+				report(name, column, expr, fmt.Sprintf("references AUTO_INCREMENT column %q", ref.Name.O))
+			}
+		}
+	}
+
+	for _, c := range ct.Constraints {
+		if c.Type == "CHECK" && c.Raw != nil && c.Raw.Expr != nil {
+			check(c.Name, nil, c.Raw.Expr)
+		}
+	}
+	for _, col := range ct.Columns {
+		if col.Raw == nil {
+			continue
+		}
+		for _, opt := range col.Raw.Options {
+			if opt.Tp == ast.ColumnOptionCheck && opt.Expr != nil {
+				check(opt.ConstraintName, strPtr(col.Name), opt.Expr)
+			}
+		}
+	}
+	return violations
+}
+
+// nonDeterministicFunctions are the built-in functions that MySQL does not
+// allow in generated columns and CHECK constraints.
+var nonDeterministicFunctions = map[string]struct{}{
+	"benchmark": {}, "connection_id": {}, "curdate": {}, "current_date": {}, "current_role": {},
+	"current_time": {}, "current_timestamp": {}, "current_user": {}, "curtime": {}, "database": {},
+	"found_rows": {}, "get_lock": {}, "is_free_lock": {}, "is_used_lock": {}, "last_insert_id": {},
+	"load_file": {}, "localtime": {}, "localtimestamp": {}, "master_pos_wait": {}, "now": {},
+	"rand": {}, "random_bytes": {}, "release_all_locks": {}, "release_lock": {}, "row_count": {},
+	"schema": {}, "session_user": {}, "sleep": {}, "source_pos_wait": {}, "sysdate": {},
+	"system_user": {}, "user": {}, "utc_date": {}, "utc_time": {}, "utc_timestamp": {},
+	"uuid": {}, "uuid_short": {}, "version": {},
+}
+
+// jsonFunctions are the built-in functions that return JSON, or TEXT in the
+// case of JSON_UNQUOTE (and the ->> operator).
+var jsonFunctions = map[string]struct{}{
+	"json_array": {}, "json_array_append": {}, "json_array_insert": {}, "json_extract": {},
+	"json_insert": {}, "json_keys": {}, "json_merge": {}, "json_merge_patch": {},
+	"json_merge_preserve": {}, "json_object": {}, "json_quote": {}, "json_remove": {},
+	"json_replace": {}, "json_search": {}, "json_set": {}, "json_unquote": {},
+}
+
+// exprRefs is what an expression refers to, as collected by collectExprRefs.
+type exprRefs struct {
+	columnNames []*ast.ColumnName
+	functions   []string
+	variables   []string
+	subquery    bool
+}
+
+// collectExprRefs walks an expression and collects its columns, the
+// functions that are forbidden in generated columns and CHECK constraints,
+// variables and subqueries.
+func collectExprRefs(expr ast.ExprNode) *exprRefs {
+	refs := &exprRefs{}
+	expr.Accept(&exprVisitor{enter: func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.ColumnNameExpr:
+			refs.columnNames = append(refs.columnNames, n.Name)
+		case *ast.FuncCallExpr:
+			name := n.FnName.L
+			if _, ok := nonDeterministicFunctions[name]; ok || name == "unix_timestamp" && len(n.Args) == 0 {
+				refs.functions = append(refs.functions, strings.ToUpper(name)+"()")
+			} else if n.Schema.L != "" {
+				refs.functions = append(refs.functions, n.Schema.O+"."+n.FnName.O+"()")
+			}
+		case *ast.VariableExpr:
+			prefix := "@"
+			if n.IsSystem {
+				prefix = "@@"
+			}
+			refs.variables = append(refs.variables, prefix+n.Name)
+		case *ast.SubqueryExpr:
+			refs.subquery = true
+		}
+	}})
+	return refs
+}
+
+// problems describes the non-deterministic functions, stored functions,
+// variables and subqueries in the expression.
+func (r *exprRefs) problems() (problems []string) {
+	for _, fn := range r.functions {
+		problems = append(problems, fmt.Sprintf("uses non-deterministic or stored function %s", fn))
+	}
+	for _, v := range r.variables {
+		problems = append(problems, fmt.Sprintf("uses variable %s", v))
+	}
+	if r.subquery {
+		problems = append(problems, "uses a subquery")
+	}
+	return problems
+}
+
+// columns returns the names of the columns of ct that the expression
+// references.
+func (r *exprRefs) columns(ct *statement.CreateTable) (names []string) {
+	for _, ref := range r.columnNames {
+		if ref.Table.L == "" || strings.EqualFold(ref.Table.O, ct.TableName) {
+			names = append(names, ref.Name.O)
+		}
+	}
+	return names
+}
+
+// returnsJSON returns true if an index expression evaluates to JSON or to
+// the TEXT returned by JSON_UNQUOTE: a JSON column or a JSON function that
+// is not wrapped in a CAST.
+func returnsJSON(expr ast.ExprNode, ct *statement.CreateTable) bool {
+	for {
+		paren, ok := expr.(*ast.ParenthesesExpr)
+		if !ok {
+			break
+		}
+		expr = paren.Expr
+	}
+	switch e := expr.(type) {
+	case *ast.FuncCallExpr:
+		_, ok := jsonFunctions[e.FnName.L]
+		return ok
+	case *ast.ColumnNameExpr:
+		col := ct.Columns.ByName(e.Name.Name.O)
+		return col != nil && col.Type == "json"
+	}
+	return false
+}
+
+// isPrimaryKeyColumn returns true if the column is part of the PRIMARY KEY.
+func isPrimaryKeyColumn(ct *statement.CreateTable, name string) bool {
+	for _, idx := range ct.GetIndexes() {
+		if idx.Type != "PRIMARY KEY" {
+			continue
+		}
+		for _, part := range indexParts(idx) {
+			if strings.EqualFold(part.Name, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func restoreExpr(expr ast.ExprNode) string {
+	var sb strings.Builder
+	if err := expr.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
+		return ""
+	}
+	return sb.String()
+}
+
+// exprVisitor calls enter for every node of an expression. It does not
+// descend into subqueries, whose columns belong to other tables.
+type exprVisitor struct {
+	enter func(ast.Node)
+}
+
+func (v *exprVisitor) Enter(n ast.Node) (ast.Node, bool) {
+	v.enter(n)
+	_, isSubquery := n.(*ast.SubqueryExpr)
+	return n, isSubquery
+}
+
+func (v *exprVisitor) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
@@ -0,0 +1,133 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/block/spirit/pkg/statement"
+	"github.com/stretchr/testify/require"
+)
+
+func lintExpressions(t *testing.T, existing []string, sql string) []Violation {
+	t.Helper()
+	stmts, err := statement.New(sql)
+	require.NoError(t, err)
+	return (&ExpressionValidationLinter{}).Lint(parseCreateTables(t, existing...), stmts)
+}
+
+func TestExpressionValidationLinter_Valid(t *testing.T) {
+	violations := lintExpressions(t, nil, `CREATE TABLE t1 (
+  id bigint unsigned NOT NULL AUTO_INCREMENT,
+  price decimal(10,2) NOT NULL,
+  qty int NOT NULL CHECK (qty >= 0),
+  total decimal(12,2) AS (price * qty) STORED,
+  data json,
+  name_lower varchar(100) AS (lower(json_unquote(json_extract(data, '$.name')))) VIRTUAL,
+  PRIMARY KEY (id),
+  KEY idx_name ((CAST(data->>'$.name' AS CHAR(64)))),
+  KEY idx_tags ((CAST(data->'$.tags' AS UNSIGNED ARRAY))),
+  KEY idx_len ((json_length(data))),
+  KEY idx_name_lower (name_lower),
+  CONSTRAINT chk_price CHECK (price > 0 AND price < 1000000)
+)`)
+	require.Empty(t, violations)
+}
+
+func TestExpressionValidationLinter_GeneratedColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		column  string
+		message string
+	}{
+		{"now", "created_day date AS (date(now())) STORED", "uses non-deterministic or stored function NOW()"},
+		{"uuid", "token char(36) AS (uuid()) STORED", "uses non-deterministic or stored function UUID()"},
+		{"unix_timestamp without arguments", "ts int AS (unix_timestamp()) VIRTUAL", "UNIX_TIMESTAMP()"},
+		{"variable", "v int AS (@x) STORED", "uses variable @x"},
+		{"stored function", "v int AS (mydb.f(1)) STORED", "uses non-deterministic or stored function mydb.f()"},
+		{"auto_increment", "v bigint AS (id + 1) STORED", `references AUTO_INCREMENT column "id"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := lintExpressions(t, nil, "CREATE TABLE t1 (id bigint NOT NULL AUTO_INCREMENT PRIMARY KEY, "+tt.column+")")
+			require.Len(t, violations, 1)
+			require.Equal(t, SeverityError, violations[0].Severity)
+			require.Contains(t, violations[0].Message, tt.message)
+			require.NotNil(t, violations[0].Location.Column)
+		})
+	}
+
+	// unix_timestamp with an argument is deterministic.
+	require.Empty(t, lintExpressions(t, nil, "CREATE TABLE t1 (id bigint PRIMARY KEY, d datetime, ts bigint AS (unix_timestamp(d)) STORED)"))
+}
+
+func TestExpressionValidationLinter_VirtualPrimaryKey(t *testing.T) {
+	violations := lintExpressions(t, nil, "CREATE TABLE t1 (a int NOT NULL, b int AS (a * 2) NOT NULL, PRIMARY KEY (a, b))")
+	require.Len(t, violations, 1)
+	require.Equal(t, "b", *violations[0].Location.Column)
+	require.Contains(t, violations[0].Message, `Virtual generated column "b" on table "t1" is part of the PRIMARY KEY`)
+
+	// Inline primary keys are included.
+	violations = lintExpressions(t, nil, "CREATE TABLE t1 (a int NOT NULL, b int AS (a * 2) VIRTUAL PRIMARY KEY)")
+	require.Len(t, violations, 1)
+
+	// STORED generated columns can be part of the PRIMARY KEY.
+	require.Empty(t, lintExpressions(t, nil, "CREATE TABLE t1 (a int NOT NULL, b int AS (a * 2) STORED NOT NULL, PRIMARY KEY (b))"))
+}
+
+func TestExpressionValidationLinter_JSONIndexes(t *testing.T) {
+	violations := lintExpressions(t, nil, `CREATE TABLE t1 (
+  id bigint PRIMARY KEY,
+  data json,
+  KEY idx_extract ((data->'$.name')),
+  KEY idx_unquote ((data->>'$.name')),
+  KEY idx_column (data)
+)`)
+	require.Len(t, violations, 3)
+	require.Equal(t, "idx_extract", *violations[0].Location.Index)
+	require.Contains(t, violations[0].Message, "without a CAST")
+	require.Equal(t, "idx_unquote", *violations[1].Location.Index)
+	require.Equal(t, "idx_column", *violations[2].Location.Index)
+	require.Equal(t, "data", *violations[2].Location.Column)
+	require.Contains(t, violations[2].Message, "includes JSON column")
+
+	// Indexes added by an ALTER are checked.
+	violations = lintExpressions(t, []string{"CREATE TABLE t1 (id bigint PRIMARY KEY, data json)"},
+		"ALTER TABLE t1 ADD INDEX idx_name ((json_extract(data, '$.name')))")
+	require.Len(t, violations, 1)
+	require.Equal(t, "idx_name", *violations[0].Location.Index)
+}
+
+func TestExpressionValidationLinter_Checks(t *testing.T) {
+	tests := []struct {
+		name    string
+		check   string
+		message string
+	}{
+		{"other table", "CONSTRAINT chk CHECK (t2.qty > 0)", `references column "qty" of table "t2"`},
+		{"unknown column", "CONSTRAINT chk CHECK (quantity > 0)", `references column "quantity", which does not exist`},
+		{"subquery", "CONSTRAINT chk CHECK (qty IN (SELECT quantity FROM t2))", "uses a subquery"},
+		{"function", "CONSTRAINT chk CHECK (created < now())", "uses non-deterministic or stored function NOW()"},
+		{"system variable", "CONSTRAINT chk CHECK (qty < @@max_connections)", "uses variable @@max_connections"},
+		{"auto_increment", "CONSTRAINT chk CHECK (id > 0)", `references AUTO_INCREMENT column "id"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := lintExpressions(t, nil, "CREATE TABLE t1 (id bigint NOT NULL AUTO_INCREMENT PRIMARY KEY, qty int, created datetime, "+tt.check+")")
+			require.Len(t, violations, 1)
+			require.Equal(t, SeverityError, violations[0].Severity)
+			require.Contains(t, violations[0].Message, `CHECK constraint "chk" on table "t1" `+tt.message)
+			require.Equal(t, "chk", *violations[0].Location.Constraint)
+		})
+	}
+
+	// A column-level CHECK may only reference its own column.
+	violations := lintExpressions(t, nil, "CREATE TABLE t1 (id bigint PRIMARY KEY, a int, b int CHECK (b > a))")
+	require.Len(t, violations, 1)
+	require.Equal(t, "b", *violations[0].Location.Column)
+	require.Contains(t, violations[0].Message, `is a column-level CHECK on "b" but references column "a"`)
+
+	// CHECK constraints added by an ALTER are checked.
+	violations = lintExpressions(t, []string{"CREATE TABLE t1 (id bigint PRIMARY KEY, qty int)"},
+		"ALTER TABLE t1 ADD CONSTRAINT chk_qty CHECK (qty < rand())")
+	require.Len(t, violations, 1)
+	require.Equal(t, "chk_qty", *violations[0].Location.Constraint)
+}
@@ -0,0 +1,246 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/block/spirit/pkg/statement"
+)
+
+func init() {
+	Register(&ForeignKeyCompatibilityLinter{})
+}
+
+// ForeignKeyCompatibilityLinter validates each FOREIGN KEY against the table
+// it references. Unlike has_foreign_key, which flags FKs as a policy, this is
+// for teams that use FKs: it catches the mistakes that otherwise surface
+// late, as cryptic MySQL errors when the DDL is executed.
+//
+//   - The referencing and referenced columns must have compatible types:
+//     the same integer size and signedness, DECIMAL precision and scale, and
+//     character set and collation (error 3780). Different string lengths
+//     are allowed by MySQL, but are reported as a warning.
+//   - The referenced columns must be indexed (error 1822), and should be
+//     exactly a PRIMARY KEY or UNIQUE index: MySQL 8.4 rejects references to
+//     non-unique keys by default.
+//   - The referencing columns should have a supporting index. MySQL creates
+//     one implicitly if there is none, which then differs from the schema
+//     definition.
+//
+// FKs are checked in the post-state of the schema. References to tables
+// that are not known (such as those in another schema) are skipped.
+type ForeignKeyCompatibilityLinter struct{}
+
+func (l *ForeignKeyCompatibilityLinter) String() string {
+	return Stringer(l)
+}
+
+func (l *ForeignKeyCompatibilityLinter) Name() string {
+	return "foreign_key_compatibility"
+}
+
+func (l *ForeignKeyCompatibilityLinter) Description() string {
+	return "Validates that foreign keys match the types and keys of the referenced table, and have a supporting index"
+}
+
+func (l *ForeignKeyCompatibilityLinter) Lint(existingTables []*statement.CreateTable, changes []*statement.AbstractStatement) (violations []Violation) {
+	tables := PostState(existingTables, changes)
+	byName := make(map[string]*statement.CreateTable, len(tables))
+	for _, ct := range tables {
+		completeColumns(ct)
+		byName[strings.ToLower(ct.TableName)] = ct
+	}
+	for _, ct := range tables {
+		for _, fk := range ct.Constraints {
+			if fk.Type != "FOREIGN KEY" || fk.References == nil {
+				continue
+			}
+			violations = append(violations, l.lintForeignKey(ct, fk, byName[strings.ToLower(fk.References.Table)])...)
+		}
+	}
+	return violations
+}
+
+func (l *ForeignKeyCompatibilityLinter) lintForeignKey(ct *statement.CreateTable, fk statement.Constraint, refTable *statement.CreateTable) (violations []Violation) {
+	label := fkLabel(fk)
+	location := func(column *string) *Location {
+		loc := &Location{Table: ct.TableName, Column: column}
+		if fk.Name != "" {
+			loc.Constraint = strPtr(fk.Name)
+		}
+		return loc
+	}
+	report := func(severity Severity, column *string, message string, suggestion *string) {
+		violations = append(violations, Violation{
+			Linter:     l,
+			Severity:   severity,
+			Location:   location(column),
+			Message:    message,
+			Suggestion: suggestion,
+		})
+	}
+
+	if !hasIndexOn(ct, fk.Columns, false) {
+		report(SeverityWarning, nil,
+			fmt.Sprintf("Foreign key %s on table %q has no index on (%s); MySQL will create one implicitly, which is not part of the schema definition", label, ct.TableName, strings.Join(fk.Columns, ", ")),
+			strPtr(fmt.Sprintf("Add an index on (%s)", strings.Join(fk.Columns, ", "))))
+	}
+
+	if refTable == nil {
+		return violations
+	}
+	refColumns := fk.References.Columns
+	if len(refColumns) != len(fk.Columns) {
+		report(SeverityError, nil,
+			fmt.Sprintf("Foreign key %s on table %q has %d columns but references %d columns of table %q", label, ct.TableName, len(fk.Columns), len(refColumns), refTable.TableName),
+			nil)
+		return violations
+	}
+
+	for i, name := range fk.Columns {
+		col := ct.Columns.ByName(name)
+		refCol := refTable.Columns.ByName(refColumns[i])
+		if col == nil || refCol == nil {
+			missing, table := refColumns[i], refTable.TableName
+			if col == nil {
+				missing, table = name, ct.TableName
+			}
+			report(SeverityError, nil, fmt.Sprintf("Foreign key %s on table %q uses column %q, which does not exist in table %q", label, ct.TableName, missing, table), nil)
+			continue
+		}
+		severity, reason := fkColumnMismatch(col, ct, refCol, refTable)
+		if reason == "" {
+			continue
+		}
+		report(severity, strPtr(col.Name),
+			fmt.Sprintf("Foreign key %s on table %q: column %q does not match referenced column %q.%q: %s", label, ct.TableName, col.Name, refTable.TableName, refCol.Name, reason),
+			strPtr(fmt.Sprintf("Define %q exactly like %q.%q", col.Name, refTable.TableName, refCol.Name)))
+	}
+
+	switch {
+	case hasIndexOn(refTable, refColumns, true):
+	case hasIndexOn(refTable, refColumns, false):
+		report(SeverityWarning, nil,
+			fmt.Sprintf("Foreign key %s on table %q references (%s) of table %q, which is not a PRIMARY KEY or UNIQUE index; this is deprecated and rejected by default in MySQL 8.4", label, ct.TableName, strings.Join(refColumns, ", "), refTable.TableName),
+			strPtr("Reference the primary key or a unique index of the parent table"))
+	default:
+		report(SeverityError, nil,
+			fmt.Sprintf("Foreign key %s on table %q references (%s) of table %q, which is not indexed (MySQL error 1822)", label, ct.TableName, strings.Join(refColumns, ", "), refTable.TableName),
+			strPtr("Reference the primary key or a unique index of the parent table"))
+	}
+	return violations
+}
+
+// fkColumnMismatch describes how col differs from the column it
+// references, or returns "" if they are compatible.
+func fkColumnMismatch(col *statement.Column, ct *statement.CreateTable, refCol *statement.Column, refTable *statement.CreateTable) (Severity, string) {
+	colType, refType := columnTypeString(col), columnTypeString(refCol)
+	family := fkTypeFamily(col.Type)
+	if family != fkTypeFamily(refCol.Type) {
+		return SeverityError, fmt.Sprintf("type %s is incompatible with %s", colType, refType)
+	}
+	if isIntegerType(col.Type) || col.Type == "decimal" || col.Type == "float" || col.Type == "double" {
+		if !sameBool(col.Unsigned, refCol.Unsigned) {
+			return SeverityError, fmt.Sprintf("signedness differs (%s vs %s)", colType, refType)
+		}
+	}
+	if col.Type == "decimal" && (!sameInt(col.Precision, refCol.Precision) || !sameInt(col.Scale, refCol.Scale)) {
+		return SeverityError, fmt.Sprintf("precision or scale differs (%s vs %s)", colType, refType)
+	}
+	if family == "string" {
+		charset, collation := effectiveCharsetCollation(col, ct)
+		refCharset, refCollation := effectiveCharsetCollation(refCol, refTable)
+		if charset != "" && refCharset != "" && charset != refCharset {
+			return SeverityError, fmt.Sprintf("character set differs (%s vs %s)", charset, refCharset)
+		}
+		if collation != "" && refCollation != "" && collation != refCollation {
+			return SeverityError, fmt.Sprintf("collation differs (%s vs %s)", collation, refCollation)
+		}
+	}
+	if (family == "string" || family == "binary") && !sameInt(col.Length, refCol.Length) {
+		return SeverityWarning, fmt.Sprintf("length differs (%s vs %s)", colType, refType)
+	}
+	return SeverityInfo, ""
+}
+
+// fkTypeFamily groups types that MySQL considers similar enough for a
+// foreign key: the length of string types does not need to match.
+func fkTypeFamily(typ string) string {
+	switch typ {
+	case "char", "varchar":
+		return "string"
+	case "binary", "varbinary":
+		return "binary"
+	}
+	return typ
+}
+
+// effectiveCharsetCollation returns the lowercased character set and
+// collation of a string column, inheriting them from the table. Either is
+// "" if it cannot be determined without the server's defaults.
+func effectiveCharsetCollation(col *statement.Column, ct *statement.CreateTable) (string, string) {
+	var charset, collation string
+	if col.Collation != nil {
+		collation = strings.ToLower(*col.Collation)
+	}
+	switch {
+	case col.Charset != nil:
+		charset = strings.ToLower(*col.Charset)
+	case collation != "":
+		charset = *collationCharset(collation)
+	}
+	if ct.TableOptions != nil && charset == "" && collation == "" {
+		if ct.TableOptions.Charset != nil {
+			charset = strings.ToLower(*ct.TableOptions.Charset)
+		}
+		if ct.TableOptions.Collation != nil {
+			collation = strings.ToLower(*ct.TableOptions.Collation)
+			if charset == "" {
+				charset = *collationCharset(collation)
+			}
+		}
+	}
+	if charset == "utf8" {
+		charset = "utf8mb3"
+	}
+	collation = strings.Replace(collation, "utf8_", "utf8mb3_", 1)
+	return charset, collation
+}
+
+// hasIndexOn returns true if the table has an index whose leading columns
+// are columns, in order and without prefix lengths. With unique, the index
+// must be a PRIMARY KEY or UNIQUE index on exactly those columns.
+func hasIndexOn(ct *statement.CreateTable, columns []string, unique bool) bool {
+	for _, idx := range ct.GetIndexes() {
+		if idx.Type == "FULLTEXT" || idx.Type == "SPATIAL" {
+			continue
+		}
+		if unique && idx.Type != "PRIMARY KEY" && idx.Type != "UNIQUE" {
+			continue
+		}
+		parts := indexParts(idx)
+		if len(parts) < len(columns) || unique && len(parts) != len(columns) {
+			continue
+		}
+		matches := true
+		for i, name := range columns {
+			if parts[i].Expression != nil || parts[i].Length != nil || !strings.EqualFold(parts[i].Name, name) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// fkLabel returns the quoted name of a foreign key, or its columns if it
+// is unnamed.
+func fkLabel(fk statement.Constraint) string {
+	if fk.Name != "" {
+		return fmt.Sprintf("%q", fk.Name)
+	}
+	return "(" + strings.Join(fk.Columns, ", ") + ")"
+}
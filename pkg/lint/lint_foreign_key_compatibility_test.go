@@ -0,0 +1,162 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/block/spirit/pkg/statement"
+	"github.com/stretchr/testify/require"
+)
+
+const fkUsersTable = `CREATE TABLE users (
+  id bigint unsigned NOT NULL AUTO_INCREMENT,
+  email varchar(255) NOT NULL,
+  region varchar(20) NOT NULL,
+  code char(8) CHARACTER SET latin1 NOT NULL,
+  nickname varchar(50) NOT NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_email (email),
+  UNIQUE KEY uk_code (code),
+  KEY idx_region (region)
+) DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci`
+
+func lintForeignKeys(t *testing.T, existing []string, sql string) []Violation {
+	t.Helper()
+	stmts, err := statement.New(sql)
+	require.NoError(t, err)
+	return (&ForeignKeyCompatibilityLinter{}).Lint(parseCreateTables(t, existing...), stmts)
+}
+
+func TestForeignKeyCompatibilityLinter_Compatible(t *testing.T) {
+	violations := lintForeignKeys(t, []string{fkUsersTable}, `CREATE TABLE orders (
+  id bigint unsigned NOT NULL AUTO_INCREMENT,
+  user_id bigint unsigned NOT NULL,
+  user_email varchar(255) NOT NULL,
+  PRIMARY KEY (id),
+  KEY idx_user (user_id),
+  KEY idx_user_email (user_email),
+  CONSTRAINT fk_orders_user FOREIGN KEY (user_id) REFERENCES users (id),
+  CONSTRAINT fk_orders_email FOREIGN KEY (user_email) REFERENCES users (email)
+) DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci`)
+	require.Empty(t, violations)
+}
+
+func TestForeignKeyCompatibilityLinter_ColumnMismatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		column   string
+		refers   string
+		severity Severity
+		message  string
+	}{
+		{"signedness", "user_id bigint NOT NULL", "id", SeverityError, "signedness differs (bigint vs bigint unsigned)"},
+		{"integer size", "user_id int unsigned NOT NULL", "id", SeverityError, "type int unsigned is incompatible with bigint unsigned"},
+		{"charset", "user_id varchar(255) CHARACTER SET latin1 NOT NULL", "email", SeverityError, "character set differs (latin1 vs utf8mb4)"},
+		{"collation", "user_id varchar(255) COLLATE utf8mb4_bin NOT NULL", "email", SeverityError, "collation differs (utf8mb4_bin vs utf8mb4_0900_ai_ci)"},
+		{"column charset on referenced side", "user_id char(8) NOT NULL", "code", SeverityError, "character set differs (utf8mb4 vs latin1)"},
+		{"length", "user_id varchar(100) NOT NULL", "email", SeverityWarning, "length differs (varchar(100) vs varchar(255))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := lintForeignKeys(t, []string{fkUsersTable}, `CREATE TABLE orders (
+  id bigint unsigned NOT NULL AUTO_INCREMENT,
+  `+tt.column+`,
+  PRIMARY KEY (id),
+  KEY idx_user (user_id),
+  CONSTRAINT fk_orders_user FOREIGN KEY (user_id) REFERENCES users (`+tt.refers+`)
+) DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci`)
+			require.Len(t, violations, 1)
+			require.Equal(t, tt.severity, violations[0].Severity)
+			require.Contains(t, violations[0].Message, tt.message)
+			require.Equal(t, "orders", violations[0].Location.Table)
+			require.Equal(t, "user_id", *violations[0].Location.Column)
+			require.Equal(t, "fk_orders_user", *violations[0].Location.Constraint)
+		})
+	}
+}
+
+func TestForeignKeyCompatibilityLinter_ReferencedKey(t *testing.T) {
+	// A non-unique index is deprecated.
+	violations := lintForeignKeys(t, []string{fkUsersTable}, `CREATE TABLE orders (
+  id bigint unsigned NOT NULL,
+  region varchar(20) NOT NULL,
+  PRIMARY KEY (id),
+  KEY idx_region (region),
+  CONSTRAINT fk_orders_region FOREIGN KEY (region) REFERENCES users (region)
+) DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci`)
+	require.Len(t, violations, 1)
+	require.Equal(t, SeverityWarning, violations[0].Severity)
+	require.Contains(t, violations[0].Message, "not a PRIMARY KEY or UNIQUE index")
+
+	// No index at all is MySQL error 1822.
+	violations = lintForeignKeys(t, []string{fkUsersTable}, `CREATE TABLE orders (
+  id bigint unsigned NOT NULL,
+  nickname varchar(50) NOT NULL,
+  PRIMARY KEY (id),
+  KEY idx_nickname (nickname),
+  CONSTRAINT fk_orders_nickname FOREIGN KEY (nickname) REFERENCES users (nickname)
+) DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci`)
+	require.Len(t, violations, 1)
+	require.Equal(t, SeverityError, violations[0].Severity)
+	require.Contains(t, violations[0].Message, "which is not indexed")
+}
+
+func TestForeignKeyCompatibilityLinter_SupportingIndex(t *testing.T) {
+	violations := lintForeignKeys(t, []string{fkUsersTable}, `CREATE TABLE orders (
+  id bigint unsigned NOT NULL,
+  status int NOT NULL,
+  user_id bigint unsigned NOT NULL,
+  PRIMARY KEY (id),
+  KEY idx_status_user (status, user_id),
+  FOREIGN KEY (user_id) REFERENCES users (id)
+)`)
+	require.Len(t, violations, 1)
+	require.Equal(t, SeverityWarning, violations[0].Severity)
+	require.Contains(t, violations[0].Message, "Foreign key (user_id) on table \"orders\" has no index on (user_id)")
+	require.Nil(t, violations[0].Location.Constraint)
+
+	// The primary key can be the supporting index.
+	violations = lintForeignKeys(t, []string{fkUsersTable}, `CREATE TABLE profiles (
+  user_id bigint unsigned NOT NULL,
+  PRIMARY KEY (user_id),
+  CONSTRAINT fk_profiles_user FOREIGN KEY (user_id) REFERENCES users (id)
+)`)
+	require.Empty(t, violations)
+}
+
+func TestForeignKeyCompatibilityLinter_Alter(t *testing.T) {
+	orders := `CREATE TABLE orders (
+  id bigint unsigned NOT NULL,
+  user_id int NOT NULL,
+  PRIMARY KEY (id),
+  KEY idx_user (user_id)
+)`
+	violations := lintForeignKeys(t, []string{fkUsersTable, orders},
+		"ALTER TABLE orders ADD CONSTRAINT fk_orders_user FOREIGN KEY (user_id) REFERENCES users (id)")
+	require.Len(t, violations, 1)
+	require.Equal(t, SeverityError, violations[0].Severity)
+	require.Contains(t, violations[0].Message, "type int is incompatible with bigint unsigned")
+
+	// Fixing the type in the same ALTER resolves it.
+	violations = lintForeignKeys(t, []string{fkUsersTable, orders},
+		"ALTER TABLE orders MODIFY COLUMN user_id bigint unsigned NOT NULL, ADD CONSTRAINT fk_orders_user FOREIGN KEY (user_id) REFERENCES users (id)")
+	require.Empty(t, violations)
+
+	// Unknown referenced tables are skipped, apart from the supporting index.
+	violations = lintForeignKeys(t, []string{orders},
+		"ALTER TABLE orders ADD CONSTRAINT fk_orders_user FOREIGN KEY (user_id) REFERENCES users (id)")
+	require.Empty(t, violations)
+}
+
+func TestForeignKeyCompatibilityLinter_UnknownColumn(t *testing.T) {
+	violations := lintForeignKeys(t, []string{fkUsersTable}, `CREATE TABLE orders (
+  id bigint unsigned NOT NULL,
+  user_id bigint unsigned NOT NULL,
+  PRIMARY KEY (id),
+  KEY idx_user (user_id),
+  CONSTRAINT fk_orders_user FOREIGN KEY (user_id) REFERENCES users (uid)
+)`)
+	require.Len(t, violations, 2)
+	require.Equal(t, SeverityError, violations[0].Severity)
+	require.Contains(t, violations[0].Message, `uses column "uid", which does not exist in table "users"`)
+	require.Contains(t, violations[1].Message, "which is not indexed")
+}
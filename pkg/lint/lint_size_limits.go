@@ -0,0 +1,398 @@
+package lint
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/block/spirit/pkg/statement"
+)
+
+func init() {
+	Register(&SizeLimitsLinter{})
+}
+
+// MySQL and InnoDB size limits.
+const (
+	// maxRowSize is MySQL's limit on the combined maximum size of a row's
+	// columns, not counting the contents of BLOB and TEXT columns.
+	maxRowSize = 65535
+	// maxKeyLength is InnoDB's limit on the length of an index key with a
+	// 16KB or larger page size; it scales down with smaller pages.
+	maxKeyLength = 3072
+	// maxAntelopeKeyColumnLength is InnoDB's limit on the length of each
+	// index column with ROW_FORMAT=REDUNDANT or COMPACT.
+	maxAntelopeKeyColumnLength = 767
+	// externalFieldRefSize is the size of the pointer InnoDB keeps in the
+	// row for a column that is stored off-page.
+	externalFieldRefSize = 20
+)
+
+// SizeLimitsLinter detects indexes and rows that exceed MySQL's size limits.
+// These are otherwise only found when the DDL is executed, which for a
+// migration can be halfway through.
+//
+// Index key lengths are computed from the column types, character sets
+// (e.g. 4 bytes per character for utf8mb4) and prefix lengths, and are
+// checked against InnoDB's limit of 3072 bytes per index (767 bytes per
+// column with ROW_FORMAT=REDUNDANT or COMPACT). The maximum row size is
+// checked against MySQL's limit of 65535 bytes. Both are errors.
+//
+// InnoDB also requires a row to fit in about half a page (8126 bytes with
+// the default 16KB page size), with large variable-length columns moved
+// off-page. When the worst case cannot fit, a warning is reported: the
+// table can be created, but inserting rows near the maximum size fails.
+type SizeLimitsLinter struct {
+	pageSize int
+}
+
+func (l *SizeLimitsLinter) String() string {
+	return Stringer(l)
+}
+
+func (l *SizeLimitsLinter) Name() string {
+	return "size_limits"
+}
+
+func (l *SizeLimitsLinter) Description() string {
+	return "Detects indexes and rows that exceed MySQL's key length and row size limits"
+}
+
+func (l *SizeLimitsLinter) Configure(config map[string]string) error {
+	for k, v := range config {
+		switch k {
+		case "pageSize":
+			pageSize, err := strconv.Atoi(v)
+			if err != nil || (pageSize != 4096 && pageSize != 8192 && pageSize != 16384 && pageSize != 32768 && pageSize != 65536) {
+				return fmt.Errorf("invalid value for %s: %s (expected 4096, 8192, 16384, 32768 or 65536)", k, v)
+			}
+			l.pageSize = pageSize
+		default:
+			return fmt.Errorf("unknown config key for %s: %s", l.Name(), k)
+		}
+	}
+	return nil
+}
+
+func (l *SizeLimitsLinter) DefaultConfig() map[string]string {
+	return map[string]string{
+		"pageSize": "16384",
+	}
+}
+
+// Lint checks the post-state of the schema, so that both new tables and
+// ALTERs that add or widen columns and indexes are checked.
+func (l *SizeLimitsLinter) Lint(existingTables []*statement.CreateTable, changes []*statement.AbstractStatement) (violations []Violation) {
+	if l.pageSize == 0 {
+		l.pageSize = 16384
+	}
+	for _, ct := range PostState(existingTables, changes) {
+		completeColumns(ct)
+		violations = append(violations, l.lintIndexes(ct)...)
+		violations = append(violations, l.lintRowSize(ct)...)
+	}
+	return violations
+}
+
+// keyLengthLimit returns the maximum index key length for the page size.
+func (l *SizeLimitsLinter) keyLengthLimit() int {
+	switch l.pageSize {
+	case 4096:
+		return 768
+	case 8192:
+		return 1536
+	default:
+		return maxKeyLength
+	}
+}
+
+// rowSizeLimit returns the maximum in-page row size for the page size.
+func (l *SizeLimitsLinter) rowSizeLimit() int {
+	if l.pageSize == 65536 {
+		return 16383
+	}
+	return l.pageSize/2 - 66
+}
+
+func (l *SizeLimitsLinter) lintIndexes(ct *statement.CreateTable) (violations []Violation) {
+	antelope := isAntelopeRowFormat(ct)
+	for _, idx := range ct.GetIndexes() {
+		if idx.Type == "FULLTEXT" || idx.Type == "SPATIAL" {
+			continue
+		}
+		total := 0
+		for _, part := range indexParts(idx) {
+			if part.Expression != nil {
+				continue // the type of a functional key part is not known
+			}
+			col := ct.Columns.ByName(part.Name)
+			if col == nil {
+				continue
+			}
+			length, ok := keyPartLength(col, part.Length, ct)
+			if !ok {
+				continue
+			}
+			if antelope && length > maxAntelopeKeyColumnLength {
+				violations = append(violations, Violation{
+					Linter:     l,
+					Severity:   SeverityError,
+					Location:   &Location{Table: ct.TableName, Index: strPtr(idx.Name), Column: strPtr(col.Name)},
+					Message:    fmt.Sprintf("Column %q in index %q on table %q is %d bytes long, exceeding InnoDB's limit of %d bytes per index column with ROW_FORMAT=%s", col.Name, idx.Name, ct.TableName, length, maxAntelopeKeyColumnLength, strings.ToUpper(*ct.TableOptions.RowFormat)),
+					Suggestion: strPtr("Use ROW_FORMAT=DYNAMIC, or index a prefix of the column"),
+					Context:    map[string]any{"bytes": length, "limit": maxAntelopeKeyColumnLength},
+				})
+			}
+			total += length
+		}
+		if limit := l.keyLengthLimit(); total > limit {
+			violations = append(violations, Violation{
+				Linter:     l,
+				Severity:   SeverityError,
+				Location:   &Location{Table: ct.TableName, Index: strPtr(idx.Name)},
+				Message:    fmt.Sprintf("Index %q on table %q has a maximum key length of %d bytes, exceeding InnoDB's limit of %d bytes", idx.Name, ct.TableName, total, limit),
+				Suggestion: strPtr("Index a prefix of long string columns (e.g. name(191)), or remove columns from the index"),
+				Context:    map[string]any{"bytes": total, "limit": limit},
+			})
+		}
+	}
+	return violations
+}
+
+func (l *SizeLimitsLinter) lintRowSize(ct *statement.CreateTable) (violations []Violation) {
+	antelope := isAntelopeRowFormat(ct)
+	rowSize, inPageSize := 0, 5+6+7 // record header, DB_TRX_ID and DB_ROLL_PTR
+	nullable := 0
+	for i := range ct.Columns {
+		col := &ct.Columns[i]
+		if col.Nullable {
+			nullable++
+		}
+		data, ok := columnDataLength(col, ct)
+		if !ok {
+			continue
+		}
+		if size, isBlob := blobPointerSize(col.Type); isBlob {
+			// Only a pointer to BLOB and TEXT data counts toward the
+			// row size; InnoDB stores the data off-page when needed.
+			rowSize += size
+			inPageSize += offPageSize(antelope) + 2
+			continue
+		}
+		lengthBytes := 0
+		if isVariableLength(col.Type) {
+			lengthBytes = 1
+			if data > 255 {
+				lengthBytes = 2
+			}
+		}
+		rowSize += data + lengthBytes
+		if isVariableLength(col.Type) && data > 255 {
+			// Long variable-length columns can be moved off-page.
+			inPageSize += min(data, offPageSize(antelope)) + lengthBytes
+		} else {
+			inPageSize += data + lengthBytes
+		}
+	}
+	rowSize += (nullable + 7) / 8
+	inPageSize += (nullable + 7) / 8
+
+	if rowSize > maxRowSize {
+		violations = append(violations, Violation{
+			Linter:     l,
+			Severity:   SeverityError,
+			Location:   &Location{Table: ct.TableName},
+			Message:    fmt.Sprintf("Table %q has a maximum row size of %d bytes, exceeding MySQL's limit of %d bytes (not counting BLOB and TEXT contents)", ct.TableName, rowSize, maxRowSize),
+			Suggestion: strPtr("Change large VARCHAR or VARBINARY columns to TEXT or BLOB, which only count toward the limit with a few bytes"),
+			Context:    map[string]any{"bytes": rowSize, "limit": maxRowSize},
+		})
+	} else if limit := l.rowSizeLimit(); inPageSize > limit {
+		violations = append(violations, Violation{
+			Linter:     l,
+			Severity:   SeverityWarning,
+			Location:   &Location{Table: ct.TableName},
+			Message:    fmt.Sprintf("Table %q has a maximum in-page row size of about %d bytes, exceeding InnoDB's limit of %d bytes for a %dKB page. Inserting or updating rows near the maximum size will fail with \"Row size too large\"", ct.TableName, inPageSize, limit, l.pageSize/1024),
+			Suggestion: strPtr("Change large columns to TEXT or BLOB and use ROW_FORMAT=DYNAMIC, so that they can be stored off-page"),
+			Context:    map[string]any{"bytes": inPageSize, "limit": limit},
+		})
+	}
+	return violations
+}
+
+// indexParts returns the columns of an index, with their prefix lengths.
+func indexParts(idx statement.Index) []statement.IndexColumn {
+	if len(idx.ColumnList) > 0 {
+		return idx.ColumnList
+	}
+	// Indexes synthesized from inline PRIMARY KEY / UNIQUE only have names.
+	parts := make([]statement.IndexColumn, 0, len(idx.Columns))
+	for _, name := range idx.Columns {
+		parts = append(parts, statement.IndexColumn{Name: name})
+	}
+	return parts
+}
+
+// keyPartLength returns the number of bytes a column (or a prefix of it)
+// contributes to an index key.
+func keyPartLength(col *statement.Column, prefix *int, ct *statement.CreateTable) (int, bool) {
+	if prefix != nil && isStringType(col.Type) {
+		return *prefix * columnCharsetBytes(col, ct), true
+	}
+	if _, isBlob := blobPointerSize(col.Type); isBlob {
+		return 0, false // MySQL rejects BLOB and TEXT keys without a prefix
+	}
+	return columnDataLength(col, ct)
+}
+
+// columnDataLength returns the maximum number of bytes of a column's data,
+// without length bytes. BLOB, TEXT and JSON columns return their maximum
+// inline length of 0; callers handle them with blobPointerSize.
+func columnDataLength(col *statement.Column, ct *statement.CreateTable) (int, bool) {
+	switch col.Type {
+	case "tinyint", "year":
+		return 1, true
+	case "smallint":
+		return 2, true
+	case "mediumint", "date":
+		return 3, true
+	case "int", "float":
+		return 4, true
+	case "bigint", "double":
+		return 8, true
+	case "decimal":
+		precision, scale := 10, 0
+		if col.Precision != nil {
+			precision = *col.Precision
+		}
+		if col.Scale != nil {
+			scale = *col.Scale
+		}
+		return decimalStorageSize(precision-scale) + decimalStorageSize(scale), true
+	case "time":
+		return 3 + fractionalSecondsSize(col), true
+	case "datetime":
+		return 5 + fractionalSecondsSize(col), true
+	case "timestamp":
+		return 4 + fractionalSecondsSize(col), true
+	case "bit":
+		bits := 1
+		if col.Length != nil {
+			bits = *col.Length
+		}
+		return (bits + 7) / 8, true
+	case "enum":
+		return enumStorageSize(len(col.EnumValues)), true
+	case "set":
+		return setStorageSize(len(col.SetValues)), true
+	case "char", "varchar", "binary", "varbinary":
+		chars := 1
+		if col.Length != nil {
+			chars = *col.Length
+		}
+		return chars * columnCharsetBytes(col, ct), true
+	}
+	if _, isBlob := blobPointerSize(col.Type); isBlob {
+		return 0, true
+	}
+	return 0, false
+}
+
+// blobPointerSize returns the number of bytes a BLOB-like column counts
+// toward MySQL's row size limit, and whether the type is BLOB-like.
+func blobPointerSize(typ string) (int, bool) {
+	switch typ {
+	case "tinyblob", "tinytext":
+		return 9, true
+	case "blob", "text":
+		return 10, true
+	case "mediumblob", "mediumtext":
+		return 11, true
+	case "longblob", "longtext", "json", "geometry", "point", "linestring", "polygon",
+		"multipoint", "multilinestring", "multipolygon", "geometrycollection":
+		return 12, true
+	}
+	return 0, false
+}
+
+// offPageSize returns the bytes a column stored off-page keeps in the row:
+// a pointer, plus a 768-byte prefix with ROW_FORMAT=REDUNDANT or COMPACT.
+func offPageSize(antelope bool) int {
+	if antelope {
+		return 768 + externalFieldRefSize
+	}
+	return externalFieldRefSize
+}
+
+func isVariableLength(typ string) bool {
+	return typ == "varchar" || typ == "varbinary"
+}
+
+func isStringType(typ string) bool {
+	switch typ {
+	case "char", "varchar", "binary", "varbinary":
+		return true
+	}
+	_, isBlob := blobPointerSize(typ)
+	return isBlob && typ != "json"
+}
+
+// columnCharsetBytes returns the maximum bytes per character of a column,
+// from its own character set or collation, or the table's.
+func columnCharsetBytes(col *statement.Column, ct *statement.CreateTable) int {
+	switch col.Type {
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		return 1
+	}
+	if col.Charset != nil {
+		return charsetMaxBytes(col.Charset)
+	}
+	if col.Collation != nil {
+		return charsetMaxBytes(collationCharset(*col.Collation))
+	}
+	if ct.TableOptions != nil {
+		if ct.TableOptions.Charset != nil {
+			return charsetMaxBytes(ct.TableOptions.Charset)
+		}
+		if ct.TableOptions.Collation != nil {
+			return charsetMaxBytes(collationCharset(*ct.TableOptions.Collation))
+		}
+	}
+	return charsetMaxBytes(nil)
+}
+
+// collationCharset returns the character set of a collation, which is the
+// part of its name before the first underscore (e.g. utf8mb4_0900_ai_ci).
+func collationCharset(collation string) *string {
+	charset, _, _ := strings.Cut(collation, "_")
+	return &charset
+}
+
+// isAntelopeRowFormat returns true for ROW_FORMAT=REDUNDANT and COMPACT,
+// which store a 768-byte prefix of long columns in the row.
+func isAntelopeRowFormat(ct *statement.CreateTable) bool {
+	if ct.TableOptions == nil || ct.TableOptions.RowFormat == nil {
+		return false
+	}
+	rowFormat := strings.ToUpper(*ct.TableOptions.RowFormat)
+	return rowFormat == "REDUNDANT" || rowFormat == "COMPACT"
+}
+
+// decimalStorageSize returns the bytes used for digits of a DECIMAL:
+// 4 bytes for each 9 digits, and 0-4 bytes for the remainder.
+func decimalStorageSize(digits int) int {
+	leftover := [...]int{0, 1, 1, 2, 2, 3, 3, 4, 4, 4}
+	return digits/9*4 + leftover[digits%9]
+}
+
+// fractionalSecondsSize returns the extra bytes used by the fractional
+// seconds precision of a TIME, DATETIME or TIMESTAMP column.
+func fractionalSecondsSize(col *statement.Column) int {
+	if col.Raw == nil || col.Raw.Tp == nil {
+		return 0
+	}
+	fsp := col.Raw.Tp.GetDecimal()
+	if fsp <= 0 {
+		return 0
+	}
+	return (fsp + 1) / 2
+}
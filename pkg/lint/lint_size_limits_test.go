@@ -0,0 +1,140 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/block/spirit/pkg/statement"
+	"github.com/stretchr/testify/require"
+)
+
+func lintSizeLimits(t *testing.T, linter *SizeLimitsLinter, existing []string, sql string) []Violation {
+	t.Helper()
+	var changes []*statement.AbstractStatement
+	if sql != "" {
+		var err error
+		changes, err = statement.New(sql)
+		require.NoError(t, err)
+	}
+	return linter.Lint(parseCreateTables(t, existing...), changes)
+}
+
+func TestSizeLimitsLinter_IndexLength(t *testing.T) {
+	tests := []struct {
+		name  string
+		sql   string
+		bytes int // 0 for no violation
+	}{
+		{"utf8mb4 varchar too long", "CREATE TABLE t1 (id bigint PRIMARY KEY, name varchar(1000), KEY idx_name (name)) CHARSET=utf8mb4", 4000},
+		{"utf8mb4 varchar at limit", "CREATE TABLE t1 (id bigint PRIMARY KEY, name varchar(768), KEY idx_name (name)) CHARSET=utf8mb4", 0},
+		{"default charset is utf8mb4", "CREATE TABLE t1 (id bigint PRIMARY KEY, name varchar(1000), KEY idx_name (name))", 4000},
+		{"prefix", "CREATE TABLE t1 (id bigint PRIMARY KEY, name varchar(1000), KEY idx_name (name(191))) CHARSET=utf8mb4", 0},
+		{"latin1", "CREATE TABLE t1 (id bigint PRIMARY KEY, name varchar(3000), KEY idx_name (name)) CHARSET=latin1", 0},
+		{"column charset", "CREATE TABLE t1 (id bigint PRIMARY KEY, name varchar(1000) CHARACTER SET utf8mb4, KEY idx_name (name)) CHARSET=latin1", 4000},
+		{"column collation", "CREATE TABLE t1 (id bigint PRIMARY KEY, name varchar(1100) COLLATE utf8mb3_general_ci, KEY idx_name (name))", 3300},
+		{"varbinary", "CREATE TABLE t1 (id bigint PRIMARY KEY, name varbinary(3072), KEY idx_name (name)) CHARSET=utf8mb4", 0},
+		{"composite", "CREATE TABLE t1 (id bigint PRIMARY KEY, a varchar(500), b varchar(300), c bigint, KEY idx_abc (a, b, c)) CHARSET=utf8mb4", 3208},
+		{"text prefix", "CREATE TABLE t1 (id bigint PRIMARY KEY, body text, KEY idx_body (body(800))) CHARSET=utf8mb4", 3200},
+		{"fulltext is ignored", "CREATE TABLE t1 (id bigint PRIMARY KEY, body varchar(2000), FULLTEXT KEY ft_body (body)) CHARSET=utf8mb4", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := lintSizeLimits(t, &SizeLimitsLinter{}, nil, tt.sql)
+			if tt.bytes == 0 {
+				require.Empty(t, violations)
+				return
+			}
+			require.Len(t, violations, 1)
+			require.Equal(t, SeverityError, violations[0].Severity)
+			require.Equal(t, "t1", violations[0].Location.Table)
+			require.NotNil(t, violations[0].Location.Index)
+			require.Equal(t, tt.bytes, violations[0].Context["bytes"])
+			require.Contains(t, violations[0].Message, "exceeding InnoDB's limit of 3072 bytes")
+		})
+	}
+}
+
+func TestSizeLimitsLinter_CompactRowFormat(t *testing.T) {
+	violations := lintSizeLimits(t, &SizeLimitsLinter{}, nil,
+		"CREATE TABLE t1 (id bigint PRIMARY KEY, name varchar(255), KEY idx_name (name)) CHARSET=utf8mb4 ROW_FORMAT=COMPACT")
+	require.Len(t, violations, 1)
+	require.Equal(t, SeverityError, violations[0].Severity)
+	require.Equal(t, "name", *violations[0].Location.Column)
+	require.Equal(t, "idx_name", *violations[0].Location.Index)
+	require.Contains(t, violations[0].Message, "767 bytes per index column with ROW_FORMAT=COMPACT")
+}
+
+func TestSizeLimitsLinter_RowSize(t *testing.T) {
+	violations := lintSizeLimits(t, &SizeLimitsLinter{}, nil,
+		"CREATE TABLE t1 (id bigint PRIMARY KEY, a varchar(10000), b varchar(10000)) CHARSET=utf8mb4")
+	require.Len(t, violations, 1)
+	require.Equal(t, SeverityError, violations[0].Severity)
+	require.Nil(t, violations[0].Location.Index)
+	// 8 + 2 * (40000 + 2) + 1 byte of NULL flags.
+	require.Equal(t, 80013, violations[0].Context["bytes"])
+	require.Contains(t, violations[0].Message, "exceeding MySQL's limit of 65535 bytes")
+
+	// TEXT columns only count with a few bytes.
+	violations = lintSizeLimits(t, &SizeLimitsLinter{}, nil,
+		"CREATE TABLE t1 (id bigint PRIMARY KEY, a text, b longtext, c json, d varchar(10000)) CHARSET=utf8mb4")
+	require.Empty(t, violations)
+}
+
+func TestSizeLimitsLinter_PageSize(t *testing.T) {
+	// 40 CHAR(100) utf8mb4 columns are 16000 bytes, which cannot be moved off-page.
+	var cols []string
+	for i := range 40 {
+		cols = append(cols, fmt.Sprintf("c%d char(100)", i))
+	}
+	sql := "CREATE TABLE t1 (id bigint PRIMARY KEY, " + strings.Join(cols, ", ") + ") CHARSET=utf8mb4"
+	violations := lintSizeLimits(t, &SizeLimitsLinter{}, nil, sql)
+	require.Len(t, violations, 1)
+	require.Equal(t, SeverityWarning, violations[0].Severity)
+	require.Equal(t, 8126, violations[0].Context["limit"])
+	require.Contains(t, violations[0].Message, "16KB page")
+
+	// Long VARCHAR columns can be stored off-page with ROW_FORMAT=DYNAMIC,
+	// but keep a 768-byte prefix in the row with COMPACT.
+	cols = cols[:0]
+	for i := range 20 {
+		cols = append(cols, fmt.Sprintf("c%d varchar(1000)", i))
+	}
+	sql = "CREATE TABLE t1 (id bigint PRIMARY KEY, " + strings.Join(cols, ", ") + ") CHARSET=latin1"
+	require.Empty(t, lintSizeLimits(t, &SizeLimitsLinter{}, nil, sql))
+	violations = lintSizeLimits(t, &SizeLimitsLinter{}, nil, sql+" ROW_FORMAT=COMPACT")
+	require.Len(t, violations, 1)
+	require.Equal(t, SeverityWarning, violations[0].Severity)
+}
+
+func TestSizeLimitsLinter_Alter(t *testing.T) {
+	existing := "CREATE TABLE users (id bigint unsigned NOT NULL, email varchar(1000), PRIMARY KEY (id)) CHARSET=utf8mb4"
+
+	violations := lintSizeLimits(t, &SizeLimitsLinter{}, []string{existing}, "ALTER TABLE users ADD INDEX idx_email (email)")
+	require.Len(t, violations, 1)
+	require.Equal(t, "idx_email", *violations[0].Location.Index)
+
+	violations = lintSizeLimits(t, &SizeLimitsLinter{}, []string{existing}, "ALTER TABLE users ADD INDEX idx_email (email(255))")
+	require.Empty(t, violations)
+
+	// Columns added by the ALTER are included.
+	violations = lintSizeLimits(t, &SizeLimitsLinter{}, []string{existing}, "ALTER TABLE users ADD COLUMN name varchar(900), ADD INDEX idx_name (name)")
+	require.Len(t, violations, 1)
+	require.Equal(t, "idx_name", *violations[0].Location.Index)
+	require.Equal(t, 3600, violations[0].Context["bytes"])
+}
+
+func TestSizeLimitsLinter_Configure(t *testing.T) {
+	linter := &SizeLimitsLinter{}
+	require.Equal(t, map[string]string{"pageSize": "16384"}, linter.DefaultConfig())
+	require.NoError(t, linter.Configure(linter.DefaultConfig()))
+
+	require.NoError(t, linter.Configure(map[string]string{"pageSize": "8192"}))
+	violations := lintSizeLimits(t, linter, nil,
+		"CREATE TABLE t1 (id bigint PRIMARY KEY, name varchar(500), KEY idx_name (name)) CHARSET=utf8mb4")
+	require.Len(t, violations, 1)
+	require.Contains(t, violations[0].Message, "limit of 1536 bytes")
+
+	require.ErrorContains(t, linter.Configure(map[string]string{"pageSize": "1000"}), "invalid value for pageSize")
+	require.ErrorContains(t, linter.Configure(map[string]string{"unknown": "x"}), "unknown config key")
+}
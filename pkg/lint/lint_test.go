@@ -1015,3 +1015,33 @@ func TestRunLinters_LintOnlyChanges_And_IgnoreTables_NoResults(t *testing.T) {
 
 	require.Empty(t, violations)
 }
+
+func TestRun_Suppressed(t *testing.T) {
+	source := parseCreateTables(t,
+		`CREATE TABLE users (
+			id bigint unsigned NOT NULL,
+			balance float, -- spirit-lint:ignore has_float
+			score double,
+			PRIMARY KEY (id)
+		)`,
+		`CREATE TABLE orders (
+			id bigint unsigned NOT NULL,
+			total float,
+			PRIMARY KEY (id)
+		) COMMENT='spirit-lint:ignore has_float'`,
+	)
+	config := Config{Enabled: map[string]bool{}}
+	for _, name := range List() {
+		config.Enabled[name] = name == "has_float"
+	}
+	result, err := Run(source, nil, config)
+	require.NoError(t, err)
+	require.Len(t, result.Violations, 1)
+	require.Equal(t, "score", *result.Violations[0].Location.Column)
+	require.Len(t, result.Suppressed, 2)
+
+	// RunLinters drops the suppressed violations.
+	violations, err := RunLinters(source, nil, config)
+	require.NoError(t, err)
+	require.Len(t, violations, 1)
+}
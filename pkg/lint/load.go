@@ -42,6 +42,8 @@ func LoadSchemaFromDSN(ctx context.Context, dsn string) ([]*statement.CreateTabl
 
 // LoadSchemaFromDir reads all .sql files from a directory and parses them as
 // CREATE TABLE statements. Each file should contain exactly one CREATE TABLE statement.
+// The file name is recorded in the source positions of each table so that
+// violations can be mapped back to a file and line.
 func LoadSchemaFromDir(dir string) ([]*statement.CreateTable, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
@@ -67,6 +69,7 @@ func LoadSchemaFromDir(dir string) ([]*statement.CreateTable, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
 		}
+		ct.Positions.File = path
 		tables = append(tables, ct)
 	}
 
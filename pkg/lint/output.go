@@ -0,0 +1,302 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/block/spirit/pkg/buildinfo"
+	"github.com/block/spirit/pkg/statement"
+)
+
+// Output formats supported by the lint and diff commands.
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatSARIF  = "sarif"
+	FormatGitHub = "github"
+)
+
+// resolvePositions sets the File and Line of each violation's Location from
+// the source positions of the table it refers to. Violations on tables that
+// are not in tables are left unchanged.
+func resolvePositions(violations []Violation, tables []*statement.CreateTable) {
+	positions := make(map[string]*statement.SourcePositions)
+	for _, ct := range tables {
+		if ct.Positions != nil {
+			positions[strings.ToLower(ct.TableName)] = ct.Positions
+		}
+	}
+	for _, v := range violations {
+		if v.Location == nil {
+			continue
+		}
+		p, ok := positions[strings.ToLower(v.Location.Table)]
+		if !ok {
+			continue
+		}
+		v.Location.File = p.File
+		v.Location.Line = p.Line(v.Location.Column, v.Location.Index, v.Location.Constraint)
+	}
+}
+
+// writeReport writes violations in one of the machine-readable formats.
+// Ignored are the violations silenced by spirit-lint:ignore directives.
+// Statements are the DDL statements generated by diff; they are only
+// included in the JSON format.
+func writeReport(w io.Writer, format string, violations, ignored []Violation, statements []string) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, violations, ignored, statements)
+	case FormatSARIF:
+		return writeSARIF(w, violations, ignored)
+	case FormatGitHub:
+		return writeGitHub(w, violations)
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+type jsonViolation struct {
+	Linter     string         `json:"linter"`
+	Severity   string         `json:"severity"`
+	Message    string         `json:"message"`
+	Location   *Location      `json:"location,omitempty"`
+	Suggestion *string        `json:"suggestion,omitempty"`
+	Context    map[string]any `json:"context,omitempty"`
+}
+
+type jsonReport struct {
+	Violations []jsonViolation `json:"violations"`
+	Ignored    []jsonViolation `json:"ignored"`
+	Statements []string        `json:"statements,omitempty"`
+}
+
+func toJSONViolations(violations []Violation) []jsonViolation {
+	out := make([]jsonViolation, 0, len(violations))
+	for _, v := range sortViolations(violations) {
+		out = append(out, jsonViolation{
+			Linter:     v.Linter.Name(),
+			Severity:   strings.ToLower(v.Severity.String()),
+			Message:    v.Message,
+			Location:   v.Location,
+			Suggestion: v.Suggestion,
+			Context:    v.Context,
+		})
+	}
+	return out
+}
+
+func writeJSON(w io.Writer, violations, ignored []Violation, statements []string) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jsonReport{
+		Violations: toJSONViolations(violations),
+		Ignored:    toJSONViolations(ignored),
+		Statements: statements,
+	})
+}
+
+// SARIF 2.1.0, as consumed by code scanning tools such as GitHub's.
+// Only the subset of the format that Spirit produces is modeled.
+// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Version        string      `json:"version,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID       string             `json:"ruleId"`
+	Level        string             `json:"level"`
+	Message      sarifMessage       `json:"message"`
+	Locations    []sarifLocation    `json:"locations,omitempty"`
+	Suppressions []sarifSuppression `json:"suppressions,omitempty"`
+	Properties   map[string]any     `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind,omitempty"`
+}
+
+type sarifSuppression struct {
+	Kind string `json:"kind"`
+}
+
+// sarifLevel maps a Severity to a SARIF result level.
+func sarifLevel(s Severity) string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func toSARIFResult(v Violation) sarifResult {
+	result := sarifResult{
+		RuleID:     v.Linter.Name(),
+		Level:      sarifLevel(v.Severity),
+		Message:    sarifMessage{Text: v.Message},
+		Properties: v.Context,
+	}
+	if v.Suggestion != nil {
+		result.Message.Text += " Suggestion: " + *v.Suggestion
+	}
+	if v.Location != nil {
+		var loc sarifLocation
+		if v.Location.File != "" {
+			loc.PhysicalLocation = &sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(v.Location.File)},
+			}
+			if v.Location.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{StartLine: v.Location.Line}
+			}
+		}
+		name, kind := v.Location.Table, "table"
+		for _, part := range []struct {
+			name *string
+			kind string
+		}{{v.Location.Column, "column"}, {v.Location.Index, "index"}, {v.Location.Constraint, "constraint"}} {
+			if part.name != nil {
+				name, kind = v.Location.Table+"."+*part.name, part.kind
+				break
+			}
+		}
+		loc.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: name, Kind: kind}}
+		result.Locations = []sarifLocation{loc}
+	}
+	return result
+}
+
+func writeSARIF(w io.Writer, violations, ignored []Violation) error {
+	rules := make(map[string]sarifRule)
+	results := make([]sarifResult, 0, len(violations)+len(ignored))
+	for _, v := range sortViolations(violations) {
+		rules[v.Linter.Name()] = sarifRule{ID: v.Linter.Name(), ShortDescription: sarifMessage{Text: v.Linter.Description()}}
+		results = append(results, toSARIFResult(v))
+	}
+	// Suppressed violations are included, marked as suppressed in source,
+	// so that code scanning tools can show and audit them.
+	for _, v := range sortViolations(ignored) {
+		rules[v.Linter.Name()] = sarifRule{ID: v.Linter.Name(), ShortDescription: sarifMessage{Text: v.Linter.Description()}}
+		result := toSARIFResult(v)
+		result.Suppressions = []sarifSuppression{{Kind: "inSource"}}
+		results = append(results, result)
+	}
+	driver := sarifDriver{
+		Name:           "spirit",
+		InformationURI: "https://github.com/block/spirit",
+		Rules:          make([]sarifRule, 0, len(rules)),
+	}
+	if info := buildinfo.Get(); info.Version != "dev" {
+		driver.Version = info.Version
+	}
+	for _, rule := range rules {
+		driver.Rules = append(driver.Rules, rule)
+	}
+	sort.Slice(driver.Rules, func(i, j int) bool {
+		return driver.Rules[i].ID < driver.Rules[j].ID
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
+
+// writeGitHub writes violations as GitHub Actions workflow commands, which
+// are shown as annotations on the pull request. Violations without a file
+// are still reported, but are only shown in the job summary.
+// See https://docs.github.com/en/actions/reference/workflow-commands-for-github-actions
+func writeGitHub(w io.Writer, violations []Violation) error {
+	for _, v := range sortViolations(violations) {
+		command := "notice"
+		switch v.Severity {
+		case SeverityError:
+			command = "error"
+		case SeverityWarning:
+			command = "warning"
+		}
+		var props []string
+		message := v.Message
+		if v.Location != nil && v.Location.File != "" {
+			props = append(props, "file="+escapeGitHubProperty(filepath.ToSlash(v.Location.File)))
+			if v.Location.Line > 0 {
+				props = append(props, fmt.Sprintf("line=%d", v.Location.Line))
+			}
+		} else if v.Location != nil {
+			message += " (" + v.Location.String() + ")"
+		}
+		props = append(props, "title="+escapeGitHubProperty("spirit lint: "+v.Linter.Name()))
+		if v.Suggestion != nil {
+			message += " Suggestion: " + *v.Suggestion
+		}
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", command, strings.Join(props, ","), escapeGitHubData(message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,132 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// lintDirForOutput lints a directory with a float column (a warning on
+// line 3) and a suppressed float column, for the output format tests.
+func lintDirForOutput(t *testing.T) (string, Result) {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, dir, "users.sql", `CREATE TABLE users (
+  id bigint unsigned NOT NULL AUTO_INCREMENT,
+  balance float DEFAULT NULL,
+  score float DEFAULT NULL, -- spirit-lint:ignore has_float
+  PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`)
+	source, err := LoadSchemaFromDir(dir)
+	require.NoError(t, err)
+
+	config := Config{Enabled: map[string]bool{}}
+	for _, name := range List() {
+		config.Enabled[name] = name == "has_float"
+	}
+	result, err := Run(source, nil, config)
+	require.NoError(t, err)
+	resolvePositions(append(result.Violations, result.Suppressed...), source)
+	return filepath.Join(dir, "users.sql"), result
+}
+
+func TestResolvePositions(t *testing.T) {
+	file, result := lintDirForOutput(t)
+	require.Len(t, result.Violations, 1)
+	require.Equal(t, file, result.Violations[0].Location.File)
+	require.Equal(t, 3, result.Violations[0].Location.Line)
+	require.Len(t, result.Suppressed, 1)
+	require.Equal(t, 4, result.Suppressed[0].Location.Line)
+}
+
+func TestWriteReport_JSON(t *testing.T) {
+	file, result := lintDirForOutput(t)
+	var buf bytes.Buffer
+	require.NoError(t, writeReport(&buf, FormatJSON, result.Violations, result.Suppressed, []string{"ALTER TABLE users ADD COLUMN x int;"}))
+
+	var report struct {
+		Violations []struct {
+			Linter   string `json:"linter"`
+			Severity string `json:"severity"`
+			Message  string `json:"message"`
+			Location struct {
+				Table  string `json:"table"`
+				Column string `json:"column"`
+				File   string `json:"file"`
+				Line   int    `json:"line"`
+			} `json:"location"`
+		} `json:"violations"`
+		Ignored    []json.RawMessage `json:"ignored"`
+		Statements []string          `json:"statements"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	require.Len(t, report.Violations, 1)
+	v := report.Violations[0]
+	require.Equal(t, "has_float", v.Linter)
+	require.Equal(t, "warning", v.Severity)
+	require.Equal(t, "users", v.Location.Table)
+	require.Equal(t, "balance", v.Location.Column)
+	require.Equal(t, file, v.Location.File)
+	require.Equal(t, 3, v.Location.Line)
+	require.Len(t, report.Ignored, 1)
+	require.Equal(t, []string{"ALTER TABLE users ADD COLUMN x int;"}, report.Statements)
+
+	// Empty lists are arrays, not null.
+	buf.Reset()
+	require.NoError(t, writeReport(&buf, FormatJSON, nil, nil, nil))
+	require.JSONEq(t, `{"violations": [], "ignored": []}`, buf.String())
+}
+
+func TestWriteReport_SARIF(t *testing.T) {
+	file, result := lintDirForOutput(t)
+	var buf bytes.Buffer
+	require.NoError(t, writeReport(&buf, FormatSARIF, result.Violations, result.Suppressed, nil))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	require.Equal(t, "spirit", run.Tool.Driver.Name)
+	require.Len(t, run.Tool.Driver.Rules, 1)
+	require.Equal(t, "has_float", run.Tool.Driver.Rules[0].ID)
+	require.Len(t, run.Results, 2)
+
+	r := run.Results[0]
+	require.Equal(t, "has_float", r.RuleID)
+	require.Equal(t, "warning", r.Level)
+	require.Empty(t, r.Suppressions)
+	require.Equal(t, filepath.ToSlash(file), r.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	require.Equal(t, 3, r.Locations[0].PhysicalLocation.Region.StartLine)
+	require.Equal(t, "users.balance", r.Locations[0].LogicalLocations[0].FullyQualifiedName)
+	require.Equal(t, "column", r.Locations[0].LogicalLocations[0].Kind)
+
+	// The suppressed violation is reported as suppressed in source.
+	require.Equal(t, []sarifSuppression{{Kind: "inSource"}}, run.Results[1].Suppressions)
+}
+
+func TestWriteReport_GitHub(t *testing.T) {
+	file, result := lintDirForOutput(t)
+	var buf bytes.Buffer
+	require.NoError(t, writeReport(&buf, FormatGitHub, result.Violations, result.Suppressed, nil))
+	require.Equal(t, "::warning file="+escapeGitHubProperty(filepath.ToSlash(file))+",line=3,title=spirit lint%3A has_float::"+
+		escapeGitHubData(result.Violations[0].Message)+"\n", buf.String())
+
+	// Without a file, the location is added to the message.
+	column := "balance"
+	buf.Reset()
+	require.NoError(t, writeReport(&buf, FormatGitHub, []Violation{{
+		Linter:   &HasFloatLinter{},
+		Severity: SeverityError,
+		Message:  "100% bad,\nreally",
+		Location: &Location{Table: "users", Column: &column},
+	}}, nil, nil))
+	require.Equal(t, "::error title=spirit lint%3A has_float::100%25 bad,%0Areally (Table: users, Column: balance)\n", buf.String())
+}
+
+func TestWriteReport_UnknownFormat(t *testing.T) {
+	require.Error(t, writeReport(&bytes.Buffer{}, "xml", nil, nil, nil))
+}
@@ -0,0 +1,211 @@
+package lint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/block/spirit/pkg/statement"
+)
+
+// pluginProtocolVersion is the version of the JSON protocol spoken with
+// external linters. It is sent in every request.
+const pluginProtocolVersion = 1
+
+// defaultPluginTimeout bounds how long an external linter may run.
+const defaultPluginTimeout = 30 * time.Second
+
+// ExternalLinter is a linter implemented by an external program, so that
+// organization-specific rules can be added without recompiling Spirit.
+//
+// For each run, the command is started and the program receives a
+// PluginRequest as JSON on stdin. It must write a PluginResponse as JSON to
+// stdout and exit 0. Anything written to stderr is included in the error
+// if it fails. Failures (including timeouts and invalid output) are reported
+// as an ERROR violation from this linter, so a broken plugin cannot
+// silently pass.
+type ExternalLinter struct {
+	name        string
+	description string
+	command     []string
+	timeout     time.Duration
+	settings    map[string]string
+}
+
+var _ ConfigurableLinter = &ExternalLinter{}
+
+// NewExternalLinter creates a linter that runs command (the program and its
+// arguments). A zero timeout uses the default of 30s.
+func NewExternalLinter(name, description string, command []string, timeout time.Duration) *ExternalLinter {
+	if timeout <= 0 {
+		timeout = defaultPluginTimeout
+	}
+	return &ExternalLinter{
+		name:        name,
+		description: description,
+		command:     command,
+		timeout:     timeout,
+	}
+}
+
+// PluginRequest is sent to an external linter on stdin.
+type PluginRequest struct {
+	Version        int                      `json:"version"`
+	Linter         string                   `json:"linter"`
+	Settings       map[string]string        `json:"settings"`
+	ExistingTables []*statement.CreateTable `json:"existing_tables"`
+	Changes        []PluginChange           `json:"changes"`
+}
+
+// PluginChange is a statement being linted, as sent to an external linter.
+type PluginChange struct {
+	Schema    string `json:"schema,omitempty"`
+	Table     string `json:"table"`
+	Statement string `json:"statement"`
+	// Alter is the ALTER clause without the ALTER TABLE prefix, for ALTER statements.
+	Alter string `json:"alter,omitempty"`
+	// CreateTable is the parsed table, for CREATE TABLE statements.
+	CreateTable *statement.CreateTable `json:"create_table,omitempty"`
+}
+
+// PluginResponse is read from an external linter's stdout.
+type PluginResponse struct {
+	Violations []PluginViolation `json:"violations"`
+}
+
+// PluginViolation is a violation reported by an external linter.
+// Severity is one of info, warning or error (default warning).
+type PluginViolation struct {
+	Severity   string         `json:"severity"`
+	Message    string         `json:"message"`
+	Location   *Location      `json:"location,omitempty"`
+	Suggestion *string        `json:"suggestion,omitempty"`
+	Context    map[string]any `json:"context,omitempty"`
+}
+
+func (l *ExternalLinter) String() string {
+	return Stringer(l)
+}
+
+func (l *ExternalLinter) Name() string {
+	return l.name
+}
+
+func (l *ExternalLinter) Description() string {
+	return l.description
+}
+
+// Configure stores the settings to be passed to the program. They are
+// validated by the program itself, so any keys are accepted.
+func (l *ExternalLinter) Configure(config map[string]string) error {
+	l.settings = maps.Clone(config)
+	return nil
+}
+
+func (l *ExternalLinter) DefaultConfig() map[string]string {
+	return map[string]string{}
+}
+
+func (l *ExternalLinter) Lint(existingTables []*statement.CreateTable, changes []*statement.AbstractStatement) []Violation {
+	response, err := l.run(existingTables, changes)
+	if err != nil {
+		return []Violation{l.failure(changes, "failed: "+err.Error())}
+	}
+	violations := make([]Violation, 0, len(response.Violations))
+	for _, pv := range response.Violations {
+		severity := SeverityWarning
+		if pv.Severity != "" {
+			if severity, err = ParseSeverity(pv.Severity); err != nil {
+				return []Violation{l.failure(changes, "returned an invalid violation: "+err.Error())}
+			}
+		}
+		violations = append(violations, Violation{
+			Linter:     l,
+			Severity:   severity,
+			Message:    pv.Message,
+			Location:   pv.Location,
+			Suggestion: pv.Suggestion,
+			Context:    pv.Context,
+		})
+	}
+	return violations
+}
+
+// failure returns the ERROR violation reported when the program fails.
+// When linting changes, it is located on the first changed table so that
+// it is not discarded by Config.LintOnlyChanges.
+func (l *ExternalLinter) failure(changes []*statement.AbstractStatement, reason string) Violation {
+	v := Violation{
+		Linter:   l,
+		Severity: SeverityError,
+		Message:  fmt.Sprintf("external linter %s %s", l.name, reason),
+	}
+	if len(changes) > 0 {
+		v.Location = &Location{Table: changes[0].Table}
+	}
+	return v
+}
+
+// run executes the program with a request built from the arguments.
+func (l *ExternalLinter) run(existingTables []*statement.CreateTable, changes []*statement.AbstractStatement) (*PluginResponse, error) {
+	if len(l.command) == 0 {
+		return nil, errors.New("no command configured")
+	}
+	request := PluginRequest{
+		Version:        pluginProtocolVersion,
+		Linter:         l.name,
+		Settings:       l.settings,
+		ExistingTables: existingTables,
+		Changes:        make([]PluginChange, 0, len(changes)),
+	}
+	if request.Settings == nil {
+		request.Settings = map[string]string{}
+	}
+	if request.ExistingTables == nil {
+		request.ExistingTables = []*statement.CreateTable{}
+	}
+	for _, change := range changes {
+		pc := PluginChange{
+			Schema:    change.Schema,
+			Table:     change.Table,
+			Statement: change.Statement,
+			Alter:     change.Alter,
+		}
+		if change.IsCreateTable() {
+			pc.CreateTable, _ = change.ParseCreateTable()
+		}
+		request.Changes = append(request.Changes, pc)
+	}
+	input, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, l.command[0], l.command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("timed out after %s", l.timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	var response PluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return &response, nil
+}
@@ -0,0 +1,179 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/block/spirit/pkg/statement"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExternalLinterHelperProcess is not a real test: it is the external
+// linter program run by the tests below, which re-execute the test binary.
+func TestExternalLinterHelperProcess(t *testing.T) {
+	if os.Getenv("SPIRIT_LINT_PLUGIN_HELPER") != "1" {
+		t.Skip("helper process for external linter tests")
+	}
+	mode := os.Args[len(os.Args)-1]
+	switch mode {
+	case "fail":
+		fmt.Fprintln(os.Stderr, "something broke")
+		os.Exit(3)
+	case "garbage":
+		fmt.Println("not json")
+		os.Exit(0)
+	case "sleep":
+		time.Sleep(10 * time.Second)
+		os.Exit(0)
+	}
+	var req PluginRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		os.Exit(4)
+	}
+	var resp PluginResponse
+	for _, ct := range req.ExistingTables {
+		for _, col := range ct.Columns {
+			if col.Comment == nil {
+				column := col.Name
+				resp.Violations = append(resp.Violations, PluginViolation{
+					Severity: req.Settings["severity"],
+					Message:  fmt.Sprintf("column %s has no PII tag (protocol v%d, %d changes)", col.Name, req.Version, len(req.Changes)),
+					Location: &Location{Table: ct.TableName, Column: &column},
+				})
+			}
+		}
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(resp); err != nil {
+		os.Exit(5)
+	}
+	os.Exit(0)
+}
+
+func helperLinter(t *testing.T, mode string, timeout time.Duration) *ExternalLinter {
+	t.Helper()
+	t.Setenv("SPIRIT_LINT_PLUGIN_HELPER", "1")
+	return NewExternalLinter("pii_tagging", "Requires PII tags", []string{os.Args[0], "-test.run=^TestExternalLinterHelperProcess$", "--", mode}, timeout)
+}
+
+func TestExternalLinter(t *testing.T) {
+	ct, err := statement.ParseCreateTable(`CREATE TABLE users (
+		id bigint unsigned NOT NULL COMMENT 'pii:none',
+		email varchar(255) NOT NULL,
+		PRIMARY KEY (id)
+	)`)
+	require.NoError(t, err)
+	changes, err := statement.New("ALTER TABLE users ADD COLUMN name varchar(255)")
+	require.NoError(t, err)
+
+	l := helperLinter(t, "ok", 0)
+	require.NoError(t, l.Configure(map[string]string{"severity": "error"}))
+	violations := l.Lint([]*statement.CreateTable{ct}, changes)
+	require.Len(t, violations, 1)
+	require.Equal(t, "pii_tagging", violations[0].Linter.Name())
+	require.Equal(t, SeverityError, violations[0].Severity)
+	require.Equal(t, "column email has no PII tag (protocol v1, 1 changes)", violations[0].Message)
+	require.Equal(t, "users", violations[0].Location.Table)
+	require.Equal(t, "email", *violations[0].Location.Column)
+
+	// Severity defaults to warning.
+	require.NoError(t, l.Configure(map[string]string{}))
+	violations = l.Lint([]*statement.CreateTable{ct}, nil)
+	require.Len(t, violations, 1)
+	require.Equal(t, SeverityWarning, violations[0].Severity)
+
+	// An invalid severity is a failure.
+	require.NoError(t, l.Configure(map[string]string{"severity": "fatal"}))
+	violations = l.Lint([]*statement.CreateTable{ct}, nil)
+	require.Len(t, violations, 1)
+	require.Equal(t, SeverityError, violations[0].Severity)
+	require.Contains(t, violations[0].Message, "invalid violation")
+}
+
+func TestExternalLinter_Failures(t *testing.T) {
+	changes, err := statement.New("ALTER TABLE users ADD COLUMN name varchar(255)")
+	require.NoError(t, err)
+
+	violations := helperLinter(t, "fail", 0).Lint(nil, changes)
+	require.Len(t, violations, 1)
+	require.Equal(t, SeverityError, violations[0].Severity)
+	require.Contains(t, violations[0].Message, "something broke")
+	// Located on the changed table so that LintOnlyChanges keeps it.
+	require.Equal(t, "users", violations[0].Location.Table)
+
+	violations = helperLinter(t, "garbage", 0).Lint(nil, nil)
+	require.Len(t, violations, 1)
+	require.Contains(t, violations[0].Message, "invalid response")
+
+	violations = helperLinter(t, "sleep", 100*time.Millisecond).Lint(nil, nil)
+	require.Len(t, violations, 1)
+	require.Contains(t, violations[0].Message, "timed out")
+
+	violations = NewExternalLinter("empty", "", nil, 0).Lint(nil, nil)
+	require.Len(t, violations, 1)
+	require.Contains(t, violations[0].Message, "no command configured")
+}
+
+func TestFileConfigApply_Plugins(t *testing.T) {
+	fileConfig, err := ParseConfig([]byte(`
+plugins:
+  - name: test_plugin
+    description: A test plugin
+    command: ["/bin/true"]
+    timeout: 5s
+linters:
+  test_plugin:
+    settings:
+      anything: goes
+`))
+	require.NoError(t, err)
+	require.Equal(t, 5*time.Second, fileConfig.Plugins[0].Timeout)
+	t.Cleanup(func() {
+		lock.Lock()
+		defer lock.Unlock()
+		delete(linters, "test_plugin")
+	})
+
+	config := Config{}
+	require.NoError(t, fileConfig.Apply(&config, nil))
+	l, err := Get("test_plugin")
+	require.NoError(t, err)
+	require.Equal(t, "A test plugin", l.Description())
+	require.Equal(t, "goes", config.Settings["test_plugin"]["anything"])
+
+	fileConfig, err = ParseConfig([]byte("plugins:\n  - name: has_float\n    command: [\"/bin/true\"]\n"))
+	require.NoError(t, err)
+	require.ErrorContains(t, fileConfig.Apply(&Config{}, nil), "built-in linter")
+
+	fileConfig, err = ParseConfig([]byte("plugins:\n  - name: no_command\n"))
+	require.NoError(t, err)
+	require.ErrorContains(t, fileConfig.Apply(&Config{}, nil), "name and a command")
+}
+
+func TestLoadConfigFile_Plugins(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte("plugins:\n  - name: file_plugin\n    command: [\"./bin/linter\", \"--strict\"]\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".spirit-lint.yaml"), content, 0o644))
+	t.Cleanup(func() {
+		lock.Lock()
+		defer lock.Unlock()
+		delete(linters, "file_plugin")
+	})
+
+	// A relative command is resolved against the directory of the file.
+	fileConfig, err := LoadConfigFile(filepath.Join(dir, ".spirit-lint.yaml"))
+	require.NoError(t, err)
+	require.NoError(t, fileConfig.Apply(&Config{}, nil))
+	l, err := Get("file_plugin")
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "bin/linter"), "--strict"}, l.(*ExternalLinter).command)
+
+	// Plugins are refused in a file discovered in the working directory.
+	t.Chdir(dir)
+	fileConfig, err = LoadConfigFile("")
+	require.NoError(t, err)
+	require.ErrorContains(t, fileConfig.Apply(&Config{}, nil), "--lint-config")
+}
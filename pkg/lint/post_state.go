@@ -1,11 +1,13 @@
 package lint
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 
 	"github.com/block/spirit/pkg/statement"
 	"github.com/pingcap/tidb/pkg/parser/ast"
+	"github.com/pingcap/tidb/pkg/parser/format"
 )
 
 // PostState returns a deterministic post-state view of the schema: the existing
@@ -329,7 +331,21 @@ func applyTableOptions(existing *statement.TableOptions, opts []*ast.TableOption
 func nonIndexConstraint(c *ast.Constraint) (statement.Constraint, bool) {
 	switch c.Tp { //nolint:exhaustive
 	case ast.ConstraintForeignKey:
-		return statement.Constraint{Raw: c, Name: c.Name, Type: "FOREIGN KEY"}, true
+		fk := statement.Constraint{Raw: c, Name: c.Name, Type: "FOREIGN KEY"}
+		for _, k := range c.Keys {
+			if k.Column != nil {
+				fk.Columns = append(fk.Columns, k.Column.Name.O)
+			}
+		}
+		if c.Refer != nil && c.Refer.Table != nil {
+			fk.References = &statement.ForeignKeyReference{Table: c.Refer.Table.Name.O}
+			for _, k := range c.Refer.IndexPartSpecifications {
+				if k.Column != nil {
+					fk.References.Columns = append(fk.References.Columns, k.Column.Name.O)
+				}
+			}
+		}
+		return fk, true
 	case ast.ConstraintCheck:
 		return statement.Constraint{Raw: c, Name: c.Name, Type: "CHECK"}, true
 	}
@@ -366,6 +382,40 @@ func columnFromAst(colDef *ast.ColumnDef) statement.Column {
 	return col
 }
 
+// parseColumnDef parses an ALTER column definition into a fully populated
+// Column, as if it had been part of a CREATE TABLE.
+func parseColumnDef(colDef *ast.ColumnDef) (*statement.Column, error) {
+	var sb strings.Builder
+	if err := colDef.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
+		return nil, err
+	}
+	ct, err := statement.ParseCreateTable("CREATE TABLE t (" + sb.String() + ")")
+	if err != nil {
+		return nil, err
+	}
+	if len(ct.Columns) != 1 {
+		return nil, fmt.Errorf("unexpected column definition %q", sb.String())
+	}
+	return &ct.Columns[0], nil
+}
+
+// completeColumns replaces the minimal columns that applyAlter records for
+// added or modified columns with fully parsed ones, for linters that need
+// their types, lengths and character sets. t must be a table returned by
+// applyAlter or PostState, whose Columns are not shared with the input.
+func completeColumns(t *statement.CreateTable) {
+	for i, col := range t.Columns {
+		if col.Type != "" || col.Raw == nil {
+			continue
+		}
+		if parsed, err := parseColumnDef(col.Raw); err == nil {
+			parsed.PrimaryKey = parsed.PrimaryKey || col.PrimaryKey
+			parsed.Unique = parsed.Unique || col.Unique
+			t.Columns[i] = *parsed
+		}
+	}
+}
+
 func removeColumn(cols statement.Columns, name string) statement.Columns {
 	out := cols[:0]
 	for _, c := range cols {
@@ -437,15 +487,29 @@ func indexFromConstraint(c *ast.Constraint) (statement.Index, bool) {
 		return statement.Index{}, false
 	}
 	cols := make([]string, 0, len(c.Keys))
+	columnList := make([]statement.IndexColumn, 0, len(c.Keys))
 	for _, k := range c.Keys {
 		if k.Column != nil {
 			cols = append(cols, k.Column.Name.O)
+			part := statement.IndexColumn{Name: k.Column.Name.O}
+			if k.Length > 0 {
+				length := k.Length
+				part.Length = &length
+			}
+			columnList = append(columnList, part)
+		} else if k.Expr != nil {
+			var sb strings.Builder
+			if err := k.Expr.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err == nil {
+				columnList = append(columnList, statement.IndexColumn{Expression: strPtr(sb.String())})
+			}
 		}
 	}
 	return statement.Index{
-		Name:    c.Name,
-		Type:    typeStr,
-		Columns: cols,
+		Raw:        c,
+		Name:       c.Name,
+		Type:       typeStr,
+		Columns:    cols,
+		ColumnList: columnList,
 	}, true
 }
 
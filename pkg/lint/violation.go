@@ -3,6 +3,9 @@ package lint
 import (
 	"fmt"
 	"sort"
+	"strings"
+
+	"github.com/block/spirit/pkg/statement"
 )
 
 // Severity represents the severity level of a linting violation
@@ -34,6 +37,21 @@ func (s Severity) String() string {
 	}
 }
 
+// ParseSeverity parses a severity name (info, warning or error) as used in
+// lint configuration files. It is case-insensitive.
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(s) {
+	case "info":
+		return SeverityInfo, nil
+	case "warning":
+		return SeverityWarning, nil
+	case "error":
+		return SeverityError, nil
+	default:
+		return SeverityInfo, fmt.Errorf("invalid severity %q (expected 'info', 'warning' or 'error')", s)
+	}
+}
+
 // Violation represents a linting violation found during analysis
 type Violation struct {
 	// Linter is the linter that produced this violation
@@ -71,16 +89,21 @@ func (v Violation) String() string {
 // Location provides information about where a violation occurred
 type Location struct {
 	// Table is the name of the table where the violation occurred
-	Table string
+	Table string `json:"table"`
 
 	// Column is the name of the column (if applicable)
-	Column *string
+	Column *string `json:"column,omitempty"`
 
 	// Index is the name of the index (if applicable)
-	Index *string
+	Index *string `json:"index,omitempty"`
 
 	// Constraint is the name of the constraint (if applicable)
-	Constraint *string
+	Constraint *string `json:"constraint,omitempty"`
+
+	// File and Line locate the violation in the schema files, when the
+	// schema was loaded from files (see resolvePositions)
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
 }
 
 func (l *Location) String() string {
@@ -100,6 +123,17 @@ func (l *Location) String() string {
 	return msg
 }
 
+// suppressed returns true if any of the suppressions silences the
+// linter at this location.
+func (l *Location) suppressed(linter string, suppressions []statement.Suppression) bool {
+	for _, s := range suppressions {
+		if s.Matches(linter, l.Column, l.Index, l.Constraint) {
+			return true
+		}
+	}
+	return false
+}
+
 // sortViolations returns a sorted copy of violations: by table name, then
 // severity (errors first), then linter name.
 func sortViolations(violations []Violation) []Violation {
@@ -136,3 +170,17 @@ func printViolations(violations []Violation) {
 		fmt.Println(v.String())
 	}
 }
+
+// printSuppressed prints a summary of the violations silenced by
+// spirit-lint:ignore directives, so that suppressions stay auditable.
+// Each line is printed with the given prefix.
+func printSuppressed(suppressed []Violation, prefix string) {
+	if len(suppressed) == 0 {
+		return
+	}
+
+	fmt.Printf("%sIgnored %d violation(s) suppressed by spirit-lint:ignore:\n", prefix, len(suppressed))
+	for _, v := range sortViolations(suppressed) {
+		fmt.Printf("%s  %s\n", prefix, v.String())
+	}
+}
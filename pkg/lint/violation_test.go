@@ -319,3 +319,17 @@ func TestViolation_StructInitialization(t *testing.T) {
 func stringPtr(s string) *string {
 	return &s
 }
+
+func TestParseSeverity(t *testing.T) {
+	for s, expected := range map[string]Severity{
+		"info":    SeverityInfo,
+		"Warning": SeverityWarning,
+		"ERROR":   SeverityError,
+	} {
+		severity, err := ParseSeverity(s)
+		require.NoError(t, err)
+		require.Equal(t, expected, severity)
+	}
+	_, err := ParseSeverity("fatal")
+	require.Error(t, err)
+}
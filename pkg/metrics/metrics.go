@@ -5,6 +5,8 @@ package metrics
 import (
 	"context"
 	"log/slog"
+	"maps"
+	"slices"
 	"time"
 )
 
@@ -20,6 +22,7 @@ const (
 	ChunkProcessingTimeMetricName    = "chunk_processing_time"
 	ChunkLogicalRowsCountMetricName  = "chunk_num_logical_rows"
 	ChunkAffectedRowsCountMetricName = "chunk_num_affected_rows"
+	CutoverBlockedEventName          = "cutover_blocked"
 )
 
 // Metrics are collection of MetricValues.
@@ -44,6 +47,31 @@ type Sink interface {
 	Send(ctx context.Context, metrics *Metrics) error
 }
 
+// Event is a structured record of something which happened, such as a
+// connection blocking the cutover.
+type Event struct {
+	// Name is the event name
+	Name string
+
+	// Attributes describe the event.
+	Attributes map[string]string
+}
+
+// EventSink is optionally implemented by a Sink to receive events.
+type EventSink interface {
+	// SendEvent sends an event to the sink. It must respect the context timeout, if any.
+	SendEvent(ctx context.Context, event *Event) error
+}
+
+// SendEvent sends event to sink if it implements EventSink.
+func SendEvent(ctx context.Context, sink Sink, event *Event) error {
+	eventSink, ok := sink.(EventSink)
+	if !ok {
+		return nil
+	}
+	return eventSink.SendEvent(ctx, event)
+}
+
 // NoopSink is the default sink which does nothing
 type NoopSink struct{}
 
@@ -72,7 +100,18 @@ func (l *logSink) Send(ctx context.Context, m *Metrics) error {
 	return nil
 }
 
+func (l *logSink) SendEvent(ctx context.Context, e *Event) error {
+	args := make([]any, 0, 2+len(e.Attributes)*2)
+	args = append(args, "name", e.Name)
+	for _, k := range slices.Sorted(maps.Keys(e.Attributes)) {
+		args = append(args, k, e.Attributes[k])
+	}
+	l.logger.Info("event", args...)
+	return nil
+}
+
 var _ Sink = &logSink{}
+var _ EventSink = &logSink{}
 
 func NewLogSink(logger *slog.Logger) *logSink {
 	return &logSink{
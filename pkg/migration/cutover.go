@@ -6,13 +6,17 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/block/spirit/pkg/coordinator"
 	"github.com/block/spirit/pkg/dbconn"
+	"github.com/block/spirit/pkg/metrics"
 	"github.com/block/spirit/pkg/repl"
 	"github.com/block/spirit/pkg/table"
 	"github.com/block/spirit/pkg/utils"
+	gomysql "github.com/go-mysql-org/go-mysql/mysql"
 )
 
 const (
@@ -29,6 +33,10 @@ const (
 	// cancelled mid-cutover; without an explicit timeout the call could
 	// block indefinitely on an unhealthy connection.
 	cutoverUnlockTimeout = 30 * time.Second
+	// cutoverResumeAttempts is how many times resuming traffic is tried
+	// after the cutover lock is released. Traffic stays paused if every
+	// attempt fails, so it is retried more eagerly than other steps.
+	cutoverResumeAttempts = 5
 )
 
 type CutOver struct {
@@ -37,12 +45,25 @@ type CutOver struct {
 	config   []*cutoverConfig
 	dbConfig *dbconn.DBConfig
 	logger   *slog.Logger
+
+	hooks       map[HookPoint][]CutoverHook // optional
+	hookTimeout time.Duration               // per hook; zero is unlimited
+	coordinator coordinator.Coordinator     // optional; pauses traffic while the lock is held
+	metricsSink metrics.Sink                // optional; receives an event for each blocker
+	readiness   cutoverReadiness            // optional; waits for few changes before each attempt
+
+	// If capturePosition is set, renamedPos is set to the binlog position
+	// right after the rename, read while the lock is still held. Reverse
+	// replication starts from it.
+	capturePosition bool
+	renamedPos      gomysql.Position
 }
 
 type cutoverConfig struct {
 	table          *table.TableInfo
 	newTable       *table.TableInfo
 	oldTableName   string
+	statement      string // passed to hooks
 	useTestCutover bool
 }
 
@@ -108,6 +129,9 @@ func (c *CutOver) Run(ctx context.Context) error {
 				backoff = cutoverMaxBackoff
 			}
 		}
+		if err := c.waitUntilReady(ctx); err != nil {
+			return errors.Join(append(attemptErrs, err)...)
+		}
 		// Try and catch up before we attempt the cutover.
 		// since we will need to catch up again with the lock held
 		// and we want to minimize that.
@@ -129,6 +153,10 @@ func (c *CutOver) Run(ctx context.Context) error {
 			err = c.algorithmRenameUnderLock(ctx)
 		}
 		if err != nil {
+			var lockErr *dbconn.LockWaitError
+			if errors.As(err, &lockErr) {
+				c.sendBlockerEvents(ctx, i+1, lockErr.Blockers)
+			}
 			attemptErrs = append(attemptErrs, fmt.Errorf("attempt %d: %w", i+1, err))
 			c.logger.Warn("cutover failed",
 				"error", err.Error(),
@@ -137,6 +165,9 @@ func (c *CutOver) Run(ctx context.Context) error {
 			continue
 		}
 		c.logger.Warn("final cut over operation complete")
+		if err := c.runHooks(ctx, HookPostRename); err != nil {
+			c.logger.Error("post-rename hook failed after a successful cutover", "error", err)
+		}
 		return nil
 	}
 	c.logger.Error("cutover failed, and retries exhausted")
@@ -163,6 +194,17 @@ func (c *CutOver) algorithmRenameUnderLock(ctx context.Context) error {
 // executeRenameUnderLock is the shared implementation for performing renames under a table lock.
 // It handles locking, binlog flushing, and executing the rename statement.
 func (c *CutOver) executeRenameUnderLock(ctx context.Context, tablesToLock []*table.TableInfo, renameFragments []string) error {
+	if err := c.runHooks(ctx, HookPreLock); err != nil {
+		return err
+	}
+	if c.coordinator != nil {
+		// Resume is deferred before the lock is acquired, so that it runs
+		// after the lock is released.
+		defer c.resumeTraffic(ctx)
+		if err := c.coordinator.Pause(ctx); err != nil {
+			return fmt.Errorf("could not pause traffic before cutover: %w", err)
+		}
+	}
 	tableLock, err := dbconn.NewTableLock(ctx, c.db, tablesToLock, c.dbConfig, c.logger)
 	if err != nil {
 		return err
@@ -187,15 +229,137 @@ func (c *CutOver) executeRenameUnderLock(ctx context.Context, tablesToLock []*ta
 	// (issue #746, fixed in chunker_optimistic.go / chunker_composite.go).
 	// Now that the source of the divergence is gone, a single
 	// FlushUnderTableLock is sufficient.
-	if err := c.feed.FlushUnderTableLock(ctx, tableLock); err != nil {
-		return err
+	//
+	// With a maximum lock time, the flush and under-lock hooks run with a
+	// deadline. If it passes, the lock's connection is killed so that writes
+	// are unblocked at once, and the attempt is retried. The rename itself
+	// is quick, but is never interrupted.
+	lockCtx, stopKill := ctx, func() bool { return true }
+	if c.dbConfig.MaxLockTime > 0 {
+		var cancelLock context.CancelFunc
+		lockCtx, cancelLock = context.WithTimeout(ctx, c.dbConfig.MaxLockTime)
+		defer cancelLock()
+		stopKill = tableLock.KillOnDeadline(lockCtx)
+	}
+	if err := c.feed.FlushUnderTableLock(lockCtx, tableLock); err != nil {
+		return c.lockTimeError(lockCtx, err)
 	}
 	if !c.feed.AllChangesFlushed() {
 		return fmt.Errorf("%w, final flush might be broken", repl.ErrChangesNotFlushed)
 	}
+	if err := c.runHooks(lockCtx, HookUnderLock); err != nil {
+		return c.lockTimeError(lockCtx, err)
+	}
+	if !stopKill() {
+		return c.lockTimeError(lockCtx, lockCtx.Err())
+	}
 
 	renameStatement := "RENAME TABLE " + strings.Join(renameFragments, ", ")
-	return tableLock.ExecUnderLock(ctx, renameStatement)
+	if err := tableLock.ExecUnderLock(ctx, renameStatement); err != nil {
+		return err
+	}
+	if c.capturePosition {
+		// The tables have been renamed, so the cutover must not fail now.
+		// Without a position the old tables can't be kept in sync, which
+		// the caller reports.
+		pos, err := c.feed.CurrentBinlogPosition(ctx)
+		if err != nil {
+			c.logger.Error("could not read the binlog position after the rename", "error", err)
+		} else {
+			c.renamedPos = pos
+		}
+	}
+	return nil
+}
+
+// lockTimeError annotates err if it was caused by the maximum lock time
+// passing while the lock was held.
+func (c *CutOver) lockTimeError(lockCtx context.Context, err error) error {
+	if c.dbConfig.MaxLockTime > 0 && errors.Is(lockCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w (%s), released the lock to retry: %w", dbconn.ErrMaxLockTime, c.dbConfig.MaxLockTime, err)
+	}
+	return err
+}
+
+// sendBlockerEvents sends an event to the metrics sink for each
+// connection which blocked the table lock in a failed attempt.
+func (c *CutOver) sendBlockerEvents(ctx context.Context, attempt int, blockers []dbconn.Blocker) {
+	if c.metricsSink == nil {
+		return
+	}
+	for _, b := range blockers {
+		locks := make([]string, 0, len(b.Locks))
+		for _, lock := range b.Locks {
+			locks = append(locks, fmt.Sprintf("%s:%s:%s.%s", lock.LockStatus, lock.LockType, lock.ObjectSchema, lock.ObjectName))
+		}
+		event := &metrics.Event{
+			Name: metrics.CutoverBlockedEventName,
+			Attributes: map[string]string{
+				"attempt": strconv.Itoa(attempt),
+				"pid":     strconv.Itoa(b.PID),
+				"user":    b.User,
+				"host":    b.Host,
+				"trx_age": b.TrxAge.String(),
+				"query":   b.Query,
+				"locks":   strings.Join(locks, ","),
+			},
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, metrics.SinkTimeout)
+		if err := metrics.SendEvent(sendCtx, c.metricsSink, event); err != nil {
+			c.logger.Warn("could not send cutover blocker event", "error", err)
+		}
+		cancel()
+	}
+}
+
+// resumeTraffic resumes traffic paused by the coordinator. It runs even if
+// ctx was cancelled, and retries, since an error leaves traffic paused.
+func (c *CutOver) resumeTraffic(ctx context.Context) {
+	resumeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cutoverUnlockTimeout)
+	defer cancel()
+	var err error
+	for i := range cutoverResumeAttempts {
+		if err = c.coordinator.Resume(resumeCtx); err == nil {
+			return
+		}
+		c.logger.Error("could not resume traffic after cutover", "attempt", i+1, "error", err)
+		time.Sleep(cutoverInitialBackoff)
+	}
+	c.logger.Error("traffic is still paused after cutover, and must be resumed manually", "error", err)
+}
+
+// runHooks runs the hooks for point in the order they were added,
+// stopping at the first error.
+func (c *CutOver) runHooks(ctx context.Context, point HookPoint) error {
+	hooks := c.hooks[point]
+	if len(hooks) == 0 {
+		return nil
+	}
+	info := HookInfo{Point: point, Schema: c.config[0].table.SchemaName}
+	for _, cfg := range c.config {
+		info.Tables = append(info.Tables, HookTable{
+			Table:     cfg.table.TableName,
+			NewTable:  cfg.newTable.TableName,
+			OldTable:  cfg.oldTableName,
+			Statement: cfg.statement,
+		})
+	}
+	for i, hook := range hooks {
+		c.logger.Info("running cutover hook", "point", point, "hook", i+1, "hooks", len(hooks))
+		if err := c.runHook(ctx, hook, info); err != nil {
+			return fmt.Errorf("%s hook %d failed: %w", point, i+1, err)
+		}
+	}
+	return nil
+}
+
+func (c *CutOver) runHook(ctx context.Context, hook CutoverHook, info HookInfo) error {
+	if c.hookTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.hookTimeout)
+		defer cancel()
+	}
+	return hook(ctx, info)
 }
 
 // partialRenameForTest performs a partial cutover (only renames original table to _old)
@@ -0,0 +1,99 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// cutoverReadyInterval is how often the readiness thresholds are checked.
+// It is a var so tests can shorten it.
+var cutoverReadyInterval = 250 * time.Millisecond
+
+// readyTimeoutAction is what happens when the readiness thresholds are
+// not met within the timeout.
+type readyTimeoutAction string
+
+const (
+	readyTimeoutProceed readyTimeoutAction = "proceed"
+	readyTimeoutAbort   readyTimeoutAction = "abort"
+)
+
+func parseReadyTimeoutAction(name string) (readyTimeoutAction, error) {
+	switch readyTimeoutAction(name) {
+	case "", readyTimeoutProceed:
+		return readyTimeoutProceed, nil
+	case readyTimeoutAbort:
+		return readyTimeoutAbort, nil
+	}
+	return "", fmt.Errorf("unknown action %q, must be one of proceed or abort", name)
+}
+
+// cutoverReadiness configures the wait before each cutover attempt until
+// few enough changes are arriving that the backlog applied under the table
+// lock is small.
+type cutoverReadiness struct {
+	maxDelta        int   // changes pending since the last flush; 0 disables
+	maxBinlogBehind int64 // bytes not yet read from the binary log; 0 disables
+	window          time.Duration
+	timeout         time.Duration // 0 waits indefinitely
+	timeoutAction   readyTimeoutAction
+}
+
+func (r cutoverReadiness) enabled() bool {
+	return r.maxDelta > 0 || r.maxBinlogBehind > 0
+}
+
+// waitUntilReady blocks until the pending changes and the binary log
+// reader's lag have stayed below the thresholds for the whole window.
+// Changes are not applied while the window is open, so the pending
+// changes are what arrived during it. Whenever a threshold is exceeded
+// the changes are flushed and the window starts again.
+func (c *CutOver) waitUntilReady(ctx context.Context) error {
+	if !c.readiness.enabled() {
+		return nil
+	}
+	c.logger.Info("waiting for cutover readiness",
+		"max-delta", c.readiness.maxDelta,
+		"max-binlog-behind", c.readiness.maxBinlogBehind,
+		"window", c.readiness.window,
+	)
+	start := time.Now()
+	windowStart := start
+	ticker := time.NewTicker(cutoverReadyInterval)
+	defer ticker.Stop()
+	for {
+		delta := c.feed.GetDeltaLen()
+		behind, err := c.feed.BinlogBytesBehind(ctx)
+		if err != nil {
+			return err
+		}
+		ready := (c.readiness.maxDelta == 0 || delta < c.readiness.maxDelta) &&
+			(c.readiness.maxBinlogBehind == 0 || behind < c.readiness.maxBinlogBehind)
+		switch {
+		case ready && time.Since(windowStart) >= c.readiness.window:
+			c.logger.Info("cutover is ready", "delta", delta, "binlog-behind", behind, "waited", time.Since(start))
+			return nil
+		case c.readiness.timeout > 0 && time.Since(start) >= c.readiness.timeout:
+			if c.readiness.timeoutAction == readyTimeoutAbort {
+				return fmt.Errorf("cutover was not ready after %s: %d changes pending, %d bytes of binary log behind", c.readiness.timeout, delta, behind)
+			}
+			c.logger.Warn("cutover was not ready in time, proceeding anyway",
+				"timeout", c.readiness.timeout,
+				"delta", delta,
+				"binlog-behind", behind,
+			)
+			return nil
+		case !ready:
+			if err := c.feed.Flush(ctx); err != nil {
+				return err
+			}
+			windowStart = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
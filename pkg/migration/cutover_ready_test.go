@@ -0,0 +1,104 @@
+package migration
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/block/spirit/pkg/applier"
+	"github.com/block/spirit/pkg/dbconn"
+	"github.com/block/spirit/pkg/repl"
+	"github.com/block/spirit/pkg/table"
+	"github.com/block/spirit/pkg/testutils"
+	"github.com/block/spirit/pkg/utils"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCutoverReadiness(t *testing.T) {
+	m := &Migration{CutoverMaxDelta: -1}
+	require.ErrorContains(t, m.Validate(), "--cutover-max-delta must be non-negative")
+	m = &Migration{CutoverMaxBinlogBehind: -1}
+	require.ErrorContains(t, m.Validate(), "--cutover-max-binlog-behind must be non-negative")
+	m = &Migration{CutoverReadyWindow: -time.Second}
+	require.ErrorContains(t, m.Validate(), "--cutover-ready-window must be non-negative")
+	m = &Migration{CutoverReadyTimeout: -time.Second}
+	require.ErrorContains(t, m.Validate(), "--cutover-ready-timeout must be non-negative")
+	m = &Migration{CutoverReadyTimeoutAction: "wait"}
+	require.ErrorContains(t, m.Validate(), `--cutover-ready-timeout-action: unknown action "wait"`)
+	m = &Migration{CutoverMaxDelta: 100, CutoverMaxBinlogBehind: 1 << 20, CutoverReadyTimeoutAction: "abort"}
+	require.NoError(t, m.Validate())
+
+	action, err := parseReadyTimeoutAction("")
+	require.NoError(t, err)
+	require.Equal(t, readyTimeoutProceed, action)
+}
+
+func TestCutOverReadiness(t *testing.T) {
+	t.Parallel()
+	testutils.NewTestTable(t, "readyt1", `CREATE TABLE readyt1 (
+		id int(11) NOT NULL AUTO_INCREMENT,
+		name varchar(255) NOT NULL,
+		PRIMARY KEY (id)
+	)`)
+	testutils.RunSQL(t, `CREATE TABLE _readyt1_new (
+		id int(11) NOT NULL AUTO_INCREMENT,
+		name varchar(255) NOT NULL,
+		PRIMARY KEY (id)
+	)`)
+	testutils.RunSQL(t, `CREATE TABLE _readyt1_chkpnt (a int)`) // for binlog advancement
+
+	cfg, err := mysql.ParseDSN(testutils.DSN())
+	require.NoError(t, err)
+	config := dbconn.NewDBConfig()
+	db, err := dbconn.New(testutils.DSN(), config)
+	require.NoError(t, err)
+	defer utils.CloseAndLog(db)
+
+	t1 := table.NewTableInfo(db, cfg.DBName, "readyt1")
+	require.NoError(t, t1.SetInfo(t.Context()))
+	t1new := table.NewTableInfo(db, cfg.DBName, "_readyt1_new")
+	require.NoError(t, t1new.SetInfo(t.Context()))
+	feed := repl.NewClient(db, cfg.Addr, cfg.User, cfg.Passwd, applier.NewSingleTargetForTest(t, db), repl.NewClientDefaultConfig())
+	defer feed.Close()
+	chunker, err := table.NewChunker(t1, table.ChunkerConfig{NewTable: t1new})
+	require.NoError(t, err)
+	require.NoError(t, feed.AddSubscription(t1, t1new, chunker))
+	require.NoError(t, feed.Run(t.Context()))
+
+	cutover, err := NewCutOver(db, []*cutoverConfig{{
+		table:        t1,
+		newTable:     t1new,
+		oldTableName: "_readyt1_old",
+	}}, feed, config, slog.Default())
+	require.NoError(t, err)
+	cutover.readiness = cutoverReadiness{
+		maxDelta:      5,
+		window:        time.Second,
+		timeout:       3 * time.Second,
+		timeoutAction: readyTimeoutAbort,
+	}
+
+	// A steady stream of writes keeps the cutover from becoming ready.
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for !stop.Load() {
+			if _, err := db.ExecContext(t.Context(), "INSERT INTO readyt1 (name) VALUES ('a')"); err != nil {
+				return
+			}
+		}
+	})
+	err = cutover.Run(t.Context())
+	stop.Store(true)
+	wg.Wait()
+	require.ErrorContains(t, err, "cutover was not ready after 3s")
+
+	// Once the writes stop, the cutover proceeds.
+	require.NoError(t, cutover.Run(t.Context()))
+	var count int
+	require.NoError(t, db.QueryRowContext(t.Context(), "SELECT COUNT(*) FROM readyt1").Scan(&count))
+	require.Positive(t, count)
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/block/spirit/pkg/applier"
 	"github.com/block/spirit/pkg/dbconn"
+	"github.com/block/spirit/pkg/metrics"
 	"github.com/block/spirit/pkg/repl"
 	"github.com/block/spirit/pkg/status"
 	"github.com/block/spirit/pkg/table"
@@ -128,6 +130,8 @@ func TestMDLLockFails(t *testing.T) {
 	}
 	cutover, err := NewCutOver(db, cutoverConfig, feed, config, logger)
 	require.NoError(t, err)
+	sink := &eventSink{}
+	cutover.metricsSink = sink
 
 	// READ LOCK the table — this won't fail the table lock but will fail the rename.
 	trx, err := db.BeginTx(t.Context(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
@@ -149,9 +153,33 @@ func TestMDLLockFails(t *testing.T) {
 	require.Contains(t, err.Error(), "attempt 1:")
 	require.Contains(t, err.Error(), "attempt 2:")
 
+	// The connection holding the lock is reported in the error and as an
+	// event for each attempt.
+	var pid int
+	require.NoError(t, trx.QueryRowContext(t.Context(), "SELECT CONNECTION_ID()").Scan(&pid))
+	require.Contains(t, err.Error(), fmt.Sprintf("blocked by: pid %d ", pid))
+	require.Len(t, sink.events, 2)
+	for i, event := range sink.events {
+		require.Equal(t, metrics.CutoverBlockedEventName, event.Name)
+		require.Equal(t, strconv.Itoa(i+1), event.Attributes["attempt"])
+		require.Equal(t, strconv.Itoa(pid), event.Attributes["pid"])
+		require.Contains(t, event.Attributes["locks"], "GRANTED:SHARED_READ_ONLY:")
+	}
+
 	require.NoError(t, trx.Rollback())
 }
 
+// eventSink records the events it is sent.
+type eventSink struct {
+	metrics.NoopSink
+	events []*metrics.Event
+}
+
+func (s *eventSink) SendEvent(ctx context.Context, event *metrics.Event) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
 func TestInvalidOptions(t *testing.T) {
 	t.Parallel()
 	cfg, err := mysql.ParseDSN(testutils.DSN())
@@ -776,3 +804,116 @@ func TestDeferCutOverE2EBinlogAdvance(t *testing.T) {
 	require.Equal(t, 0, tableCount)
 	require.NoError(t, m.Close())
 }
+
+type testCoordinator struct {
+	events *[]string
+}
+
+func (c *testCoordinator) Pause(ctx context.Context) error {
+	*c.events = append(*c.events, "pause")
+	return nil
+}
+
+func (c *testCoordinator) Resume(ctx context.Context) error {
+	*c.events = append(*c.events, "resume")
+	return nil
+}
+
+func (c *testCoordinator) Close() error {
+	return nil
+}
+
+func TestCutOverCoordinator(t *testing.T) {
+	t.Parallel()
+	testutils.NewTestTable(t, "coordt1", `CREATE TABLE coordt1 (id int NOT NULL PRIMARY KEY)`)
+	testutils.RunSQL(t, `CREATE TABLE _coordt1_new (id int NOT NULL PRIMARY KEY)`)
+
+	cfg, err := mysql.ParseDSN(testutils.DSN())
+	require.NoError(t, err)
+	db, err := dbconn.New(testutils.DSN(), dbconn.NewDBConfig())
+	require.NoError(t, err)
+	defer utils.CloseAndLog(db)
+
+	t1 := table.NewTableInfo(db, cfg.DBName, "coordt1")
+	require.NoError(t, t1.SetInfo(t.Context()))
+	t1new := table.NewTableInfo(db, cfg.DBName, "_coordt1_new")
+	feed := repl.NewClient(db, cfg.Addr, cfg.User, cfg.Passwd, applier.NewSingleTargetForTest(t, db), repl.NewClientDefaultConfig())
+	defer feed.Close()
+	chunker, err := table.NewChunker(t1, table.ChunkerConfig{NewTable: t1new})
+	require.NoError(t, err)
+	require.NoError(t, feed.AddSubscription(t1, t1new, chunker))
+	require.NoError(t, feed.Run(t.Context()))
+
+	cutover, err := NewCutOver(db, []*cutoverConfig{
+		{table: t1, newTable: t1new, oldTableName: "_coordt1_old"},
+	}, feed, dbconn.NewDBConfig(), slog.Default())
+	require.NoError(t, err)
+
+	var events []string
+	record := func(ctx context.Context, info HookInfo) error {
+		events = append(events, string(info.Point))
+		return nil
+	}
+	cutover.coordinator = &testCoordinator{events: &events}
+	cutover.hooks = map[HookPoint][]CutoverHook{
+		HookPreLock:    {record},
+		HookUnderLock:  {record},
+		HookPostRename: {record},
+	}
+	require.NoError(t, cutover.Run(t.Context()))
+	// Traffic is paused only while the lock is held.
+	require.Equal(t, []string{"pre-lock", "pause", "under-lock", "resume", "post-rename"}, events)
+}
+
+func TestCutOverMaxLockTime(t *testing.T) {
+	t.Parallel()
+	testutils.NewTestTable(t, "locktimet1", `CREATE TABLE locktimet1 (id int NOT NULL PRIMARY KEY)`)
+	testutils.RunSQL(t, `CREATE TABLE _locktimet1_new (id int NOT NULL PRIMARY KEY)`)
+
+	cfg, err := mysql.ParseDSN(testutils.DSN())
+	require.NoError(t, err)
+	db, err := dbconn.New(testutils.DSN(), dbconn.NewDBConfig())
+	require.NoError(t, err)
+	defer utils.CloseAndLog(db)
+
+	t1 := table.NewTableInfo(db, cfg.DBName, "locktimet1")
+	require.NoError(t, t1.SetInfo(t.Context()))
+	t1new := table.NewTableInfo(db, cfg.DBName, "_locktimet1_new")
+	feed := repl.NewClient(db, cfg.Addr, cfg.User, cfg.Passwd, applier.NewSingleTargetForTest(t, db), repl.NewClientDefaultConfig())
+	defer feed.Close()
+	chunker, err := table.NewChunker(t1, table.ChunkerConfig{NewTable: t1new})
+	require.NoError(t, err)
+	require.NoError(t, feed.AddSubscription(t1, t1new, chunker))
+	require.NoError(t, feed.Run(t.Context()))
+
+	dbConfig := dbconn.NewDBConfig()
+	dbConfig.MaxRetries = 2
+	dbConfig.MaxLockTime = 500 * time.Millisecond
+	cutover, err := NewCutOver(db, []*cutoverConfig{
+		{table: t1, newTable: t1new, oldTableName: "_locktimet1_old"},
+	}, feed, dbConfig, slog.Default())
+	require.NoError(t, err)
+
+	// The first attempt holds the lock until the maximum lock time passes,
+	// and the second completes in time.
+	var attempts int
+	var firstErr error
+	cutover.hooks = map[HookPoint][]CutoverHook{
+		HookUnderLock: {func(ctx context.Context, info HookInfo) error {
+			attempts++
+			if attempts == 1 {
+				<-ctx.Done()
+				firstErr = ctx.Err()
+				return firstErr
+			}
+			return nil
+		}},
+	}
+	require.NoError(t, cutover.Run(t.Context()))
+	require.Equal(t, 2, attempts)
+	require.ErrorIs(t, firstErr, context.DeadlineExceeded)
+
+	var n int
+	require.NoError(t, db.QueryRowContext(t.Context(), "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = '_locktimet1_old'", cfg.DBName).Scan(&n))
+	require.Equal(t, 1, n)
+}
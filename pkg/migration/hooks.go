@@ -0,0 +1,102 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// hookWaitDelay is how long a hook command's output is waited for after
+// it is killed, for example on timeout. A child process that keeps the
+// output open would otherwise block the hook until the child exits,
+// which matters when the hook is holding the table lock.
+const hookWaitDelay = time.Second
+
+// HookPoint identifies a point in the cutover where hooks are run.
+type HookPoint string
+
+const (
+	// HookPreLock runs before the table lock is acquired. It runs on every
+	// cutover attempt, so it may run more than once.
+	HookPreLock HookPoint = "pre-lock"
+	// HookUnderLock runs with the tables locked, after the final flush and
+	// immediately before the rename. Nothing can write to the tables while
+	// it runs, so it can be used to change state which must switch at the
+	// same time as the tables. An error fails the attempt without renaming
+	// the tables. If the rename itself fails, the hook runs again on the
+	// next attempt. The lock is held until it returns.
+	HookUnderLock HookPoint = "under-lock"
+	// HookPostRename runs once after the tables have been renamed and the
+	// lock released. The cutover can not be undone at this point, so an
+	// error is logged rather than failing the migration.
+	HookPostRename HookPoint = "post-rename"
+)
+
+// HookPoints lists the supported hook points, in the order they run.
+var HookPoints = []HookPoint{HookPreLock, HookUnderLock, HookPostRename}
+
+// CutoverHook is called at a HookPoint during the cutover.
+type CutoverHook func(ctx context.Context, info HookInfo) error
+
+// HookInfo describes the change being cut over.
+type HookInfo struct {
+	Point  HookPoint
+	Schema string
+	Tables []HookTable
+}
+
+// HookTable describes one table in the change.
+type HookTable struct {
+	Table     string // the table name, which has the new schema after the rename
+	NewTable  string // the shadow table which is renamed to Table
+	OldTable  string // the name the original table is renamed to
+	Statement string
+}
+
+func parseHookPoint(name string) (HookPoint, error) {
+	for _, point := range HookPoints {
+		if name == string(point) {
+			return point, nil
+		}
+	}
+	return "", fmt.Errorf("unknown hook point %q, must be one of %v", name, HookPoints)
+}
+
+// env returns the environment variables which describe the change
+// to a hook command.
+func (info HookInfo) env() []string {
+	var tables, newTables, oldTables, statements []string
+	for _, tbl := range info.Tables {
+		tables = append(tables, tbl.Table)
+		newTables = append(newTables, tbl.NewTable)
+		oldTables = append(oldTables, tbl.OldTable)
+		statements = append(statements, tbl.Statement)
+	}
+	return []string{
+		"SPIRIT_HOOK_POINT=" + string(info.Point),
+		"SPIRIT_SCHEMA=" + info.Schema,
+		"SPIRIT_TABLES=" + strings.Join(tables, ","),
+		"SPIRIT_NEW_TABLES=" + strings.Join(newTables, ","),
+		"SPIRIT_OLD_TABLES=" + strings.Join(oldTables, ","),
+		"SPIRIT_STATEMENTS=" + strings.Join(statements, ";\n"),
+	}
+}
+
+// commandHook returns a hook which runs command with sh. The change is
+// described by the SPIRIT_* environment variables. The hook fails if the
+// command exits non-zero, and the error includes its output.
+func commandHook(command string) CutoverHook {
+	return func(ctx context.Context, info HookInfo) error {
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Env = append(os.Environ(), info.env()...)
+		cmd.WaitDelay = hookWaitDelay
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("hook command %q failed: %w: %s", command, err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+}
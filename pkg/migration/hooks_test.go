@@ -0,0 +1,127 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/block/spirit/pkg/applier"
+	"github.com/block/spirit/pkg/dbconn"
+	"github.com/block/spirit/pkg/repl"
+	"github.com/block/spirit/pkg/table"
+	"github.com/block/spirit/pkg/testutils"
+	"github.com/block/spirit/pkg/utils"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHookPoint(t *testing.T) {
+	for _, point := range HookPoints {
+		p, err := parseHookPoint(string(point))
+		require.NoError(t, err)
+		require.Equal(t, point, p)
+	}
+	_, err := parseHookPoint("post-lock")
+	require.ErrorContains(t, err, `unknown hook point "post-lock"`)
+}
+
+func TestValidateHookCmd(t *testing.T) {
+	m := &Migration{HookCmds: map[string]string{"before-lock": "true"}}
+	require.ErrorContains(t, m.Validate(), "--hook-cmd: unknown hook point")
+	m = &Migration{HookTimeout: -time.Second}
+	require.ErrorContains(t, m.Validate(), "--hook-timeout must be non-negative")
+	m = &Migration{HookCmds: map[string]string{"under-lock": "true", "post-rename": "true"}}
+	require.NoError(t, m.Validate())
+}
+
+func TestCommandHook(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "env")
+	info := HookInfo{
+		Point:  HookUnderLock,
+		Schema: "test",
+		Tables: []HookTable{
+			{Table: "t1", NewTable: "_t1_new", OldTable: "_t1_old", Statement: "ALTER TABLE t1 ADD c INT"},
+			{Table: "t2", NewTable: "_t2_new", OldTable: "_t2_old", Statement: "ALTER TABLE t2 ADD c INT"},
+		},
+	}
+	hook := commandHook(`printf '%s|%s|%s|%s|%s|%s' "$SPIRIT_HOOK_POINT" "$SPIRIT_SCHEMA" "$SPIRIT_TABLES" "$SPIRIT_NEW_TABLES" "$SPIRIT_OLD_TABLES" "$SPIRIT_STATEMENTS" > ` + out)
+	require.NoError(t, hook(t.Context(), info))
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, "under-lock|test|t1,t2|_t1_new,_t2_new|_t1_old,_t2_old|ALTER TABLE t1 ADD c INT;\nALTER TABLE t2 ADD c INT", string(data))
+
+	err = commandHook("echo not allowed; exit 3")(t.Context(), info)
+	require.ErrorContains(t, err, "exit status 3: not allowed")
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	require.Error(t, commandHook("sleep 10")(ctx, info))
+}
+
+func TestCutOverHooks(t *testing.T) {
+	t.Parallel()
+	testutils.NewTestTable(t, "hookt1", `CREATE TABLE hookt1 (id int NOT NULL PRIMARY KEY)`)
+	testutils.RunSQL(t, `CREATE TABLE _hookt1_new (id int NOT NULL PRIMARY KEY)`)
+
+	cfg, err := mysql.ParseDSN(testutils.DSN())
+	require.NoError(t, err)
+	db, err := dbconn.New(testutils.DSN(), dbconn.NewDBConfig())
+	require.NoError(t, err)
+	defer utils.CloseAndLog(db)
+
+	t1 := table.NewTableInfo(db, cfg.DBName, "hookt1")
+	require.NoError(t, t1.SetInfo(t.Context()))
+	t1new := table.NewTableInfo(db, cfg.DBName, "_hookt1_new")
+	feed := repl.NewClient(db, cfg.Addr, cfg.User, cfg.Passwd, applier.NewSingleTargetForTest(t, db), repl.NewClientDefaultConfig())
+	defer feed.Close()
+	chunker, err := table.NewChunker(t1, table.ChunkerConfig{NewTable: t1new})
+	require.NoError(t, err)
+	require.NoError(t, feed.AddSubscription(t1, t1new, chunker))
+	require.NoError(t, feed.Run(t.Context()))
+
+	dbConfig := dbconn.NewDBConfig()
+	dbConfig.MaxRetries = 3
+	cutover, err := NewCutOver(db, []*cutoverConfig{
+		{table: t1, newTable: t1new, oldTableName: "_hookt1_old", statement: "ALTER TABLE hookt1 ENGINE=InnoDB"},
+	}, feed, dbConfig, slog.Default())
+	require.NoError(t, err)
+
+	tableExists := func(name string) bool {
+		var n int
+		require.NoError(t, db.QueryRowContext(t.Context(), "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = ?", cfg.DBName, name).Scan(&n))
+		return n == 1
+	}
+	var calls []HookPoint
+	record := func(ctx context.Context, info HookInfo) error {
+		calls = append(calls, info.Point)
+		require.Equal(t, cfg.DBName, info.Schema)
+		require.Equal(t, []HookTable{{Table: "hookt1", NewTable: "_hookt1_new", OldTable: "_hookt1_old", Statement: "ALTER TABLE hookt1 ENGINE=InnoDB"}}, info.Tables)
+		return nil
+	}
+	underLockAttempts := 0
+	cutover.hooks = map[HookPoint][]CutoverHook{
+		HookPreLock: {record},
+		HookUnderLock: {record, func(ctx context.Context, info HookInfo) error {
+			// The tables have not been renamed yet.
+			require.True(t, tableExists("_hookt1_new"))
+			underLockAttempts++
+			if underLockAttempts == 1 {
+				return errors.New("not yet")
+			}
+			return nil
+		}},
+		HookPostRename: {record, func(ctx context.Context, info HookInfo) error {
+			require.True(t, tableExists("_hookt1_old"))
+			return errors.New("post-rename errors are only logged")
+		}},
+	}
+	require.NoError(t, cutover.Run(t.Context()))
+	// The first attempt fails under the lock, and is retried.
+	require.Equal(t, []HookPoint{HookPreLock, HookUnderLock, HookPreLock, HookUnderLock, HookPostRename}, calls)
+	require.True(t, tableExists("_hookt1_old"))
+	require.False(t, tableExists("_hookt1_new"))
+}
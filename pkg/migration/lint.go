@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"strings"
 
 	"github.com/block/spirit/pkg/lint"
@@ -23,12 +24,16 @@ var (
 func (r *Runner) lint(ctx context.Context) error {
 	var createTables []*statement.CreateTable
 	var alterTables []*statement.AbstractStatement
+	// Copy the defaults so the config file cannot modify them.
 	config := lint.Config{
 		Enabled:  make(map[string]bool),
-		Settings: defaultLinterSettings,
+		Settings: make(map[string]map[string]string, len(defaultLinterSettings)),
 	}
-
-	if err := printLinters(config); err != nil {
+	for name, settings := range defaultLinterSettings {
+		config.Settings[name] = maps.Clone(settings)
+	}
+	fileConfig, err := lint.LoadConfigFile(r.migration.LintConfig)
+	if err != nil {
 		return err
 	}
 
@@ -54,19 +59,29 @@ func (r *Runner) lint(ctx context.Context) error {
 		}
 	}
 
+	if err := fileConfig.Apply(&config, createTables); err != nil {
+		return fmt.Errorf("invalid lint config: %w", err)
+	}
+	if err := printLinters(config); err != nil {
+		return err
+	}
+
 	var errs []error
 
-	violations, err := lint.RunLinters(createTables, alterTables, config)
+	result, err := lint.Run(createTables, alterTables, config)
 	if err != nil {
 		errs = append(errs, err)
 	}
 
-	for _, v := range violations {
+	for _, v := range result.Violations {
 		if v.Severity == lint.SeverityError {
 			errs = append(errs, errors.New(v.String()))
 		}
 		fmt.Println(v)
 	}
+	for _, v := range result.Suppressed {
+		fmt.Printf("Ignored (spirit-lint:ignore): %s\n", v)
+	}
 
 	if len(errs) > 0 {
 		return errors.Join(errs...)
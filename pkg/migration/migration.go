@@ -45,6 +45,7 @@ type Migration struct {
 	Statement            string        `name:"statement" help:"The SQL statement to run (replaces --table and --alter)" optional:"" default:""`
 	Lint                 bool          `name:"lint" help:"Run lint checks before running migration" optional:""`
 	LintOnly             bool          `name:"lint-only" help:"Run lint checks and exit without performing migration" optional:""`
+	LintConfig           string        `name:"lint-config" help:"Lint configuration file (default: .spirit-lint.yaml in the working directory)" optional:"" type:"existingfile"`
 
 	// TLS Configuration
 	TLSMode            string `name:"tls-mode" help:"TLS connection mode (case insensitive): DISABLED, PREFERRED (default), REQUIRED, VERIFY_CA, VERIFY_IDENTITY" optional:""`
@@ -57,6 +58,9 @@ type Migration struct {
 
 	CheckpointMaxAge     time.Duration `name:"checkpoint-max-age" help:"Maximum age of a checkpoint before refusing to resume from it" optional:"" default:"168h"`
 	ChecksumYieldTimeout time.Duration `name:"checksum-yield-timeout" help:"Maximum duration for a single checksum pass before yielding to release long-running REPEATABLE READ transactions (reduces InnoDB HLL growth)" optional:"" default:"24h"`
+	ChecksumAlgorithm    string        `name:"checksum-algorithm" help:"Checksum function: crc32, md5 or sha256" optional:"" default:"crc32"`
+	ChecksumDiffFile     string        `name:"checksum-diff-file" help:"Write every row that differs in a mismatched checksum chunk to this file (JSON lines)" optional:""`
+	ChecksumDiffRedact   bool          `name:"checksum-diff-redact" help:"Replace column values in the --checksum-diff-file with a placeholder; primary keys are kept" optional:"" default:"false"`
 
 	// MaxCommitLatency throttles when observed commit latency exceeds this
 	// threshold. Currently auto-enabled only on Aurora (auto-detected); the
@@ -64,6 +68,52 @@ type Migration struct {
 	// extreme tail latencies. See issue #468.
 	MaxCommitLatency time.Duration `name:"max-commit-latency" help:"Throttle when average commit latency exceeds this threshold (currently only auto-enabled on Aurora)" optional:"" default:"100ms"`
 
+	// Server health throttlers. Each is disabled when zero. They pace the
+	// copy, and the checksum yields its read views while they are throttled.
+	MaxThreadsRunning    int64 `name:"max-threads-running" help:"Throttle when Threads_running reaches this value (0 disables)" optional:"" default:"0"`
+	MaxHistoryListLength int64 `name:"max-history-list-length" help:"Throttle when the InnoDB history list length reaches this value (0 disables)" optional:"" default:"0"`
+	MaxCheckpointAge     int64 `name:"max-checkpoint-age" help:"Throttle when the InnoDB checkpoint age in bytes reaches this value (0 disables)" optional:"" default:"0"`
+
+	// External throttling service, such as freno or the Vitess tablet throttler.
+	ThrottlerURL           string        `name:"throttler-url" help:"URL of an external throttling service to check before copying (200 proceeds, 429 throttles)" optional:""`
+	ThrottlerApp           string        `name:"throttler-app" help:"App name sent to the external throttling service" optional:"" default:"spirit"`
+	ThrottlerCheckInterval time.Duration `name:"throttler-check-interval" help:"How often to check the external throttling service" optional:"" default:"1s"`
+
+	// Scheduling of tables in multi-table migrations.
+	TablePriorities     map[string]int `name:"table-priority" help:"Copy priority for a table as table=priority (repeatable); higher priorities are copied first" optional:""`
+	MaxConcurrentTables int            `name:"max-concurrent-tables" help:"Maximum number of tables to copy at the same time (0 is unlimited)" optional:"" default:"0"`
+
+	// Cutover hooks. Commands run with sh and describe the change in
+	// SPIRIT_* environment variables; see AddCutoverHook for Go callbacks.
+	HookCmds    map[string]string `name:"hook-cmd" help:"Shell command to run during cutover as point=command (repeatable); points are pre-lock, under-lock and post-rename" optional:"" mapsep:"none"`
+	HookTimeout time.Duration     `name:"hook-timeout" help:"Maximum time each cutover hook may run (0 is unlimited); under-lock hooks hold the table lock" optional:"" default:"30s"`
+
+	// Cutover coordination pauses application traffic at a proxy while the
+	// table lock is held, using either ProxySQL or an HTTP endpoint.
+	ProxySQLAdminDSN    string        `name:"proxysql-admin-dsn" help:"DSN of the ProxySQL admin interface, to pause traffic in ProxySQL during cutover" optional:""`
+	ProxySQLHostgroups  []int         `name:"proxysql-hostgroup" help:"ProxySQL hostgroup that routes to this database (repeatable)" optional:""`
+	CutoverPauseURL     string        `name:"cutover-pause-url" help:"URL to POST to pause traffic before the cutover lock is acquired" optional:""`
+	CutoverResumeURL    string        `name:"cutover-resume-url" help:"URL to POST to resume traffic after the cutover lock is released" optional:""`
+	CutoverPauseTimeout time.Duration `name:"cutover-pause-timeout" help:"How long to wait for traffic to pause before acquiring the cutover lock" optional:"" default:"5s"`
+
+	// Cutover readiness waits before each cutover attempt until few changes
+	// are arriving, so that the backlog applied under the table lock is
+	// small. Each threshold is disabled when zero.
+	CutoverMaxDelta           int           `name:"cutover-max-delta" help:"Wait before each cutover attempt until fewer than this many changes arrive within --cutover-ready-window (0 disables)" optional:"" default:"0"`
+	CutoverMaxBinlogBehind    int64         `name:"cutover-max-binlog-behind" help:"Wait before each cutover attempt until the binary log reader is fewer than this many bytes behind (0 disables)" optional:"" default:"0"`
+	CutoverReadyWindow        time.Duration `name:"cutover-ready-window" help:"How long the cutover thresholds must hold before the cutover attempt proceeds" optional:"" default:"5s"`
+	CutoverReadyTimeout       time.Duration `name:"cutover-ready-timeout" help:"How long to wait for the cutover thresholds before --cutover-ready-timeout-action is taken (0 waits indefinitely)" optional:"" default:"10m"`
+	CutoverReadyTimeoutAction string        `name:"cutover-ready-timeout-action" help:"What to do when the cutover thresholds are not met in time: proceed or abort" optional:"" default:"proceed"`
+
+	// MaxCutoverLockTime bounds how long writes are blocked by each
+	// cutover attempt, after the table lock has been acquired.
+	MaxCutoverLockTime time.Duration `name:"max-cutover-lock-time" help:"Maximum time the tables may be locked for the final flush at cutover before the lock is released and the cutover retried (0 is unlimited)" optional:"" default:"0s"`
+
+	// Reverse replication keeps the old tables in sync after the cutover,
+	// so that the change can be rolled back with --rollback.
+	ReverseReplicationDuration time.Duration `name:"reverse-replication-duration" help:"After cutover, replicate changes back to the old tables for this long so the change can be rolled back (requires --skip-drop-after-cutover)" optional:"" default:"0s"`
+	Rollback                   bool          `name:"rollback" help:"Roll back the migration which is replicating changes back to the old tables in --database, by swapping the tables back" optional:"" default:"false"`
+
 	// Hidden options for now (supports more obscure cash/sq usecases)
 	InterpolateParams bool `name:"interpolate-params" help:"Enable interpolate params for DSN" optional:"" default:"false" hidden:""`
 	// Used for tests so we can concurrently execute without issues even though
@@ -96,10 +146,81 @@ func (m *Migration) Validate() error {
 	if m.CheckpointMaxAge < 0 {
 		return fmt.Errorf("--checkpoint-max-age must be non-negative, got %s", m.CheckpointMaxAge)
 	}
+	if m.MaxThreadsRunning < 0 {
+		return fmt.Errorf("--max-threads-running must be non-negative, got %d", m.MaxThreadsRunning)
+	}
+	if m.MaxHistoryListLength < 0 {
+		return fmt.Errorf("--max-history-list-length must be non-negative, got %d", m.MaxHistoryListLength)
+	}
+	if m.MaxCheckpointAge < 0 {
+		return fmt.Errorf("--max-checkpoint-age must be non-negative, got %d", m.MaxCheckpointAge)
+	}
+	if m.ThrottlerCheckInterval < 0 {
+		return fmt.Errorf("--throttler-check-interval must be non-negative, got %s", m.ThrottlerCheckInterval)
+	}
+	if m.MaxConcurrentTables < 0 {
+		return fmt.Errorf("--max-concurrent-tables must be non-negative, got %d", m.MaxConcurrentTables)
+	}
+	if _, err := checksum.ParseAlgorithm(m.ChecksumAlgorithm); err != nil {
+		return fmt.Errorf("--checksum-algorithm: %w", err)
+	}
+	for point := range m.HookCmds {
+		if _, err := parseHookPoint(point); err != nil {
+			return fmt.Errorf("--hook-cmd: %w", err)
+		}
+	}
+	if m.HookTimeout < 0 {
+		return fmt.Errorf("--hook-timeout must be non-negative, got %s", m.HookTimeout)
+	}
+	if (m.ProxySQLAdminDSN == "") != (len(m.ProxySQLHostgroups) == 0) {
+		return errors.New("--proxysql-admin-dsn and --proxysql-hostgroup must be used together")
+	}
+	if (m.CutoverPauseURL == "") != (m.CutoverResumeURL == "") {
+		return errors.New("--cutover-pause-url and --cutover-resume-url must be used together")
+	}
+	if m.ProxySQLAdminDSN != "" && m.CutoverPauseURL != "" {
+		return errors.New("--proxysql-admin-dsn and --cutover-pause-url are mutually exclusive")
+	}
+	if m.CutoverPauseTimeout < 0 {
+		return fmt.Errorf("--cutover-pause-timeout must be non-negative, got %s", m.CutoverPauseTimeout)
+	}
+	if m.CutoverMaxDelta < 0 {
+		return fmt.Errorf("--cutover-max-delta must be non-negative, got %d", m.CutoverMaxDelta)
+	}
+	if m.CutoverMaxBinlogBehind < 0 {
+		return fmt.Errorf("--cutover-max-binlog-behind must be non-negative, got %d", m.CutoverMaxBinlogBehind)
+	}
+	if m.CutoverReadyWindow < 0 {
+		return fmt.Errorf("--cutover-ready-window must be non-negative, got %s", m.CutoverReadyWindow)
+	}
+	if m.CutoverReadyTimeout < 0 {
+		return fmt.Errorf("--cutover-ready-timeout must be non-negative, got %s", m.CutoverReadyTimeout)
+	}
+	if _, err := parseReadyTimeoutAction(m.CutoverReadyTimeoutAction); err != nil {
+		return fmt.Errorf("--cutover-ready-timeout-action: %w", err)
+	}
+	if m.MaxCutoverLockTime < 0 {
+		return fmt.Errorf("--max-cutover-lock-time must be non-negative, got %s", m.MaxCutoverLockTime)
+	}
+	if m.ChecksumDiffRedact && m.ChecksumDiffFile == "" {
+		return errors.New("--checksum-diff-redact requires --checksum-diff-file")
+	}
+	if m.ReverseReplicationDuration < 0 {
+		return fmt.Errorf("--reverse-replication-duration must be non-negative, got %s", m.ReverseReplicationDuration)
+	}
+	if m.ReverseReplicationDuration > 0 && !m.SkipDropAfterCutover {
+		return errors.New("--reverse-replication-duration requires --skip-drop-after-cutover")
+	}
+	if m.Rollback && (m.Statement != "" || m.Table != "" || m.Alter != "") {
+		return errors.New("--rollback can not be used with --statement, --table or --alter")
+	}
 	return nil
 }
 
 func (m *Migration) Run() error {
+	if m.Rollback {
+		return m.rollback(context.TODO())
+	}
 	migration, err := NewRunner(m)
 	if err != nil {
 		return err
@@ -135,6 +256,9 @@ func (m *Migration) normalizeOptions() (stmts []*statement.AbstractStatement, er
 	if m.ChecksumYieldTimeout == 0 {
 		m.ChecksumYieldTimeout = checksum.DefaultYieldTimeout
 	}
+	if m.ThrottlerApp == "" {
+		m.ThrottlerApp = "spirit"
+	}
 
 	if err := m.normalizeConnectionOptions(); err != nil {
 		return nil, err
@@ -3,8 +3,11 @@ package migration
 import (
 	"fmt"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -115,6 +118,110 @@ func TestE2ENullAlterWithReplicas(t *testing.T) {
 	require.NoError(t, m.Run())
 }
 
+func TestE2ENullAlterWithHealthThrottlers(t *testing.T) {
+	t.Parallel()
+	testutils.NewTestTable(t, "healththrottletest", `CREATE TABLE healththrottletest (
+		id int(11) NOT NULL AUTO_INCREMENT,
+		name varchar(255) NOT NULL,
+		PRIMARY KEY (id)
+	)`)
+	m := NewTestMigration(t, WithTable("healththrottletest"), WithAlter("ENGINE=InnoDB"),
+		func(m *Migration) {
+			m.MaxThreadsRunning = 10000
+			m.MaxHistoryListLength = 1 << 40
+			m.MaxCheckpointAge = 1 << 40
+		})
+	require.NoError(t, m.Run())
+}
+
+func TestValidateHealthThrottlers(t *testing.T) {
+	m := &Migration{MaxThreadsRunning: -1}
+	require.ErrorContains(t, m.Validate(), "--max-threads-running must be non-negative")
+	m = &Migration{MaxHistoryListLength: -1}
+	require.ErrorContains(t, m.Validate(), "--max-history-list-length must be non-negative")
+	m = &Migration{MaxCheckpointAge: -1}
+	require.ErrorContains(t, m.Validate(), "--max-checkpoint-age must be non-negative")
+	m = &Migration{MaxThreadsRunning: 100, MaxHistoryListLength: 1000000, MaxCheckpointAge: 1 << 30}
+	require.NoError(t, m.Validate())
+}
+
+func TestValidateMaxConcurrentTables(t *testing.T) {
+	m := &Migration{MaxConcurrentTables: -1}
+	require.ErrorContains(t, m.Validate(), "--max-concurrent-tables must be non-negative")
+	m = &Migration{MaxConcurrentTables: 2, TablePriorities: map[string]int{"t1": 10}}
+	require.NoError(t, m.Validate())
+}
+
+func TestValidateChecksumAlgorithm(t *testing.T) {
+	m := &Migration{ChecksumAlgorithm: "sha1"}
+	require.ErrorContains(t, m.Validate(), "--checksum-algorithm: unknown checksum algorithm")
+	for _, algorithm := range []string{"", "crc32", "md5", "sha256"} {
+		m = &Migration{ChecksumAlgorithm: algorithm}
+		require.NoError(t, m.Validate())
+	}
+}
+
+func TestValidateChecksumDiffFile(t *testing.T) {
+	m := &Migration{ChecksumDiffRedact: true}
+	require.ErrorContains(t, m.Validate(), "--checksum-diff-redact requires --checksum-diff-file")
+	m = &Migration{ChecksumDiffFile: "diff.jsonl", ChecksumDiffRedact: true}
+	require.NoError(t, m.Validate())
+}
+
+func TestValidateCutoverCoordinator(t *testing.T) {
+	m := &Migration{ProxySQLAdminDSN: "admin:admin@tcp(proxysql:6032)/"}
+	require.ErrorContains(t, m.Validate(), "--proxysql-admin-dsn and --proxysql-hostgroup must be used together")
+	m = &Migration{CutoverResumeURL: "http://proxy/resume"}
+	require.ErrorContains(t, m.Validate(), "--cutover-pause-url and --cutover-resume-url must be used together")
+	m = &Migration{
+		ProxySQLAdminDSN:   "admin:admin@tcp(proxysql:6032)/",
+		ProxySQLHostgroups: []int{10},
+		CutoverPauseURL:    "http://proxy/pause",
+		CutoverResumeURL:   "http://proxy/resume",
+	}
+	require.ErrorContains(t, m.Validate(), "mutually exclusive")
+	m = &Migration{CutoverPauseTimeout: -time.Second}
+	require.ErrorContains(t, m.Validate(), "--cutover-pause-timeout must be non-negative")
+	m = &Migration{ProxySQLAdminDSN: "admin:admin@tcp(proxysql:6032)/", ProxySQLHostgroups: []int{10}}
+	require.NoError(t, m.Validate())
+}
+
+func TestValidateMaxCutoverLockTime(t *testing.T) {
+	m := &Migration{MaxCutoverLockTime: -time.Second}
+	require.ErrorContains(t, m.Validate(), "--max-cutover-lock-time must be non-negative")
+	m = &Migration{MaxCutoverLockTime: 2 * time.Second}
+	require.NoError(t, m.Validate())
+}
+
+func TestE2ENullAlterWithHTTPThrottler(t *testing.T) {
+	t.Parallel()
+	var checks atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checks.Add(1)
+		if r.URL.Query().Get("app") != "spirit" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	testutils.NewTestTable(t, "httpthrottletest", `CREATE TABLE httpthrottletest (
+		id int(11) NOT NULL AUTO_INCREMENT,
+		name varchar(255) NOT NULL,
+		PRIMARY KEY (id)
+	)`)
+	m := NewTestMigration(t, WithTable("httpthrottletest"), WithAlter("ENGINE=InnoDB"),
+		func(m *Migration) {
+			m.ThrottlerURL = srv.URL + "/throttler/check"
+		})
+	require.NoError(t, m.Run())
+	require.Positive(t, checks.Load())
+
+	m = &Migration{ThrottlerCheckInterval: -time.Second}
+	require.ErrorContains(t, m.Validate(), "--throttler-check-interval must be non-negative")
+}
+
 // TestRenameInMySQL80 tests that even though renames are not supported,
 // if the version is 8.0 it will apply the instant operation before
 // the rename check applies. It's only when it needs to actually migrate
@@ -0,0 +1,383 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/block/spirit/pkg/applier"
+	"github.com/block/spirit/pkg/coordinator"
+	"github.com/block/spirit/pkg/dbconn"
+	"github.com/block/spirit/pkg/dbconn/sqlescape"
+	"github.com/block/spirit/pkg/repl"
+	"github.com/block/spirit/pkg/status"
+	"github.com/block/spirit/pkg/table"
+	"github.com/block/spirit/pkg/utils"
+	gomysql "github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-sql-driver/mysql"
+)
+
+// These are really consts, but set to var for testing.
+var (
+	// rollbackTableName is created by --rollback to ask a migration which
+	// is replicating changes back to the old tables to swap them back. The
+	// migration drops it once the rollback is complete, or stores the
+	// reason in its error column if the rollback fails.
+	rollbackTableName = "_spirit_rollback"
+	rollbackWaitLimit = 10 * time.Minute
+)
+
+// errNoSuchTable is the MySQL error number for a table which does not exist.
+const errNoSuchTable = 1146
+
+// reverseTable is a table which has been cut over, and is being kept
+// in sync with the old table it replaced.
+type reverseTable struct {
+	change   *change
+	current  *table.TableInfo // the original name, which now has the new schema
+	oldTable *table.TableInfo
+}
+
+// prepareReverseReplication is called before the copy when
+// --reverse-replication-duration is set, so that a migration which can not
+// be rolled back fails before the cutover. The rollback table is per
+// database, so the returned lock makes sure that only one migration in the
+// database can act on it. The caller must close the lock.
+func (r *Runner) prepareReverseReplication(ctx context.Context) (*dbconn.MetadataLock, error) {
+	schema := r.changes[0].table.SchemaName
+	lock, err := dbconn.NewMetadataLock(ctx, r.dsn(), []*table.TableInfo{table.NewTableInfo(r.db, schema, rollbackTableName)}, r.dbConfig, r.logger)
+	if err != nil {
+		return nil, fmt.Errorf("another migration in %s is already using --reverse-replication-duration: %w", schema, err)
+	}
+	if err := r.checkReverseReplication(ctx); err != nil {
+		utils.CloseAndLog(lock)
+		return nil, err
+	}
+	return lock, nil
+}
+
+// checkReverseReplication checks that changes to the new tables can be
+// replicated back to the old tables. Columns which the ALTER drops are not
+// in the new tables, so changes are applied with INSERT ... ON DUPLICATE
+// KEY UPDATE of the remaining columns, which keeps their values. That is
+// only safe without unique indexes other than the primary key, and rows
+// inserted after the cutover need a value for each dropped column.
+func (r *Runner) checkReverseReplication(ctx context.Context) error {
+	schema := r.changes[0].table.SchemaName
+	exists, err := r.tableExists(ctx, rollbackTableName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("the rollback table %s.%s already exists, and would roll back this migration as soon as it is cut over; drop it first", schema, rollbackTableName)
+	}
+	var dropsColumns bool
+	for _, change := range r.changes {
+		dropped := droppedColumns(reverseColumnMapping(change, change.newTable, change.table))
+		for _, col := range dropped {
+			var n int
+			if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM INFORMATION_SCHEMA.COLUMNS
+				WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND COLUMN_NAME = ?
+				AND IS_NULLABLE = 'NO' AND COLUMN_DEFAULT IS NULL AND EXTRA NOT LIKE '%auto_increment%'`,
+				change.table.SchemaName, change.table.TableName, col).Scan(&n); err != nil {
+				return err
+			}
+			if n > 0 {
+				return fmt.Errorf("--reverse-replication-duration can not be used: the ALTER drops column %q of table %s, which is NOT NULL without a default, so rows inserted after the cutover could not be replicated back", col, change.table.TableName)
+			}
+		}
+		dropsColumns = dropsColumns || len(dropped) > 0
+	}
+	if !dropsColumns {
+		return nil
+	}
+	for _, change := range r.changes {
+		var index sql.NullString
+		err := r.db.QueryRowContext(ctx, `SELECT MIN(INDEX_NAME) FROM INFORMATION_SCHEMA.STATISTICS
+			WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND NON_UNIQUE = 0 AND INDEX_NAME != 'PRIMARY'`,
+			change.table.SchemaName, change.table.TableName).Scan(&index)
+		if err != nil {
+			return err
+		}
+		if index.Valid {
+			return fmt.Errorf("--reverse-replication-duration can not be used: the ALTER drops columns, so changes are replicated back with INSERT ... ON DUPLICATE KEY UPDATE, which is not safe with the unique index %q of table %s", index.String, change.table.TableName)
+		}
+	}
+	return nil
+}
+
+// reverseColumnMapping maps the columns of the table with the new schema
+// back to the table with the old schema. Renames are old→new, so they are
+// inverted.
+func reverseColumnMapping(change *change, current, oldTable *table.TableInfo) *table.ColumnMapping {
+	renames := make(map[string]string)
+	for oldName, newName := range change.stmt.ColumnRenameMap() {
+		renames[newName] = oldName
+	}
+	return table.NewColumnMapping(current, oldTable, renames)
+}
+
+// droppedColumns returns the columns of the old table which are not in
+// the mapping, because the ALTER dropped them.
+func droppedColumns(mapping *table.ColumnMapping) []string {
+	_, mapped := mapping.ColumnsSlice()
+	var dropped []string
+	for _, col := range mapping.TargetTable().NonGeneratedColumns {
+		if !slices.Contains(mapped, col) {
+			dropped = append(dropped, col)
+		}
+	}
+	return dropped
+}
+
+// reverseReplicate keeps the old tables in sync with the new tables for
+// --reverse-replication-duration after the cutover. Changes are read from
+// pos, which was captured under the cutover lock, and applied to the old
+// tables with the column mapping inverted. If the rollback table is
+// created while it runs, the tables are swapped back with a second
+// cutover, and the tables with the new schema are renamed to _<table>_new.
+func (r *Runner) reverseReplicate(ctx context.Context, pos gomysql.Position, coord coordinator.Coordinator) error {
+	if pos.Name == "" {
+		return errors.New("the binlog position after the cutover is unknown")
+	}
+	// The forward subscriptions are keyed on the original table names,
+	// which now have the new schema, so the client is closed before
+	// anything is applied to the wrong table.
+	r.replClient.Close()
+
+	tables := make([]reverseTable, 0, len(r.changes))
+	mappings := make([]*table.ColumnMapping, 0, len(r.changes))
+	var dropsColumns bool
+	for _, change := range r.changes {
+		rt := reverseTable{
+			change:   change,
+			current:  table.NewTableInfo(r.db, change.table.SchemaName, change.table.TableName),
+			oldTable: table.NewTableInfo(r.db, change.table.SchemaName, change.oldTableName()),
+		}
+		if err := rt.current.SetInfo(ctx); err != nil {
+			return err
+		}
+		if err := rt.oldTable.SetInfo(ctx); err != nil {
+			return err
+		}
+		mapping := reverseColumnMapping(change, rt.current, rt.oldTable)
+		dropsColumns = dropsColumns || len(droppedColumns(mapping)) > 0
+		tables = append(tables, rt)
+		mappings = append(mappings, mapping)
+	}
+
+	// If the ALTER dropped columns, REPLACE would reset them in the old
+	// tables, so only the mapped columns are updated. This was checked
+	// to be safe by checkReverseReplication.
+	appl, err := applier.NewSingleTargetApplier(
+		applier.Target{DB: r.db},
+		&applier.ApplierConfig{
+			Logger:              r.logger,
+			DBConfig:            r.dbConfig,
+			Threads:             r.migration.Threads,
+			KeepUnmappedColumns: dropsColumns,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create applier: %w", err)
+	}
+	reverseCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	replConfig := repl.NewClientDefaultConfig()
+	replConfig.Logger = r.logger
+	replConfig.DBConfig = r.dbConfig
+	replConfig.CancelFunc = func() bool {
+		cancel()
+		return true
+	}
+	feed := repl.NewClient(r.db, r.migration.Host, r.migration.Username, *r.migration.Password, appl, replConfig)
+	defer feed.Close()
+
+	for i, rt := range tables {
+		chunker, err := table.NewChunker(rt.current, table.ChunkerConfig{
+			NewTable:      rt.oldTable,
+			Logger:        r.logger,
+			ColumnMapping: mappings[i],
+		})
+		if err != nil {
+			return err
+		}
+		if err := feed.AddSubscription(rt.current, rt.oldTable, chunker); err != nil {
+			return err
+		}
+	}
+	feed.SetFlushedPos(pos)
+	if err := feed.Run(reverseCtx); err != nil {
+		return err
+	}
+	go feed.StartPeriodicFlush(reverseCtx, repl.DefaultFlushInterval)
+
+	r.logger.Warn("replicating changes back to the old tables; create the rollback table to roll back",
+		"rollback-table", rollbackTableName,
+		"duration", r.migration.ReverseReplicationDuration,
+	)
+	timer := time.NewTimer(r.migration.ReverseReplicationDuration)
+	defer timer.Stop()
+	ticker := time.NewTicker(sentinelCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-reverseCtx.Done():
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return errors.New("a table changed during reverse replication")
+		case <-timer.C:
+			feed.StopPeriodicFlush()
+			if err := feed.Flush(reverseCtx); err != nil {
+				return err
+			}
+			r.logger.Warn("reverse replication complete; the old tables are no longer kept in sync")
+			return nil
+		case <-ticker.C:
+			requested, err := r.tableExists(reverseCtx, rollbackTableName)
+			if err != nil {
+				return err
+			}
+			if requested {
+				feed.StopPeriodicFlush()
+				if err := r.rollbackCutover(ctx, feed, coord, tables); err != nil {
+					r.recordRollbackFailure(ctx, err)
+					return err
+				}
+				return nil
+			}
+		}
+	}
+}
+
+// rollbackCutover swaps the old tables back under a lock, in the same way
+// as the cutover. The tables with the new schema are renamed to their
+// _<table>_new names.
+func (r *Runner) rollbackCutover(ctx context.Context, feed *repl.Client, coord coordinator.Coordinator, tables []reverseTable) error {
+	r.logger.Warn("rollback requested; swapping the old tables back")
+	r.status.Set(status.CutOver)
+	cutoverCfg := make([]*cutoverConfig, 0, len(tables))
+	for _, rt := range tables {
+		// The _new table was dropped after the cutover, but make sure
+		// the name is free.
+		if err := rt.change.cleanup(ctx); err != nil {
+			return err
+		}
+		cutoverCfg = append(cutoverCfg, &cutoverConfig{
+			table:        rt.current,
+			newTable:     rt.oldTable,
+			oldTableName: utils.NewTableName(rt.current.TableName),
+			statement:    rt.change.stmt.Statement,
+		})
+	}
+	cutover, err := NewCutOver(r.db, cutoverCfg, feed, r.dbConfig, r.logger)
+	if err != nil {
+		return err
+	}
+	cutover.coordinator = coord
+	if err := cutover.Run(ctx); err != nil {
+		return fmt.Errorf("rollback failed: %w", err)
+	}
+	if err := dbconn.Exec(ctx, r.db, "DROP TABLE IF EXISTS %n.%n", r.changes[0].table.SchemaName, rollbackTableName); err != nil {
+		return err
+	}
+	r.logger.Warn("rollback complete")
+	return nil
+}
+
+// recordRollbackFailure stores the reason the rollback failed in the
+// rollback table, so that --rollback fails with it instead of waiting.
+// If the table was created by hand without an error column, the failure
+// can only be logged.
+func (r *Runner) recordRollbackFailure(ctx context.Context, rollbackErr error) {
+	if err := dbconn.Exec(ctx, r.db, "INSERT INTO %n.%n (id, error) VALUES (1, %?) ON DUPLICATE KEY UPDATE error = %?",
+		r.changes[0].table.SchemaName, rollbackTableName, rollbackErr.Error(), rollbackErr.Error()); err != nil {
+		r.logger.Error("could not record the rollback failure in the rollback table",
+			"rollback-table", rollbackTableName,
+			"error", err,
+		)
+	}
+}
+
+func (r *Runner) tableExists(ctx context.Context, tableName string) (bool, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?",
+		r.changes[0].table.SchemaName, tableName).Scan(&n)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// rollback asks the migration which is replicating changes back to the
+// old tables in --database to swap them back, and waits for it to finish.
+// If the migration fails to roll back, it returns the migration's error.
+// If no migration picks up the request, it is withdrawn.
+func (m *Migration) rollback(ctx context.Context) error {
+	if err := m.normalizeConnectionOptions(); err != nil {
+		return err
+	}
+	cfg := mysql.NewConfig()
+	cfg.User = m.Username
+	cfg.Passwd = *m.Password
+	cfg.Net = "tcp"
+	cfg.Addr = m.Host
+	cfg.DBName = m.Database
+	dbConfig := dbconn.NewDBConfig()
+	dbConfig.TLSMode = m.TLSMode
+	dbConfig.TLSCertificatePath = m.TLSCertificatePath
+	db, err := dbconn.New(cfg.FormatDSN(), dbConfig)
+	if err != nil {
+		return err
+	}
+	defer utils.CloseAndLog(db)
+
+	if err := dbconn.Exec(ctx, db, "CREATE TABLE %n.%n (id int NOT NULL PRIMARY KEY, error text)", m.Database, rollbackTableName); err != nil {
+		return fmt.Errorf("could not request rollback: %w", err)
+	}
+	timer := time.NewTimer(rollbackWaitLimit)
+	defer timer.Stop()
+	ticker := time.NewTicker(sentinelCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			var n int
+			if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?",
+				m.Database, rollbackTableName).Scan(&n); err != nil {
+				return err
+			}
+			if n == 0 {
+				return nil // the migration rolled back and dropped the table
+			}
+			var rollbackErr sql.NullString
+			err := db.QueryRowContext(ctx, sqlescape.MustEscapeSQL("SELECT error FROM %n.%n WHERE error IS NOT NULL LIMIT 1", m.Database, rollbackTableName)).Scan(&rollbackErr)
+			var myErr *mysql.MySQLError
+			if errors.Is(err, sql.ErrNoRows) || errors.As(err, &myErr) && myErr.Number == errNoSuchTable {
+				continue // still waiting, or rolled back since the check above
+			}
+			if err != nil {
+				return err
+			}
+			// The migration failed to roll back. Remove the request so that
+			// it can be retried.
+			if err := dbconn.Exec(ctx, db, "DROP TABLE IF EXISTS %n.%n", m.Database, rollbackTableName); err != nil {
+				return err
+			}
+			return fmt.Errorf("the migration in %s failed to roll back: %s", m.Database, rollbackErr.String)
+		case <-timer.C:
+			// Withdraw the request, so that it does not roll back a later
+			// migration.
+			if err := dbconn.Exec(ctx, db, "DROP TABLE IF EXISTS %n.%n", m.Database, rollbackTableName); err != nil {
+				return err
+			}
+			return fmt.Errorf("timed out waiting for a migration in %s to roll back; it must be running with --reverse-replication-duration", m.Database)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package migration
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/block/spirit/pkg/status"
+	"github.com/block/spirit/pkg/testutils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateReverseReplication(t *testing.T) {
+	m := &Migration{ReverseReplicationDuration: -time.Second}
+	require.ErrorContains(t, m.Validate(), "--reverse-replication-duration must be non-negative")
+	m = &Migration{ReverseReplicationDuration: time.Minute}
+	require.ErrorContains(t, m.Validate(), "--reverse-replication-duration requires --skip-drop-after-cutover")
+	m = &Migration{Rollback: true, Table: "t1"}
+	require.ErrorContains(t, m.Validate(), "--rollback can not be used with --statement, --table or --alter")
+	m = &Migration{ReverseReplicationDuration: time.Minute, SkipDropAfterCutover: true}
+	require.NoError(t, m.Validate())
+	m = &Migration{Rollback: true, Database: "test"}
+	require.NoError(t, m.Validate())
+}
+
+func TestReverseReplicationRollback(t *testing.T) {
+	t.Parallel()
+	dbName, db := testutils.CreateUniqueTestDatabase(t)
+	testutils.RunSQLInDatabase(t, dbName, `CREATE TABLE revt1 (id int NOT NULL PRIMARY KEY, a varchar(10), b int)`)
+	testutils.RunSQLInDatabase(t, dbName, `INSERT INTO revt1 VALUES (1, 'a', 1), (2, 'b', 2), (3, 'c', 3)`)
+
+	m := NewTestRunner(t, "revt1", "RENAME COLUMN a TO c, ADD COLUMN d int, ENGINE=InnoDB",
+		WithDBName(dbName),
+		WithSkipDropAfterCutover())
+	m.migration.ReverseReplicationDuration = time.Minute
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Run(t.Context())
+	}()
+	waitForStatus(t, m, status.CutOver)
+	require.Eventually(t, func() bool {
+		var n int
+		require.NoError(t, db.QueryRowContext(t.Context(), "SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = ? AND table_name = 'revt1' AND column_name = 'd'", dbName).Scan(&n))
+		return n == 1
+	}, 30*time.Second, 10*time.Millisecond)
+
+	// Changes made after the cutover are replicated back to the old table.
+	testutils.RunSQLInDatabase(t, dbName, `INSERT INTO revt1 (id, c, b, d) VALUES (4, 'd', 4, 4)`)
+	testutils.RunSQLInDatabase(t, dbName, `UPDATE revt1 SET c = 'x' WHERE id = 1`)
+	testutils.RunSQLInDatabase(t, dbName, `DELETE FROM revt1 WHERE id = 2`)
+
+	rollback := newTestMigration(t, WithDBName(dbName))
+	rollback.Rollback = true
+	require.NoError(t, rollback.Run())
+	require.NoError(t, <-done)
+	require.NoError(t, m.Close())
+
+	// The original table is back, with the changes.
+	var tables string
+	require.NoError(t, db.QueryRowContext(t.Context(),
+		"SELECT GROUP_CONCAT(table_name ORDER BY table_name) FROM information_schema.tables WHERE table_schema = ? AND table_name LIKE '%revt1%'", dbName).Scan(&tables))
+	require.Equal(t, "_revt1_new,revt1", tables)
+	var rows string
+	require.NoError(t, db.QueryRowContext(t.Context(),
+		fmt.Sprintf("SELECT GROUP_CONCAT(CONCAT_WS(':', id, a, b) ORDER BY id) FROM `%s`.revt1", dbName)).Scan(&rows))
+	require.Equal(t, "1:x:1,3:c:3,4:d:4", rows)
+}
+
+func TestReverseReplicationRollbackDroppedColumn(t *testing.T) {
+	t.Parallel()
+	dbName, db := testutils.CreateUniqueTestDatabase(t)
+	testutils.RunSQLInDatabase(t, dbName, `CREATE TABLE revt2 (id int NOT NULL PRIMARY KEY, a varchar(10), b int)`)
+	testutils.RunSQLInDatabase(t, dbName, `INSERT INTO revt2 VALUES (1, 'a', 1), (2, 'b', 2)`)
+
+	m := NewTestRunner(t, "revt2", "DROP COLUMN b, ENGINE=InnoDB",
+		WithDBName(dbName),
+		WithSkipDropAfterCutover())
+	m.migration.ReverseReplicationDuration = time.Minute
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Run(t.Context())
+	}()
+	waitForStatus(t, m, status.CutOver)
+	require.Eventually(t, func() bool {
+		var n int
+		require.NoError(t, db.QueryRowContext(t.Context(), "SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = ? AND table_name = 'revt2' AND column_name = 'b'", dbName).Scan(&n))
+		return n == 0
+	}, 30*time.Second, 10*time.Millisecond)
+
+	// The dropped column keeps its value for the updated row, and is
+	// NULL for the inserted one.
+	testutils.RunSQLInDatabase(t, dbName, `UPDATE revt2 SET a = 'x' WHERE id = 1`)
+	testutils.RunSQLInDatabase(t, dbName, `INSERT INTO revt2 (id, a) VALUES (3, 'c')`)
+
+	rollback := newTestMigration(t, WithDBName(dbName))
+	rollback.Rollback = true
+	require.NoError(t, rollback.Run())
+	require.NoError(t, <-done)
+	require.NoError(t, m.Close())
+
+	var rows string
+	require.NoError(t, db.QueryRowContext(t.Context(),
+		fmt.Sprintf("SELECT GROUP_CONCAT(CONCAT_WS(':', id, a, IFNULL(b, 'NULL')) ORDER BY id) FROM `%s`.revt2", dbName)).Scan(&rows))
+	require.Equal(t, "1:x:1,2:b:2,3:c:NULL", rows)
+}
+
+func TestReverseReplicationDroppedColumnUniqueIndex(t *testing.T) {
+	t.Parallel()
+	dbName, _ := testutils.CreateUniqueTestDatabase(t)
+	testutils.RunSQLInDatabase(t, dbName, `CREATE TABLE revt3 (id int NOT NULL PRIMARY KEY, a varchar(10), b int, UNIQUE KEY (a))`)
+
+	m := NewTestRunner(t, "revt3", "DROP COLUMN b, ENGINE=InnoDB",
+		WithDBName(dbName),
+		WithSkipDropAfterCutover())
+	m.migration.ReverseReplicationDuration = time.Minute
+	err := m.Run(t.Context())
+	require.ErrorContains(t, err, "not safe with the unique index")
+	require.NoError(t, m.Close())
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/block/spirit/pkg/applier"
 	"github.com/block/spirit/pkg/buildinfo"
 	"github.com/block/spirit/pkg/checksum"
+	"github.com/block/spirit/pkg/coordinator"
 	"github.com/block/spirit/pkg/copier"
 	"github.com/block/spirit/pkg/dbconn"
 	"github.com/block/spirit/pkg/metrics"
@@ -60,8 +61,10 @@ type Runner struct {
 	copyChunker  table.Chunker // the chunker for copying
 	copyDuration time.Duration // how long the copy took
 
-	checker         checksum.Checker
-	checksumChunker table.Chunker // the chunker for checksum
+	checker           checksum.Checker
+	checksumChunker   table.Chunker        // the chunker for checksum
+	checksumThrottler throttler.Throttler  // the server health throttlers; nil if none
+	diffReport        *checksum.DiffReport // optional; from --checksum-diff-file
 
 	chunkerMu sync.RWMutex // protects copyChunker and checksumChunker from concurrent access
 
@@ -105,6 +108,9 @@ type Runner struct {
 
 	// MetricsSink
 	metricsSink metrics.Sink
+
+	// cutoverHooks are the Go callbacks added with AddCutoverHook.
+	cutoverHooks map[HookPoint][]CutoverHook
 }
 
 var _ status.Task = (*Runner)(nil)
@@ -140,6 +146,42 @@ func (r *Runner) SetLogger(logger *slog.Logger) {
 	r.logger = logger
 }
 
+// newCoordinator returns the coordinator which pauses traffic during the
+// cutover, or nil if none is configured.
+func (r *Runner) newCoordinator() (coordinator.Coordinator, error) {
+	switch {
+	case r.migration.ProxySQLAdminDSN != "":
+		return coordinator.NewProxySQL(r.migration.ProxySQLAdminDSN, r.migration.ProxySQLHostgroups, r.migration.CutoverPauseTimeout, r.logger)
+	case r.migration.CutoverPauseURL != "":
+		return coordinator.NewHTTP(r.migration.CutoverPauseURL, r.migration.CutoverResumeURL, r.migration.CutoverPauseTimeout, r.logger)
+	default:
+		return nil, nil
+	}
+}
+
+// AddCutoverHook adds a callback which runs at point during the cutover.
+// Hooks for the same point run in the order they were added, and before
+// any --hook-cmd for that point.
+func (r *Runner) AddCutoverHook(point HookPoint, hook CutoverHook) {
+	if r.cutoverHooks == nil {
+		r.cutoverHooks = make(map[HookPoint][]CutoverHook)
+	}
+	r.cutoverHooks[point] = append(r.cutoverHooks[point], hook)
+}
+
+// hooks returns the Go callbacks followed by the --hook-cmd commands
+// for each point.
+func (r *Runner) hooks() map[HookPoint][]CutoverHook {
+	hooks := make(map[HookPoint][]CutoverHook, len(HookPoints))
+	for _, point := range HookPoints {
+		hooks[point] = append(hooks[point], r.cutoverHooks[point]...)
+		if command, ok := r.migration.HookCmds[string(point)]; ok {
+			hooks[point] = append(hooks[point], commandHook(command))
+		}
+	}
+	return hooks
+}
+
 // attemptMySQLDDL tries to perform the DDL using MySQL's built-in
 // either with INSTANT or known safe INPLACE operations.
 func (r *Runner) attemptMySQLDDL(ctx context.Context) error {
@@ -173,6 +215,7 @@ func (r *Runner) Run(ctx context.Context) error {
 	}
 	r.dbConfig.InterpolateParams = r.migration.InterpolateParams
 	r.dbConfig.ForceKill = !r.migration.SkipForceKill
+	r.dbConfig.MaxLockTime = r.migration.MaxCutoverLockTime
 	// Map TLS configuration from migration to dbConfig
 	r.dbConfig.TLSMode = r.migration.TLSMode
 	r.dbConfig.TLSCertificatePath = r.migration.TLSCertificatePath
@@ -214,6 +257,14 @@ func (r *Runner) Run(ctx context.Context) error {
 		}
 	}
 
+	// The diff report is shared by the initial and continuous checksums.
+	// It will be closed in r.Close()
+	if r.migration.ChecksumDiffFile != "" {
+		if r.diffReport, err = checksum.NewDiffReport(r.migration.ChecksumDiffFile, r.migration.ChecksumDiffRedact); err != nil {
+			return err
+		}
+	}
+
 	if len(r.changes) == 1 {
 		// We only allow non-ALTERs (i.e. CREATE TABLE, DROP TABLE, RENAME TABLE)
 		// in single table mode.
@@ -281,6 +332,16 @@ func (r *Runner) Run(ctx context.Context) error {
 		return err
 	}
 
+	// Check that the migration can be rolled back before copying, and
+	// hold the rollback table for this database until we finish.
+	if r.migration.ReverseReplicationDuration > 0 {
+		rollbackLock, err := r.prepareReverseReplication(ctx)
+		if err != nil {
+			return err
+		}
+		defer utils.CloseAndLog(rollbackLock)
+	}
+
 	// Perform the main copy rows task. This is where the majority
 	// of migrations usually spend time. It is not strictly necessary,
 	// but we always recopy the last-bit, even if we are resuming
@@ -336,6 +397,7 @@ func (r *Runner) Run(ctx context.Context) error {
 			table:          change.table,
 			newTable:       change.newTable,
 			oldTableName:   change.oldTableName(),
+			statement:      change.stmt.Statement,
 			useTestCutover: r.migration.useTestCutover, // indicates we want the test cutover
 		})
 	}
@@ -343,6 +405,25 @@ func (r *Runner) Run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	cutover.hooks = r.hooks()
+	cutover.metricsSink = r.metricsSink
+	cutover.readiness = cutoverReadiness{
+		maxDelta:        r.migration.CutoverMaxDelta,
+		maxBinlogBehind: r.migration.CutoverMaxBinlogBehind,
+		window:          r.migration.CutoverReadyWindow,
+		timeout:         r.migration.CutoverReadyTimeout,
+	}
+	if cutover.readiness.timeoutAction, err = parseReadyTimeoutAction(r.migration.CutoverReadyTimeoutAction); err != nil {
+		return err
+	}
+	cutover.hookTimeout = r.migration.HookTimeout
+	cutover.capturePosition = r.migration.ReverseReplicationDuration > 0
+	if cutover.coordinator, err = r.newCoordinator(); err != nil {
+		return err
+	}
+	if cutover.coordinator != nil {
+		defer utils.CloseAndLog(cutover.coordinator)
+	}
 	// Drop the _old table if it exists. This ensures
 	// that the rename will succeed (although there is a brief race)
 	for _, change := range r.changes {
@@ -393,6 +474,15 @@ func (r *Runner) Run(ctx context.Context) error {
 			return err
 		}
 	}
+	if r.migration.ReverseReplicationDuration > 0 {
+		// The migration is already successful, so like dropping
+		// the old table, a failure here is only logged.
+		if err := r.reverseReplicate(ctx, cutover.renamedPos, cutover.coordinator); err != nil {
+			r.logger.Error("reverse replication failed; the old tables are no longer kept in sync",
+				"error", err,
+			)
+		}
+	}
 	return nil
 }
 
@@ -607,6 +697,8 @@ func (r *Runner) setupCopierCheckerAndReplClient(ctx context.Context) error {
 		FixDifferences:  true,
 		MaxRetries:      3,
 		YieldTimeout:    r.migration.ChecksumYieldTimeout,
+		Algorithm:       checksum.Algorithm(r.migration.ChecksumAlgorithm),
+		DiffReport:      r.diffReport,
 	})
 
 	return err
@@ -679,7 +771,12 @@ func (r *Runner) closeReplicas() error {
 //   - one replication throttler per --replica-dsn (slowest wins)
 //   - a commit-latency throttler if the source is detected as Aurora and
 //     --max-commit-latency is positive (issue #468)
+//   - Threads_running, history list length and checkpoint age throttlers
+//     on the source, for each of the corresponding --max-* options that
+//     is positive
+//   - an HTTP throttler if --throttler-url is set
 //
+// The server health throttlers also pace the checksum.
 // Multiple replica DSNs can be specified as a comma-separated list.
 // This is common logic shared between resume and new migration paths.
 func (r *Runner) setupThrottler(ctx context.Context) error {
@@ -721,6 +818,30 @@ func (r *Runner) setupThrottler(ctx context.Context) error {
 		}
 	}
 
+	healthThrottlers, err := r.buildHealthThrottlers()
+	if err != nil {
+		_ = r.closeReplicas()
+		return err
+	}
+	throttlers = append(throttlers, healthThrottlers...)
+	if len(healthThrottlers) > 0 {
+		// The checksum is paced by the server health throttlers only:
+		// replica lag and the HTTP throttler are about write load, which
+		// the checksum does not add (apart from repairing chunks). They
+		// are opened and closed as part of r.throttler.
+		r.checksumThrottler = throttler.NewMultiThrottler(healthThrottlers...)
+		r.checker.SetThrottler(r.checksumThrottler)
+	}
+
+	if r.migration.ThrottlerURL != "" {
+		httpThrottler, err := throttler.NewHTTPThrottler(r.migration.ThrottlerURL, r.migration.ThrottlerApp, r.migration.ThrottlerCheckInterval, r.logger)
+		if err != nil {
+			_ = r.closeReplicas()
+			return fmt.Errorf("could not create http throttler: %w", err)
+		}
+		throttlers = append(throttlers, httpThrottler)
+	}
+
 	if len(throttlers) == 0 {
 		return nil // use default Noop throttler
 	}
@@ -738,6 +859,35 @@ func (r *Runner) setupThrottler(ctx context.Context) error {
 	return nil
 }
 
+// buildHealthThrottlers returns the throttlers which watch the health of the
+// source server itself: Threads_running, history list length and checkpoint
+// age. Each one is only created if its threshold is positive.
+func (r *Runner) buildHealthThrottlers() ([]throttler.Throttler, error) {
+	var throttlers []throttler.Throttler
+	if r.migration.MaxThreadsRunning > 0 {
+		t, err := throttler.NewThreadsRunningThrottler(r.db, r.migration.MaxThreadsRunning, r.logger)
+		if err != nil {
+			return nil, fmt.Errorf("could not create threads-running throttler: %w", err)
+		}
+		throttlers = append(throttlers, t)
+	}
+	if r.migration.MaxHistoryListLength > 0 {
+		t, err := throttler.NewHistoryListLengthThrottler(r.db, r.migration.MaxHistoryListLength, r.logger)
+		if err != nil {
+			return nil, fmt.Errorf("could not create history-list-length throttler: %w", err)
+		}
+		throttlers = append(throttlers, t)
+	}
+	if r.migration.MaxCheckpointAge > 0 {
+		t, err := throttler.NewCheckpointAgeThrottler(r.db, r.migration.MaxCheckpointAge, r.logger)
+		if err != nil {
+			return nil, fmt.Errorf("could not create checkpoint-age throttler: %w", err)
+		}
+		throttlers = append(throttlers, t)
+	}
+	return throttlers, nil
+}
+
 // buildReplicaThrottlers opens the configured replica DSN(s) and returns a
 // throttler per replica. Replica connections are tracked on the runner so
 // they get closed alongside the main DB.
@@ -1033,6 +1183,11 @@ func (r *Runner) Close() error {
 			errs = append(errs, err)
 		}
 	}
+	if r.diffReport != nil {
+		if err := r.diffReport.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	if err := r.closeReplicas(); err != nil {
 		errs = append(errs, err)
 	}
@@ -1218,6 +1373,17 @@ func (r *Runner) binlogFileExists(ctx context.Context, binlogName string) (bool,
 // It does not open them yet, and we need to either
 // call Open() or OpenAtWatermark() later.
 func (r *Runner) initChunkers() error {
+	copyConfig := table.MultiChunkerConfig{
+		Priorities:          r.migration.TablePriorities,
+		MaxConcurrentTables: r.migration.MaxConcurrentTables,
+	}
+	tables := make([]*table.TableInfo, 0, len(r.changes))
+	for _, change := range r.changes {
+		tables = append(tables, change.table)
+	}
+	if err := copyConfig.Validate(tables); err != nil {
+		return fmt.Errorf("invalid --table-priority: %w", err)
+	}
 	copyChunkers := make([]table.Chunker, 0, len(r.changes))
 	checksumChunkers := make([]table.Chunker, 0, len(r.changes))
 	for _, change := range r.changes {
@@ -1250,7 +1416,7 @@ func (r *Runner) initChunkers() error {
 	// We can wrap it the multi-chunker regardless.
 	// It won't cause any harm.
 	r.chunkerMu.Lock()
-	r.copyChunker = table.NewMultiChunker(copyChunkers...)
+	r.copyChunker = table.NewMultiChunkerWithConfig(copyConfig, copyChunkers...)
 	r.checksumChunker = table.NewMultiChunker(checksumChunkers...)
 	r.chunkerMu.Unlock()
 	return nil
@@ -1587,6 +1753,9 @@ func (r *Runner) runContinuousChecksum(ctx context.Context) error {
 			// retry loop inside each iteration.
 			MaxRetries:   1,
 			YieldTimeout: r.migration.ChecksumYieldTimeout,
+			Algorithm:    checksum.Algorithm(r.migration.ChecksumAlgorithm),
+			DiffReport:   r.diffReport,
+			Throttler:    r.checksumThrottler,
 		},
 	)
 	if err != nil {
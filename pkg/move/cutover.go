@@ -85,18 +85,33 @@ func (c *CutOver) Run(ctx context.Context) error {
 }
 
 func (c *CutOver) algorithmCutover(ctx context.Context) error {
-	// Lock tables on ALL sources.
+	// Lock tables on ALL sources. With a maximum lock time, the deadline
+	// starts when the first lock is acquired, and covers acquiring the
+	// remaining locks and the final flush. If it passes, the connections
+	// holding the locks are killed so that writes are unblocked at once,
+	// and the attempt is retried. The cutover function and the renames
+	// are never interrupted.
+	lockCtx := ctx
 	var sourceLocks []*dbconn.TableLock
+	var stopKills []func() bool
 	for i, src := range c.sources {
-		lock, err := dbconn.NewTableLock(ctx, src.DB, src.Tables, c.dbConfig, c.logger)
+		lock, err := dbconn.NewTableLock(lockCtx, src.DB, src.Tables, c.dbConfig, c.logger)
 		if err != nil {
 			// Close any locks we already acquired.
 			for _, l := range sourceLocks {
 				utils.CloseAndLogWithContext(ctx, l)
 			}
-			return fmt.Errorf("failed to lock tables on source %d: %w", i, err)
+			return c.lockTimeError(lockCtx, fmt.Errorf("failed to lock tables on source %d: %w", i, err))
 		}
 		sourceLocks = append(sourceLocks, lock)
+		if c.dbConfig.MaxLockTime > 0 {
+			if i == 0 {
+				var cancelLock context.CancelFunc
+				lockCtx, cancelLock = context.WithTimeout(ctx, c.dbConfig.MaxLockTime)
+				defer cancelLock()
+			}
+			stopKills = append(stopKills, lock.KillOnDeadline(lockCtx))
+		}
 	}
 	defer func() {
 		for _, l := range sourceLocks {
@@ -106,10 +121,19 @@ func (c *CutOver) algorithmCutover(ctx context.Context) error {
 
 	// Flush ALL repl clients. No new changes will arrive because all sources are locked.
 	for i, src := range c.sources {
-		if err := src.ReplClient.Flush(ctx); err != nil {
-			return fmt.Errorf("failed to flush repl client for source %d: %w", i, err)
+		if err := src.ReplClient.Flush(lockCtx); err != nil {
+			return c.lockTimeError(lockCtx, fmt.Errorf("failed to flush repl client for source %d: %w", i, err))
 		}
 	}
+	var killed bool
+	for _, stop := range stopKills {
+		if !stop() {
+			killed = true
+		}
+	}
+	if killed {
+		return c.lockTimeError(lockCtx, lockCtx.Err())
+	}
 
 	// Check ALL changes flushed.
 	for i, src := range c.sources {
@@ -167,3 +191,12 @@ func (c *CutOver) algorithmCutover(ctx context.Context) error {
 	}
 	return nil
 }
+
+// lockTimeError annotates err if it was caused by the maximum lock time
+// passing while the locks were held.
+func (c *CutOver) lockTimeError(lockCtx context.Context, err error) error {
+	if c.dbConfig.MaxLockTime > 0 && errors.Is(lockCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w (%s), released the locks to retry: %w", dbconn.ErrMaxLockTime, c.dbConfig.MaxLockTime, err)
+	}
+	return err
+}
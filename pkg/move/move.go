@@ -10,13 +10,29 @@ import (
 )
 
 type Move struct {
-	SourceDSN             string        `name:"source-dsn" help:"Where to copy the tables from." default:"spirit:spirit@tcp(127.0.0.1:3306)/src"`
-	TargetDSN             string        `name:"target-dsn" help:"Where to copy the tables to." default:"spirit:spirit@tcp(127.0.0.1:3306)/dest"`
-	TargetChunkTime       time.Duration `name:"target-chunk-time" help:"How long each chunk should take to copy" default:"5s"`
-	Threads               int           `name:"threads" help:"How many chunks to copy in parallel" default:"2"`
-	WriteThreads          int           `name:"write-threads" help:"How many concurrent write threads to use per target" default:"2"`
-	CreateSentinel        bool          `name:"create-sentinel" help:"Create a sentinel table on the source database to block after table copy" default:"false"`
-	DeferSecondaryIndexes bool          `name:"defer-secondary-indexes" help:"Create target tables without secondary indexes, add them before cutover" default:"false"`
+	SourceDSN               string         `name:"source-dsn" help:"Where to copy the tables from." default:"spirit:spirit@tcp(127.0.0.1:3306)/src"`
+	TargetDSN               string         `name:"target-dsn" help:"Where to copy the tables to." default:"spirit:spirit@tcp(127.0.0.1:3306)/dest"`
+	TargetChunkTime         time.Duration  `name:"target-chunk-time" help:"How long each chunk should take to copy" default:"5s"`
+	Threads                 int            `name:"threads" help:"How many chunks to copy in parallel" default:"2"`
+	WriteThreads            int            `name:"write-threads" help:"How many concurrent write threads to use per target" default:"2"`
+	CreateSentinel          bool           `name:"create-sentinel" help:"Create a sentinel table on the source database to block after table copy" default:"false"`
+	DeferSecondaryIndexes   bool           `name:"defer-secondary-indexes" help:"Create target tables without secondary indexes, add them before cutover" default:"false"`
+	ConsistentSnapshot      bool           `name:"consistent-snapshot" help:"Seed the target from a consistent snapshot and start replication from its binlog position" default:"false"`
+	TargetReplicaDSN        string         `name:"target-replica-dsn" help:"DSN for a replica of the target used for lag checking" optional:""`
+	TargetReplicaMaxLag     time.Duration  `name:"target-replica-max-lag" help:"The maximum lag allowed on a target replica before the copy throttles" optional:"" default:"120s"`
+	TargetMaxThreadsRunning int64          `name:"target-max-threads-running" help:"Throttle when Threads_running on any target reaches this value (0 disables)" optional:"" default:"0"`
+	TargetMaxCommitLatency  time.Duration  `name:"target-max-commit-latency" help:"Throttle when average commit latency on any target exceeds this threshold (currently only auto-enabled on Aurora)" optional:"" default:"100ms"`
+	ThrottlerURL            string         `name:"throttler-url" help:"URL of an external throttling service to check before copying (200 proceeds, 429 throttles)" optional:""`
+	ThrottlerApp            string         `name:"throttler-app" help:"App name sent to the external throttling service" optional:"" default:"spirit"`
+	ThrottlerCheckInterval  time.Duration  `name:"throttler-check-interval" help:"How often to check the external throttling service" optional:"" default:"1s"`
+	TablePriorities         map[string]int `name:"table-priority" help:"Copy priority for a table as table=priority (repeatable); higher priorities are copied first" optional:""`
+	MaxConcurrentTables     int            `name:"max-concurrent-tables" help:"Maximum number of tables to copy at the same time (0 is unlimited)" optional:"" default:"0"`
+	ChecksumAlgorithm       string         `name:"checksum-algorithm" help:"Checksum function: crc32, md5 or sha256" optional:"" default:"crc32"`
+	ChecksumDiffFile        string         `name:"checksum-diff-file" help:"Write every row that differs in a mismatched checksum chunk to this file (JSON lines)" optional:""`
+	ChecksumDiffRedact      bool           `name:"checksum-diff-redact" help:"Replace column values in the --checksum-diff-file with a placeholder; primary keys are kept" optional:"" default:"false"`
+	MaxCutoverLockTime      time.Duration  `name:"max-cutover-lock-time" help:"Maximum time the source tables may be locked for the final flush at cutover before the locks are released and the cutover retried (0 is unlimited)" optional:"" default:"0s"`
+	Rollback                bool           `name:"rollback" help:"Reverse a completed move by renaming the source _old tables back" optional:"" default:"false"`
+	RollbackReplay          bool           `name:"rollback-replay" help:"With --rollback, first replay the changes made on the target since the cutover to the source" optional:"" default:"false"`
 
 	// SourceTables optionally specifies a list of tables to move.
 	// If empty, all tables in the source database will be moved.
@@ -43,6 +59,9 @@ func (m *Move) Run() error {
 		return err
 	}
 	defer utils.CloseAndLog(move)
+	if m.Rollback {
+		return move.Rollback(context.TODO())
+	}
 	if err := move.Run(context.TODO()); err != nil {
 		return err
 	}
@@ -0,0 +1,352 @@
+package move
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/block/spirit/pkg/applier"
+	"github.com/block/spirit/pkg/dbconn"
+	"github.com/block/spirit/pkg/repl"
+	"github.com/block/spirit/pkg/status"
+	"github.com/block/spirit/pkg/table"
+	"github.com/block/spirit/pkg/utils"
+	gomysql "github.com/go-mysql-org/go-mysql/mysql"
+)
+
+// cutoverTableName records a completed cutover on sources[0]: the tables
+// which were moved, and the target's binlog position at the cutover. It is
+// read by --rollback, and dropped once the move is rolled back.
+var cutoverTableName = "_spirit_cutover"
+
+// cutoverRecord is a row of the cutover table.
+type cutoverRecord struct {
+	tables []string
+	pos    gomysql.Position // empty if there is not exactly one target
+}
+
+// createCutoverTable creates the cutover table on sources[0]. It is
+// created before the cutover so that the lock is held for less time.
+func (r *Runner) createCutoverTable(ctx context.Context) error {
+	src0 := &r.sources[0]
+	if err := dbconn.Exec(ctx, src0.db, "DROP TABLE IF EXISTS %n.%n", src0.config.DBName, cutoverTableName); err != nil {
+		return err
+	}
+	return dbconn.Exec(ctx, src0.db, `CREATE TABLE %n.%n (
+	id int NOT NULL PRIMARY KEY,
+	table_names TEXT NOT NULL,
+	binlog_name VARCHAR(255),
+	binlog_pos INT UNSIGNED,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+		src0.config.DBName, cutoverTableName)
+}
+
+// recordCutover returns the function run by the cutover while the sources
+// are locked. It records the cutover, and then runs the caller's cutover
+// function. Since the caller's function has not moved traffic to the
+// target yet, every change after the recorded position was made through
+// the target.
+func (r *Runner) recordCutover() func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		tableNames := make([]string, 0, len(r.sourceTables))
+		for _, tbl := range r.sourceTables {
+			tableNames = append(tableNames, tbl.TableName)
+		}
+		tablesJSON, err := json.Marshal(tableNames)
+		if err != nil {
+			return err
+		}
+		var binlogName sql.NullString
+		var binlogPos sql.NullInt64
+		if len(r.targets) == 1 {
+			pos, err := currentBinlogPosition(ctx, r.targets[0].DB)
+			if err != nil {
+				return fmt.Errorf("could not read target binlog position: %w", err)
+			}
+			binlogName = sql.NullString{String: pos.Name, Valid: true}
+			binlogPos = sql.NullInt64{Int64: int64(pos.Pos), Valid: true}
+		}
+		src0 := &r.sources[0]
+		if err := dbconn.Exec(ctx, src0.db, "REPLACE INTO %n.%n (id, table_names, binlog_name, binlog_pos) VALUES (1, %?, %?, %?)",
+			src0.config.DBName, cutoverTableName, string(tablesJSON), binlogName, binlogPos); err != nil {
+			return fmt.Errorf("could not record cutover: %w", err)
+		}
+		if r.cutoverFunc != nil {
+			return r.cutoverFunc(ctx)
+		}
+		return nil
+	}
+}
+
+// readCutoverRecord reads the cutover table from sources[0]. It returns
+// nil if there is no record.
+func (r *Runner) readCutoverRecord(ctx context.Context) (*cutoverRecord, error) {
+	src0 := &r.sources[0]
+	var n int
+	if err := src0.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = ?",
+		src0.config.DBName, cutoverTableName).Scan(&n); err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	var tablesJSON string
+	var binlogName sql.NullString
+	var binlogPos sql.NullInt64
+	query := fmt.Sprintf("SELECT table_names, binlog_name, binlog_pos FROM `%s`.`%s` WHERE id = 1", src0.config.DBName, cutoverTableName)
+	if err := src0.db.QueryRowContext(ctx, query).Scan(&tablesJSON, &binlogName, &binlogPos); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	record := &cutoverRecord{}
+	if err := json.Unmarshal([]byte(tablesJSON), &record.tables); err != nil {
+		return nil, fmt.Errorf("could not parse cutover record: %w", err)
+	}
+	if binlogName.Valid && binlogPos.Valid {
+		record.pos = gomysql.Position{Name: binlogName.String, Pos: uint32(binlogPos.Int64)}
+	}
+	return record, nil
+}
+
+// currentBinlogPosition returns the position db is currently writing to.
+func currentBinlogPosition(ctx context.Context, db *sql.DB) (gomysql.Position, error) {
+	var pos gomysql.Position
+	var fake string
+	// SHOW MASTER STATUS was renamed to SHOW BINARY LOG STATUS in MySQL 8.2.
+	err := db.QueryRowContext(ctx, "SHOW MASTER STATUS").Scan(&pos.Name, &pos.Pos, &fake, &fake, &fake)
+	if err != nil {
+		err = db.QueryRowContext(ctx, "SHOW BINARY LOG STATUS").Scan(&pos.Name, &pos.Pos, &fake, &fake, &fake)
+	}
+	return pos, err
+}
+
+// SetRollback sets a function which is run during --rollback, after any
+// changes have been replayed and immediately before the source tables are
+// renamed back. With --rollback-replay the target tables are locked while
+// it runs. It is the rollback counterpart of SetCutover, and is used to
+// move traffic back to the sources.
+func (r *Runner) SetRollback(rollback func(ctx context.Context) error) {
+	r.rollbackFunc = rollback
+}
+
+// Rollback reverses a completed move. It checks that each moved table was
+// renamed to <table>_old on every source, optionally replays the changes
+// made on the target since the cutover to the _old tables, and renames
+// them back.
+func (r *Runner) Rollback(ctx context.Context) error {
+	ctx, r.cancelFunc = context.WithCancel(ctx)
+	defer r.cancelFunc()
+	r.dbConfig = dbconn.NewDBConfig()
+	r.dbConfig.MaxOpenConnections = r.move.WriteThreads + 2
+
+	sourceDSNs := r.move.SourceDSNs
+	if len(sourceDSNs) == 0 {
+		sourceDSNs = []string{r.move.SourceDSN}
+	}
+	err := r.openSources(sourceDSNs)
+	defer func() {
+		for i := range r.sources {
+			utils.CloseAndLog(r.sources[i].db)
+		}
+	}()
+	if err != nil {
+		return err
+	}
+	record, err := r.readCutoverRecord(ctx)
+	if err != nil {
+		return err
+	}
+	tableNames := r.move.SourceTables
+	if len(tableNames) == 0 {
+		if record == nil {
+			return fmt.Errorf("no cutover was recorded in %s; specify the tables to roll back with --source-tables", cutoverTableName)
+		}
+		tableNames = record.tables
+	}
+	if err := r.checkRollbackTables(ctx, tableNames); err != nil {
+		return err
+	}
+
+	r.status.Set(status.CutOver)
+	if r.move.RollbackReplay {
+		if record == nil || record.pos.Name == "" {
+			return errors.New("--rollback-replay requires the target binlog position recorded at the cutover, which is only recorded for moves with a single target")
+		}
+		if len(r.sources) != 1 {
+			return errors.New("--rollback-replay is only supported with a single source")
+		}
+		if err := r.openTargets(); err != nil {
+			return err
+		}
+		if len(r.targets) != 1 {
+			return errors.New("--rollback-replay is only supported with a single target")
+		}
+		err = r.replayAndRenameBack(ctx, tableNames, record.pos)
+	} else {
+		err = r.renameBack(ctx, tableNames)
+	}
+	if err != nil {
+		return err
+	}
+	src0 := &r.sources[0]
+	if err := dbconn.Exec(ctx, src0.db, "DROP TABLE IF EXISTS %n.%n", src0.config.DBName, cutoverTableName); err != nil {
+		return err
+	}
+	r.logger.Warn("Move rolled back.", "tables", tableNames)
+	return nil
+}
+
+// checkRollbackTables checks that each table was renamed to <table>_old,
+// and that nothing has been created in its place, on every source.
+func (r *Runner) checkRollbackTables(ctx context.Context, tableNames []string) error {
+	for i := range r.sources {
+		src := &r.sources[i]
+		for _, name := range tableNames {
+			for _, want := range []struct {
+				name   string
+				exists bool
+			}{
+				{name + "_old", true},
+				{name, false},
+			} {
+				var n int
+				if err := src.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = ?",
+					src.config.DBName, want.name).Scan(&n); err != nil {
+					return err
+				}
+				if want.exists && n == 0 {
+					return fmt.Errorf("can not roll back: table %s.%s does not exist on source %d", src.config.DBName, want.name, i)
+				}
+				if !want.exists && n > 0 {
+					return fmt.Errorf("can not roll back: table %s.%s already exists on source %d", src.config.DBName, want.name, i)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// replayAndRenameBack applies the changes made to the target tables since
+// pos to the _old tables on the source, through a reverse replication
+// client. Once it has caught up, the target tables are locked so that the
+// final changes can be applied, and the tables are renamed back while the
+// lock is held.
+func (r *Runner) replayAndRenameBack(ctx context.Context, tableNames []string, pos gomysql.Position) error {
+	src := &r.sources[0]
+	target := r.targets[0]
+	appl, err := applier.NewSingleTargetApplier(
+		applier.Target{DB: src.db, Config: src.config, KeyRange: "0"},
+		&applier.ApplierConfig{
+			Logger:   r.logger,
+			DBConfig: r.dbConfig,
+			Threads:  r.move.WriteThreads,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create applier: %w", err)
+	}
+	replConfig := repl.NewClientDefaultConfig()
+	replConfig.Logger = r.logger
+	replConfig.CancelFunc = func() bool {
+		r.cancelFunc()
+		return true
+	}
+	replConfig.DBConfig = r.dbConfig
+	feed := repl.NewClient(target.DB, target.Config.Addr, target.Config.User, target.Config.Passwd, appl, replConfig)
+	defer feed.Close()
+
+	targetTables := make([]*table.TableInfo, 0, len(tableNames))
+	for _, name := range tableNames {
+		targetTable := table.NewTableInfo(target.DB, target.Config.DBName, name)
+		if err := targetTable.SetInfo(ctx); err != nil {
+			return err
+		}
+		oldTable := table.NewTableInfo(src.db, src.config.DBName, name+"_old")
+		if err := oldTable.SetInfo(ctx); err != nil {
+			return err
+		}
+		chunker, err := table.NewChunker(targetTable, table.ChunkerConfig{NewTable: oldTable, Logger: r.logger})
+		if err != nil {
+			return err
+		}
+		if err := feed.AddSubscription(targetTable, oldTable, chunker); err != nil {
+			return err
+		}
+		targetTables = append(targetTables, targetTable)
+	}
+	feed.SetFlushedPos(pos)
+	if err := feed.Run(ctx); err != nil {
+		return err
+	}
+	r.logger.Info("Replaying target changes to the source", "from", pos)
+	// Catch up before taking the lock, so that the lock is held briefly.
+	if err := feed.BlockWait(ctx); err != nil {
+		return err
+	}
+	if err := feed.Flush(ctx); err != nil {
+		return err
+	}
+	lock, err := dbconn.NewTableLock(ctx, target.DB, targetTables, r.dbConfig, r.logger)
+	if err != nil {
+		return err
+	}
+	defer utils.CloseAndLogWithContext(ctx, lock)
+	// The target is locked, so once this has caught up
+	// there are no more changes to replay.
+	if err := feed.BlockWait(ctx); err != nil {
+		return err
+	}
+	if err := feed.Flush(ctx); err != nil {
+		return err
+	}
+	if !feed.AllChangesFlushed() {
+		return fmt.Errorf("%w, final flush might be broken", repl.ErrChangesNotFlushed)
+	}
+	return r.renameBack(ctx, tableNames)
+}
+
+// renameBack runs the rollback function and renames each <table>_old back
+// to <table> on every source. If a rename fails, the sources already
+// renamed are renamed to _old again.
+func (r *Runner) renameBack(ctx context.Context, tableNames []string) error {
+	if r.rollbackFunc != nil {
+		r.logger.Info("Running rollback function")
+		if err := r.rollbackFunc(ctx); err != nil {
+			return err
+		}
+		r.logger.Info("Rollback function complete")
+	}
+	renameStatement := func(from, to string) string {
+		fragments := make([]string, 0, len(tableNames))
+		for _, name := range tableNames {
+			fragments = append(fragments, fmt.Sprintf("`%s` TO `%s`", name+from, name+to))
+		}
+		return "RENAME TABLE " + strings.Join(fragments, ", ")
+	}
+	var completedRenames []int
+	for i := range r.sources {
+		if _, err := r.sources[i].db.ExecContext(ctx, renameStatement("_old", "")); err != nil {
+			var undoErrors []string
+			for _, j := range completedRenames {
+				if _, undoErr := r.sources[j].db.ExecContext(ctx, renameStatement("", "_old")); undoErr != nil {
+					r.logger.Error("undo rename failed", "source", j, "error", undoErr)
+					undoErrors = append(undoErrors, fmt.Sprintf("source %d: %v", j, undoErr))
+				}
+			}
+			if len(undoErrors) > 0 {
+				return fmt.Errorf("rename failed on source %d and undo also failed (%s): %w",
+					i, strings.Join(undoErrors, "; "), err)
+			}
+			return fmt.Errorf("rename failed on source %d, undid %d completed renames: %w",
+				i, len(completedRenames), err)
+		}
+		completedRenames = append(completedRenames, i)
+	}
+	return nil
+}
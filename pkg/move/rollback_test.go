@@ -0,0 +1,58 @@
+package move
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/block/spirit/pkg/testutils"
+	"github.com/block/spirit/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveRollback(t *testing.T) {
+	srcDB := "source_rollback"
+	dstDB := "dest_rollback"
+	testutils.RunSQL(t, "DROP DATABASE IF EXISTS "+srcDB)
+	testutils.RunSQL(t, "DROP DATABASE IF EXISTS "+dstDB)
+	testutils.RunSQL(t, "CREATE DATABASE "+srcDB)
+	testutils.RunSQL(t, "CREATE DATABASE "+dstDB)
+	testutils.RunSQL(t, "CREATE TABLE "+srcDB+".t1 (id INT NOT NULL PRIMARY KEY, val VARCHAR(10))")
+	testutils.RunSQL(t, "INSERT INTO "+srcDB+".t1 VALUES (1, 'a'), (2, 'b'), (3, 'c')")
+
+	move := &Move{
+		SourceDSN:       testutils.DSNForDatabase(srcDB),
+		TargetDSN:       testutils.DSNForDatabase(dstDB),
+		TargetChunkTime: 100 * time.Millisecond,
+		Threads:         2,
+		WriteThreads:    2,
+	}
+	require.NoError(t, move.Run())
+
+	// Changes made to the target after the cutover.
+	testutils.RunSQL(t, "INSERT INTO "+dstDB+".t1 VALUES (4, 'd')")
+	testutils.RunSQL(t, "UPDATE "+dstDB+".t1 SET val = 'x' WHERE id = 1")
+	testutils.RunSQL(t, "DELETE FROM "+dstDB+".t1 WHERE id = 2")
+
+	move.Rollback = true
+	move.RollbackReplay = true
+	require.NoError(t, move.Run())
+
+	db, err := sql.Open("mysql", testutils.DSNForDatabase(srcDB))
+	require.NoError(t, err)
+	defer utils.CloseAndLog(db)
+	var rows string
+	require.NoError(t, db.QueryRowContext(t.Context(),
+		"SELECT GROUP_CONCAT(CONCAT_WS(':', id, val) ORDER BY id) FROM t1").Scan(&rows))
+	require.Equal(t, "1:x,3:c,4:d", rows)
+	var n int
+	require.NoError(t, db.QueryRowContext(t.Context(),
+		"SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name IN ('t1_old', ?)", srcDB, cutoverTableName).Scan(&n))
+	require.Zero(t, n)
+
+	// There is nothing left to roll back.
+	move.RollbackReplay = false
+	require.ErrorContains(t, move.Run(), "no cutover was recorded")
+	move.SourceTables = []string{"t1"}
+	require.ErrorContains(t, move.Run(), "t1_old does not exist")
+}
@@ -1,6 +1,7 @@
 package move
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
 	"encoding/json"
@@ -41,6 +42,9 @@ var (
 	// small tables would re-acquire the table lock back-to-back since each
 	// pass finishes in seconds.
 	continuousChecksumMinInterval = 1 * time.Hour
+	// defaultTargetReplicaMaxLag is used when a target has a ReplicaDB
+	// but no --target-replica-max-lag was given.
+	defaultTargetReplicaMaxLag = 120 * time.Second
 )
 
 // sourceInfo holds per-source connection state for N:M moves.
@@ -80,9 +84,16 @@ type Runner struct {
 	copyChunker       table.Chunker
 	checksumChunker   table.Chunker
 	copier            copier.Copier
+	throttler         throttler.Throttler
 	checker           checksum.Checker
+	diffReport        *checksum.DiffReport // optional; from --checksum-diff-file
 	checksumWatermark string
 
+	// snapshotPool holds the transactions the copier reads from when
+	// --consistent-snapshot is used. It is only set for a new copy,
+	// and is closed as soon as the copier finishes.
+	snapshotPool *dbconn.TrxPool
+
 	// Track some key statistics.
 	startTime                time.Time
 	sentinelWaitStartTime    time.Time
@@ -97,7 +108,8 @@ type Runner struct {
 	// call so they never overlap.
 	continuousFlushMu sync.Mutex
 
-	cutoverFunc func(ctx context.Context) error
+	cutoverFunc  func(ctx context.Context) error
+	rollbackFunc func(ctx context.Context) error
 
 	logger     *slog.Logger
 	cancelFunc context.CancelFunc
@@ -131,6 +143,7 @@ func (r *Runner) Close() error {
 	if r.watchTaskWait != nil {
 		r.watchTaskWait()
 	}
+	r.closeSnapshotPool()
 	if r.copyChunker != nil {
 		if err := r.copyChunker.Close(); err != nil {
 			return err
@@ -141,10 +154,20 @@ func (r *Runner) Close() error {
 			r.sources[i].replClient.Close()
 		}
 	}
+	if r.throttler != nil {
+		if err := r.throttler.Close(); err != nil {
+			return err
+		}
+	}
 	for _, target := range r.targets {
 		if err := target.DB.Close(); err != nil {
 			return err
 		}
+		if target.ReplicaDB != nil {
+			if err := target.ReplicaDB.Close(); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -172,8 +195,8 @@ func (r *Runner) getTables(ctx context.Context, src *sourceInfo) ([]*table.Table
 		if err := rows.Scan(&tableName); err != nil {
 			return nil, err
 		}
-		if tableName == checkpointTableName || tableName == sentinelTableName {
-			continue // Skip if the table name is the checkpoint or sentinel table
+		if tableName == checkpointTableName || tableName == sentinelTableName || tableName == cutoverTableName {
+			continue // Skip if the table name is the checkpoint, sentinel or cutover table
 		}
 
 		// If SourceTables is specified, only include tables in that list
@@ -317,7 +340,7 @@ func (r *Runner) resumeFromCheckpoint(ctx context.Context) error {
 	}
 
 	// Then create a multi chunker of all chunkers.
-	r.copyChunker = table.NewMultiChunker(copyChunkers...)
+	r.copyChunker = table.NewMultiChunkerWithConfig(r.copyChunkerConfig(), copyChunkers...)
 	r.checksumChunker = table.NewMultiChunker(checksumChunkers...)
 
 	// Create a copier that reads from the multi chunker and uses the shared applier.
@@ -417,6 +440,10 @@ func (r *Runner) setup(ctx context.Context) error {
 		r.sources[i].tables = tables
 	}
 
+	if err := r.copyChunkerConfig().Validate(r.sourceTables); err != nil {
+		return fmt.Errorf("invalid --table-priority: %w", err)
+	}
+
 	if len(r.sourceTables) == 0 {
 		r.logger.Info("No tables found in source database; nothing to move")
 		return nil
@@ -507,9 +534,17 @@ func (r *Runner) newCopy(ctx context.Context) error {
 		}
 	}
 
-	r.copyChunker = table.NewMultiChunker(copyChunkers...)
+	r.copyChunker = table.NewMultiChunkerWithConfig(r.copyChunkerConfig(), copyChunkers...)
 	r.checksumChunker = table.NewMultiChunker(checksumChunkers...)
 
+	// With a consistent snapshot the repl client is started under
+	// the table lock, so it must not be started again below.
+	if r.move.ConsistentSnapshot {
+		if err := r.takeConsistentSnapshot(ctx); err != nil {
+			return err
+		}
+	}
+
 	// Create a copier that reads from the multi chunker and uses the shared applier.
 	var err error
 	r.copier, err = copier.NewCopier(r.sources[0].db, r.copyChunker, &copier.CopierConfig{
@@ -521,6 +556,7 @@ func (r *Runner) newCopy(ctx context.Context) error {
 		DBConfig:        r.dbConfig,
 		Applier:         r.applier, // Use the shared applier
 		Buffered:        true,      // move always uses the buffered copier
+		SnapshotPool:    r.snapshotPool,
 	})
 	if err != nil {
 		return err
@@ -530,6 +566,9 @@ func (r *Runner) newCopy(ctx context.Context) error {
 	if err := r.copyChunker.Open(); err != nil {
 		return err
 	}
+	if r.snapshotPool != nil {
+		return nil
+	}
 
 	// Start all replication clients.
 	for i := range r.sources {
@@ -541,6 +580,43 @@ func (r *Runner) newCopy(ctx context.Context) error {
 	return nil
 }
 
+// takeConsistentSnapshot briefly locks the source tables, and while no writes
+// can occur starts the replication client and creates the transactions that
+// the copier will read from. This means the read-view of the copy matches
+// exactly the binlog position that replication starts from, so the copy does
+// not need the watermark algorithm to stay consistent. The changes that arrive
+// during the copy are buffered in the repl client and are applied after the
+// copier has finished.
+func (r *Runner) takeConsistentSnapshot(ctx context.Context) error {
+	src := &r.sources[0]
+	r.logger.Info("taking consistent snapshot, this will require a table lock")
+	tableLock, err := dbconn.NewTableLock(ctx, src.db, src.tables, r.dbConfig, r.logger)
+	if err != nil {
+		return err
+	}
+	defer utils.CloseAndLogWithContext(ctx, tableLock)
+	if err := src.replClient.Run(ctx); err != nil {
+		return fmt.Errorf("failed to start repl client for source 0: %w", err)
+	}
+	r.snapshotPool, err = dbconn.NewTrxPool(ctx, src.db, r.move.Threads, r.dbConfig)
+	if err != nil {
+		return err
+	}
+	r.logger.Info("consistent snapshot taken", "binlog-position", src.replClient.GetBinlogApplyPosition())
+	return nil
+}
+
+// closeSnapshotPool releases the snapshot transactions if they are open.
+func (r *Runner) closeSnapshotPool() {
+	if r.snapshotPool == nil {
+		return
+	}
+	if err := r.snapshotPool.Close(); err != nil {
+		r.logger.Error("failed to close snapshot transactions", "error", err)
+	}
+	r.snapshotPool = nil
+}
+
 // createCheckpointTable creates checkpoint table on SOURCE (not target).
 // createCheckpointTable creates checkpoint table on sources[0] by convention.
 func (r *Runner) createCheckpointTable(ctx context.Context) error {
@@ -587,54 +663,45 @@ func (r *Runner) Run(ctx context.Context) error {
 	if len(sourceDSNs) == 0 {
 		sourceDSNs = []string{r.move.SourceDSN}
 	}
-
-	// Open connections to all sources.
-	r.sources = make([]sourceInfo, len(sourceDSNs))
-	for i, dsn := range sourceDSNs {
-		db, err := dbconn.New(dsn, r.dbConfig)
-		if err != nil {
-			return fmt.Errorf("failed to connect to source %d: %w", i, err)
-		}
-		cfg, err := mysql.ParseDSN(dsn)
-		if err != nil {
-			return fmt.Errorf("failed to parse source DSN %d: %w", i, err)
+	if _, err := checksum.ParseAlgorithm(r.move.ChecksumAlgorithm); err != nil {
+		return fmt.Errorf("--checksum-algorithm: %w", err)
+	}
+	if r.move.ChecksumDiffRedact && r.move.ChecksumDiffFile == "" {
+		return errors.New("--checksum-diff-redact requires --checksum-diff-file")
+	}
+	if r.move.MaxCutoverLockTime < 0 {
+		return fmt.Errorf("--max-cutover-lock-time must be non-negative, got %s", r.move.MaxCutoverLockTime)
+	}
+	r.dbConfig.MaxLockTime = r.move.MaxCutoverLockTime
+	if r.move.ConsistentSnapshot {
+		if len(sourceDSNs) > 1 {
+			return errors.New("consistent-snapshot is only supported with a single source")
 		}
-		r.sources[i] = sourceInfo{db: db, config: cfg, dsn: dsn}
+		// Each copier thread holds open a snapshot transaction for the
+		// duration of the copy, in addition to the usual connections.
+		r.dbConfig.MaxOpenConnections += r.move.Threads
 	}
-	// Sort sources by sourceKey (addr/dbname) for deterministic ordering.
-	// The checkpoint is always written to sources[0], so the order must be
-	// stable across runs even if the caller constructed SourceDSNs from a map.
-	// Sorting by addr/dbname rather than raw DSN ensures stability across
-	// credential rotations or DSN parameter reordering.
-	slices.SortFunc(r.sources, func(a, b sourceInfo) int {
-		return strings.Compare(a.sourceKey(), b.sourceKey())
-	})
+
+	// Open connections to all sources.
+	err = r.openSources(sourceDSNs)
 	defer func() {
 		for i := range r.sources {
 			utils.CloseAndLog(r.sources[i].db)
 		}
 	}()
-
-	// If targets are already configured (e.g., for resharding), use them.
-	// Otherwise, create a single target from TargetDSN (for simple 1:1 moves).
-	if len(r.move.Targets) > 0 {
-		r.targets = r.move.Targets
-		r.logger.Info("Using pre-configured targets", "count", len(r.targets))
-	} else {
-		db, err := dbconn.New(r.move.TargetDSN, r.dbConfig)
-		if err != nil {
-			return err
-		}
-		targetConfig, err := mysql.ParseDSN(r.move.TargetDSN)
-		if err != nil {
+	if err != nil {
+		return err
+	}
+	// The diff report is shared by the initial and continuous checksums.
+	if r.move.ChecksumDiffFile != "" {
+		if r.diffReport, err = checksum.NewDiffReport(r.move.ChecksumDiffFile, r.move.ChecksumDiffRedact); err != nil {
 			return err
 		}
-		r.targets = []applier.Target{{
-			KeyRange: "0",
-			DB:       db,
-			Config:   targetConfig,
-		}}
-		r.logger.Info("Created single target from TargetDSN")
+		defer utils.CloseAndLog(r.diffReport)
+	}
+
+	if err := r.openTargets(); err != nil {
+		return err
 	}
 	if err := r.setup(ctx); err != nil {
 		return err
@@ -683,15 +750,31 @@ func (r *Runner) Run(ctx context.Context) error {
 		}
 	}()
 
-	r.startBackgroundRoutines(ctx)
-	if err := r.setWatermarkOptimizationAll(ctx, true); err != nil {
+	// When copying from a consistent snapshot there is no need for the
+	// watermark optimization, and changes are not flushed until the
+	// copy has finished.
+	fromSnapshot := r.snapshotPool != nil
+	if err := r.setupThrottler(ctx); err != nil {
 		return err
 	}
+	r.startBackgroundRoutines(ctx, !fromSnapshot)
+	if !fromSnapshot {
+		if err := r.setWatermarkOptimizationAll(ctx, true); err != nil {
+			return err
+		}
+	}
 
 	r.status.Set(status.CopyRows)
-	if err := r.copier.Run(ctx); err != nil {
+	err = r.copier.Run(ctx)
+	r.closeSnapshotPool()
+	if err != nil {
 		return err
 	}
+	if fromSnapshot {
+		for i := range r.sources {
+			go r.sources[i].replClient.StartPeriodicFlush(ctx, repl.DefaultFlushInterval)
+		}
+	}
 
 	// Disable both watermark optimizations so that all changes can be flushed.
 	// For non-memory-comparable PKs this also drains the buffered map and
@@ -732,7 +815,11 @@ func (r *Runner) Run(ctx context.Context) error {
 			Tables:     r.sources[i].tables,
 		}
 	}
-	cutover, err := NewCutOver(cutoverSources, r.cutoverFunc, r.dbConfig, r.logger)
+	// The cutover is recorded on sources[0], so that it can be rolled back.
+	if err := r.createCutoverTable(ctx); err != nil {
+		return err
+	}
+	cutover, err := NewCutOver(cutoverSources, r.recordCutover(), r.dbConfig, r.logger)
 	if err != nil {
 		return err
 	}
@@ -748,9 +835,149 @@ func (r *Runner) Run(ctx context.Context) error {
 	return nil
 }
 
+// openSources opens a connection to each source. The connections are
+// closed by the caller, even if an error is returned.
+func (r *Runner) openSources(sourceDSNs []string) error {
+	r.sources = make([]sourceInfo, 0, len(sourceDSNs))
+	for i, dsn := range sourceDSNs {
+		db, err := dbconn.New(dsn, r.dbConfig)
+		if err != nil {
+			return fmt.Errorf("failed to connect to source %d: %w", i, err)
+		}
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			utils.CloseAndLog(db)
+			return fmt.Errorf("failed to parse source DSN %d: %w", i, err)
+		}
+		r.sources = append(r.sources, sourceInfo{db: db, config: cfg, dsn: dsn})
+	}
+	// Sort sources by sourceKey (addr/dbname) for deterministic ordering.
+	// The checkpoint is always written to sources[0], so the order must be
+	// stable across runs even if the caller constructed SourceDSNs from a map.
+	// Sorting by addr/dbname rather than raw DSN ensures stability across
+	// credential rotations or DSN parameter reordering.
+	slices.SortFunc(r.sources, func(a, b sourceInfo) int {
+		return strings.Compare(a.sourceKey(), b.sourceKey())
+	})
+	return nil
+}
+
+// openTargets sets the targets. If they are already configured (e.g., for
+// resharding), they are used. Otherwise, a single target is created from
+// TargetDSN (for simple 1:1 moves). The targets are closed in Close().
+func (r *Runner) openTargets() error {
+	if len(r.move.Targets) > 0 {
+		if r.move.TargetReplicaDSN != "" {
+			return errors.New("target-replica-dsn can not be used with pre-configured targets, set ReplicaDB on each target instead")
+		}
+		r.targets = r.move.Targets
+		r.logger.Info("Using pre-configured targets", "count", len(r.targets))
+		return nil
+	}
+	db, err := dbconn.New(r.move.TargetDSN, r.dbConfig)
+	if err != nil {
+		return err
+	}
+	targetConfig, err := mysql.ParseDSN(r.move.TargetDSN)
+	if err != nil {
+		utils.CloseAndLog(db)
+		return err
+	}
+	r.targets = []applier.Target{{
+		KeyRange: "0",
+		DB:       db,
+		Config:   targetConfig,
+	}}
+	if r.move.TargetReplicaDSN != "" {
+		r.targets[0].ReplicaDB, err = dbconn.New(r.move.TargetReplicaDSN, r.dbConfig)
+		if err != nil {
+			return fmt.Errorf("failed to connect to target replica: %w", err)
+		}
+	}
+	r.logger.Info("Created single target from TargetDSN")
+	return nil
+}
+
+// copyChunkerConfig returns how the copy chunker schedules tables.
+// Priorities are by table name, so they apply to the same table on
+// every source. They are validated against the source tables in setup.
+func (r *Runner) copyChunkerConfig() table.MultiChunkerConfig {
+	return table.MultiChunkerConfig{
+		Priorities:          r.move.TablePriorities,
+		MaxConcurrentTables: r.move.MaxConcurrentTables,
+	}
+}
+
+// setupThrottler sets up the throttlers used to pace the copier and the
+// restoration of deferred secondary indexes. They watch the targets, so
+// that the copy slows down when any target struggles:
+//   - a replication throttler for each target with a ReplicaDB
+//   - a Threads_running throttler for each target if
+//     --target-max-threads-running is set
+//   - a commit-latency throttler for each target detected as Aurora
+//
+// In addition, an HTTP throttler is added if --throttler-url is set.
+//
+// If none apply, the copier keeps its default Noop throttler.
+func (r *Runner) setupThrottler(ctx context.Context) error {
+	var throttlers []throttler.Throttler
+	for _, target := range r.targets {
+		if target.ReplicaDB != nil {
+			maxLag := r.move.TargetReplicaMaxLag
+			if maxLag <= 0 {
+				maxLag = defaultTargetReplicaMaxLag
+			}
+			replicaThrottler, err := throttler.NewReplicationThrottler(target.ReplicaDB, maxLag, r.logger)
+			if err != nil {
+				return fmt.Errorf("could not create replication throttler for target %s: %w", target.KeyRange, err)
+			}
+			throttlers = append(throttlers, replicaThrottler)
+		}
+		if r.move.TargetMaxThreadsRunning > 0 {
+			threadsThrottler, err := throttler.NewThreadsRunningThrottler(target.DB, r.move.TargetMaxThreadsRunning, r.logger)
+			if err != nil {
+				return fmt.Errorf("could not create threads-running throttler for target %s: %w", target.KeyRange, err)
+			}
+			throttlers = append(throttlers, threadsThrottler)
+		}
+		if r.move.TargetMaxCommitLatency > 0 {
+			isAurora, err := throttler.IsAurora(ctx, target.DB)
+			if err != nil {
+				r.logger.Debug("Aurora probe failed, skipping commit-latency throttler", "target", target.KeyRange, "error", err)
+			} else if isAurora {
+				cl, err := throttler.NewCommitLatencyThrottler(target.DB, r.move.TargetMaxCommitLatency, r.logger)
+				if err != nil {
+					return fmt.Errorf("could not create commit-latency throttler for target %s: %w", target.KeyRange, err)
+				}
+				r.logger.Info("Aurora target detected, enabling commit-latency throttler",
+					"target", target.KeyRange,
+					"threshold", r.move.TargetMaxCommitLatency)
+				throttlers = append(throttlers, cl)
+			}
+		}
+	}
+	if r.move.ThrottlerURL != "" {
+		httpThrottler, err := throttler.NewHTTPThrottler(r.move.ThrottlerURL, cmp.Or(r.move.ThrottlerApp, "spirit"), r.move.ThrottlerCheckInterval, r.logger)
+		if err != nil {
+			return fmt.Errorf("could not create http throttler: %w", err)
+		}
+		throttlers = append(throttlers, httpThrottler)
+	}
+	if len(throttlers) == 0 {
+		return nil // use default Noop throttler
+	}
+	r.throttler = throttler.NewMultiThrottler(throttlers...)
+	r.copier.SetThrottler(r.throttler)
+	if err := r.throttler.Open(ctx); err != nil {
+		return fmt.Errorf("opening throttlers: %w", err)
+	}
+	return nil
+}
+
 // startBackgroundRoutines starts the background routines needed for monitoring.
-// This includes table statistics updates and periodic binlog flushing.
-func (r *Runner) startBackgroundRoutines(ctx context.Context) {
+// This includes table statistics updates and, if periodicFlush is true,
+// periodic binlog flushing.
+func (r *Runner) startBackgroundRoutines(ctx context.Context, periodicFlush bool) {
 	// Start routines in table and replication packages to
 	// Continuously update the min/max and estimated rows
 	// and to flush the binary log position periodically.
@@ -761,7 +988,9 @@ func (r *Runner) startBackgroundRoutines(ctx context.Context) {
 		for _, tbl := range r.sources[i].tables {
 			go tbl.AutoUpdateStatistics(ctx, tableStatUpdateInterval, r.logger)
 		}
-		go r.sources[i].replClient.StartPeriodicFlush(ctx, repl.DefaultFlushInterval)
+		if periodicFlush {
+			go r.sources[i].replClient.StartPeriodicFlush(ctx, repl.DefaultFlushInterval)
+		}
 	}
 
 	// Start go routines for checkpointing and dumping status. The returned
@@ -967,6 +1196,16 @@ func (r *Runner) restoreIndexesForTargets(ctx context.Context, host string, targ
 				"host", host,
 				"stmt", alterStmt)
 
+			// Adding indexes is expensive, so wait while the target
+			// throttlers (such as Threads_running) are throttled.
+			if r.throttler != nil && r.throttler.IsThrottled() {
+				r.logger.Info("waiting for throttler before restoring secondary indexes",
+					"table", tbl.TableName,
+					"target", targetIdx,
+					"host", host)
+				r.throttler.BlockWait(ctx)
+			}
+
 			// Execute the ALTER TABLE statement to add all missing indexes at once
 			if _, err := target.DB.ExecContext(ctx, alterStmt); err != nil {
 				return fmt.Errorf("failed to restore indexes on target %d (host %s): %w", targetIdx, host, err)
@@ -1048,6 +1287,8 @@ func (r *Runner) postCopyPhase(ctx context.Context) error {
 		Logger:          r.logger,
 		Applier:         r.applier,
 		FixDifferences:  true,
+		Algorithm:       checksum.Algorithm(r.move.ChecksumAlgorithm),
+		DiffReport:      r.diffReport,
 	})
 	if err != nil {
 		return err
@@ -1277,6 +1518,8 @@ func (r *Runner) runContinuousChecksum(ctx context.Context) error {
 		// loop itself supplies the retry, so we don't nest a second
 		// retry loop inside each iteration.
 		MaxRetries: 1,
+		Algorithm:  checksum.Algorithm(r.move.ChecksumAlgorithm),
+		DiffReport: r.diffReport,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create continuous checker: %w", err)
@@ -131,6 +131,123 @@ func testMoveWithConcurrentWrites(t *testing.T, deferSecondaryIndexes bool) {
 	require.Equal(t, sourceCount, targetCount, "Source and target should have same row count")
 }
 
+// TestMoveConsistentSnapshot verifies that a move seeded from a consistent
+// snapshot ends up with the same rows as the source, including the changes
+// that were made while the snapshot was being copied.
+func TestMoveConsistentSnapshot(t *testing.T) {
+	sourceDSN := testutils.DSNForDatabase("source_snapshot")
+	targetDSN := testutils.DSNForDatabase("dest_snapshot")
+
+	testutils.RunSQL(t, `DROP DATABASE IF EXISTS source_snapshot`)
+	testutils.RunSQL(t, `DROP DATABASE IF EXISTS dest_snapshot`)
+	testutils.RunSQL(t, `CREATE DATABASE source_snapshot`)
+	testutils.RunSQL(t, `CREATE DATABASE dest_snapshot`)
+	testutils.RunSQL(t, `CREATE TABLE source_snapshot.xfers (
+		id INT NOT NULL PRIMARY KEY AUTO_INCREMENT,
+		x_token VARCHAR(36) NOT NULL,
+		cents INT NOT NULL,
+		currency VARCHAR(3) NOT NULL,
+		s_token VARCHAR(36) NOT NULL,
+		r_token VARCHAR(36) NOT NULL,
+		version INT NOT NULL DEFAULT 1,
+		c1 VARCHAR(20),
+		c2 VARCHAR(200),
+		c3 VARCHAR(10),
+		t1 DATETIME,
+		t2 DATETIME,
+		t3 DATETIME,
+		b1 TINYINT,
+		b2 TINYINT,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		UNIQUE KEY idx_x_token (x_token)
+	)`)
+	testutils.RunSQL(t, `INSERT INTO source_snapshot.xfers (x_token, cents, currency, s_token, r_token, created_at, updated_at)
+		SELECT UUID(), 100, 'USD', UUID(), UUID(), NOW(), NOW() FROM dual`)
+	testutils.RunSQL(t, `INSERT INTO source_snapshot.xfers (x_token, cents, currency, s_token, r_token, created_at, updated_at)
+		SELECT UUID(), 100, 'USD', UUID(), UUID(), NOW(), NOW() FROM source_snapshot.xfers a JOIN source_snapshot.xfers b JOIN source_snapshot.xfers c LIMIT 10000`)
+
+	sourceDB, err := sql.Open("mysql", sourceDSN)
+	require.NoError(t, err)
+	defer utils.CloseAndLog(sourceDB)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	var wg sync.WaitGroup
+	var writeCount atomic.Int64
+	var errorCount atomic.Int64
+	for range 2 {
+		wg.Go(func() {
+			concurrentWriteThread(ctx, sourceDB, &writeCount, &errorCount)
+		})
+	}
+
+	move := &Move{
+		SourceDSN:          sourceDSN,
+		TargetDSN:          targetDSN,
+		TargetChunkTime:    100 * time.Millisecond,
+		Threads:            2,
+		ConsistentSnapshot: true,
+	}
+	err = move.Run()
+	cancel()
+	wg.Wait()
+	t.Logf("Completed %d writes with %d errors during move operation",
+		writeCount.Load(), errorCount.Load())
+	require.NoError(t, err)
+
+	var sourceCount, targetCount int
+	err = sourceDB.QueryRowContext(t.Context(), "SELECT COUNT(*) FROM source_snapshot.xfers_old").Scan(&sourceCount)
+	require.NoError(t, err)
+	targetDB, err := sql.Open("mysql", targetDSN)
+	require.NoError(t, err)
+	defer utils.CloseAndLog(targetDB)
+	err = targetDB.QueryRowContext(t.Context(), "SELECT COUNT(*) FROM dest_snapshot.xfers").Scan(&targetCount)
+	require.NoError(t, err)
+	require.Equal(t, sourceCount, targetCount)
+}
+
+func TestMoveConsistentSnapshotMultipleSources(t *testing.T) {
+	move := &Move{
+		SourceDSNs:         []string{testutils.DSNForDatabase("src0"), testutils.DSNForDatabase("src1")},
+		TargetDSN:          testutils.DSNForDatabase("dest"),
+		TargetChunkTime:    100 * time.Millisecond,
+		Threads:            2,
+		ConsistentSnapshot: true,
+	}
+	err := move.Run()
+	require.ErrorContains(t, err, "only supported with a single source")
+}
+
+func TestMoveWithTargetThrottlers(t *testing.T) {
+	sourceDSN := testutils.DSNForDatabase("source_tthrottle")
+	targetDSN := testutils.DSNForDatabase("dest_tthrottle")
+	testutils.RunSQL(t, `DROP DATABASE IF EXISTS source_tthrottle`)
+	testutils.RunSQL(t, `DROP DATABASE IF EXISTS dest_tthrottle`)
+	testutils.RunSQL(t, `CREATE DATABASE source_tthrottle`)
+	testutils.RunSQL(t, `CREATE DATABASE dest_tthrottle`)
+	testutils.RunSQL(t, `CREATE TABLE source_tthrottle.t1 (id INT PRIMARY KEY, val VARCHAR(255))`)
+	testutils.RunSQL(t, `INSERT INTO source_tthrottle.t1 VALUES (1, 'one'), (2, 'two'), (3, 'three')`)
+
+	move := &Move{
+		SourceDSN:               sourceDSN,
+		TargetDSN:               targetDSN,
+		TargetChunkTime:         100 * time.Millisecond,
+		Threads:                 2,
+		WriteThreads:            2,
+		TargetReplicaDSN:        targetDSN, // not a replica, so lag is always 0
+		TargetMaxThreadsRunning: 1000,
+		TargetMaxCommitLatency:  100 * time.Millisecond,
+	}
+	r, err := NewRunner(move)
+	require.NoError(t, err)
+	defer utils.CloseAndLog(r)
+	require.NoError(t, r.Run(t.Context()))
+	require.NotNil(t, r.throttler)
+	require.NotNil(t, r.targets[0].ReplicaDB)
+	require.False(t, r.throttler.IsThrottled())
+}
+
 // concurrentWriteThread simulates the load pattern from the load test
 func concurrentWriteThread(ctx context.Context, db *sql.DB, writeCount, errorCount *atomic.Int64) {
 	for {
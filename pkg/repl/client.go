@@ -15,6 +15,7 @@ import (
 	"github.com/block/spirit/pkg/applier"
 	"github.com/block/spirit/pkg/dbconn"
 	"github.com/block/spirit/pkg/table"
+	"github.com/block/spirit/pkg/utils"
 	"github.com/go-mysql-org/go-mysql/mysql"
 	"github.com/go-mysql-org/go-mysql/replication"
 )
@@ -213,6 +214,13 @@ func (c *Client) getCurrentBinlogPosition(ctx context.Context) (mysql.Position,
 	if _, err := c.db.ExecContext(ctx, `FLUSH BINARY LOGS`); err != nil {
 		return mysql.Position{}, fmt.Errorf("failed to flush binary logs: %w", err)
 	}
+	return c.CurrentBinlogPosition(ctx)
+}
+
+// CurrentBinlogPosition returns the position the source is currently
+// writing to. Unlike the position used to start the client, the binary
+// log is not rotated first, so it is cheap enough to call under a lock.
+func (c *Client) CurrentBinlogPosition(ctx context.Context) (mysql.Position, error) {
 	var binlogFile, fake string
 	var binlogPos uint32
 	// On the first call, try SHOW MASTER STATUS (works on MySQL 8.0, the most common version)
@@ -242,6 +250,53 @@ func (c *Client) getCurrentBinlogPosition(ctx context.Context) (mysql.Position,
 	}, nil
 }
 
+// BinlogBytesBehind returns how many bytes the server has written to the
+// binary log which have not been read into the buffer yet. It does not
+// rotate the binary log, so it is cheap enough to call in a loop.
+func (c *Client) BinlogBytesBehind(ctx context.Context) (int64, error) {
+	current, err := c.CurrentBinlogPosition(ctx)
+	if err != nil {
+		return 0, err
+	}
+	buffered := c.getBufferedPos()
+	if buffered.Name == current.Name {
+		return max(int64(current.Pos)-int64(buffered.Pos), 0), nil
+	}
+	// The reader is in an earlier file, so the remainder of that file and
+	// the size of every file in between is added.
+	rows, err := c.db.QueryContext(ctx, "SHOW BINARY LOGS")
+	if err != nil {
+		return 0, err
+	}
+	defer utils.CloseAndLog(rows)
+	cols, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	behind := int64(current.Pos)
+	for rows.Next() {
+		var name string
+		var size int64
+		// The number of columns varies by version, only the first
+		// two (Log_name, File_size) are needed.
+		dest := make([]any, len(cols))
+		dest[0], dest[1] = &name, &size
+		for i := 2; i < len(cols); i++ {
+			dest[i] = new(sql.RawBytes)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return 0, err
+		}
+		switch {
+		case name == buffered.Name:
+			behind += max(size-int64(buffered.Pos), 0)
+		case name > buffered.Name && name < current.Name:
+			behind += size
+		}
+	}
+	return behind, rows.Err()
+}
+
 // Run initializes the binlog syncer and starts the binlog reader.
 // It returns an error if the initialization fails.
 func (c *Client) Run(ctx context.Context) error {
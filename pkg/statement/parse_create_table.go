@@ -27,6 +27,8 @@ type CreateTable struct {
 	Constraints  Constraints          `json:"constraints"`
 	TableOptions *TableOptions        `json:"table_options,omitempty"`
 	Partition    *PartitionOptions    `json:"partition,omitempty"`
+	Suppressions []Suppression        `json:"suppressions,omitempty"` // spirit-lint:ignore directives
+	Positions    *SourcePositions     `json:"-"`                      // Lines of each element in the parsed SQL
 }
 
 // Column represents a table column definition
@@ -204,6 +206,8 @@ func ParseCreateTable(sql string) (*CreateTable, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse CREATE TABLE: %w", err)
 	}
+	ct.parseSuppressions(sql)
+	ct.parseSourcePositions(sql)
 	return ct, nil
 }
 
@@ -0,0 +1,70 @@
+package statement
+
+import "strings"
+
+// SourcePositions records where the elements of a CREATE TABLE statement
+// are defined in the SQL text it was parsed from. Lines are 1-based and
+// relative to the start of that text. Like suppressions, positions rely on
+// the one-element-per-line layout of SHOW CREATE TABLE.
+type SourcePositions struct {
+	// File is the file the statement was read from. It is set by callers
+	// that load schemas from files; the parser leaves it empty.
+	File string
+
+	// Table is the line of the CREATE TABLE keyword.
+	Table int
+
+	// Columns, Indexes and Constraints map lowercase names to lines.
+	Columns     map[string]int
+	Indexes     map[string]int
+	Constraints map[string]int
+}
+
+// Line returns the line of the column, index or constraint (the first that
+// is set and known), falling back to the line of the table.
+func (p *SourcePositions) Line(column, index, constraint *string) int {
+	if column != nil {
+		if line, ok := p.Columns[strings.ToLower(*column)]; ok {
+			return line
+		}
+	}
+	if index != nil {
+		if line, ok := p.Indexes[strings.ToLower(*index)]; ok {
+			return line
+		}
+	}
+	if constraint != nil {
+		if line, ok := p.Constraints[strings.ToLower(*constraint)]; ok {
+			return line
+		}
+	}
+	return p.Table
+}
+
+// parseSourcePositions records the line of each element defined in sql.
+func (ct *CreateTable) parseSourcePositions(sql string) {
+	p := &SourcePositions{
+		Columns:     make(map[string]int),
+		Indexes:     make(map[string]int),
+		Constraints: make(map[string]int),
+	}
+	for i, line := range splitCommentLines(sql) {
+		if strings.TrimSpace(line.code) == "" {
+			continue
+		}
+		if p.Table == 0 && hasKeywordPrefix(strings.ToUpper(strings.TrimSpace(line.code)), "CREATE") {
+			p.Table = i + 1
+			continue
+		}
+		column, index, constraint, _ := ct.elementForLine(line.code)
+		switch {
+		case column != nil:
+			p.Columns[strings.ToLower(*column)] = i + 1
+		case index != nil:
+			p.Indexes[strings.ToLower(*index)] = i + 1
+		case constraint != nil:
+			p.Constraints[strings.ToLower(*constraint)] = i + 1
+		}
+	}
+	ct.Positions = p
+}
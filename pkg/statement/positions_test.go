@@ -0,0 +1,34 @@
+package statement
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourcePositions(t *testing.T) {
+	ct, err := ParseCreateTable(`-- orders table
+
+CREATE TABLE orders (
+  id bigint unsigned NOT NULL AUTO_INCREMENT,
+  customer_id bigint unsigned NOT NULL,
+  note varchar(255) DEFAULT 'multi
+line',
+  PRIMARY KEY (id),
+  KEY idx_customer (customer_id),
+  CONSTRAINT fk_customer FOREIGN KEY (customer_id) REFERENCES customers (id)
+) ENGINE=InnoDB`)
+	require.NoError(t, err)
+	p := ct.Positions
+	require.NotNil(t, p)
+	require.Equal(t, 3, p.Table)
+	require.Equal(t, 4, p.Line(stringPtr("id"), nil, nil))
+	require.Equal(t, 5, p.Line(stringPtr("Customer_ID"), nil, nil))
+	require.Equal(t, 6, p.Line(stringPtr("note"), nil, nil))
+	require.Equal(t, 8, p.Line(nil, stringPtr("PRIMARY"), nil))
+	require.Equal(t, 9, p.Line(nil, stringPtr("idx_customer"), nil))
+	require.Equal(t, 10, p.Line(nil, nil, stringPtr("fk_customer")))
+	// Unknown elements fall back to the table.
+	require.Equal(t, 3, p.Line(stringPtr("missing"), nil, nil))
+	require.Equal(t, 3, p.Line(nil, nil, nil))
+}
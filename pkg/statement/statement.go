@@ -202,6 +202,8 @@ func (a *AbstractStatement) ParseCreateTable() (*CreateTable, error) {
 	}
 	// Parse into structured format
 	ct.parseToStruct()
+	ct.parseSuppressions(createStmt.Text())
+	ct.parseSourcePositions(createStmt.Text())
 	return ct, nil
 }
 
@@ -0,0 +1,276 @@
+package statement
+
+import (
+	"regexp"
+	"strings"
+)
+
+// suppressionRe matches a lint suppression directive, e.g.
+// "spirit-lint:ignore has_float" or "spirit-lint:ignore has_float, has_timestamp".
+var suppressionRe = regexp.MustCompile(`spirit-lint:ignore\s+(\w+(?:\s*,\s*\w+)*)`)
+
+// Suppression is a "spirit-lint:ignore" directive found in a CREATE TABLE
+// statement. It silences the named linters for the element it is attached
+// to: a column, an index or a constraint. When Column, Index and Constraint
+// are all nil, it applies to the whole table.
+//
+// Directives are read from SQL comments and from COMMENT attributes. A SQL
+// comment applies to the element defined on the same line, or when it is on
+// a line of its own, to the element defined on the next line. This matches
+// the one-element-per-line layout of SHOW CREATE TABLE. A SQL comment on the
+// CREATE TABLE line applies to the whole table; one which does not attach
+// to a named element, such as on an unnamed index or the closing line, is
+// ignored rather than widened to the table. A COMMENT attribute
+// applies to the column, index or table it belongs to; unlike SQL comments,
+// these are preserved by MySQL and so also work when loading from a server.
+type Suppression struct {
+	Linters    []string `json:"linters"`
+	Column     *string  `json:"column,omitempty"`
+	Index      *string  `json:"index,omitempty"`
+	Constraint *string  `json:"constraint,omitempty"`
+}
+
+// Matches returns true if the suppression silences the linter for the
+// given column, index and constraint (each may be nil).
+func (s Suppression) Matches(linter string, column, index, constraint *string) bool {
+	found := false
+	for _, l := range s.Linters {
+		if strings.EqualFold(l, linter) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false
+	}
+	return matchesName(s.Column, column) && matchesName(s.Index, index) && matchesName(s.Constraint, constraint)
+}
+
+// matchesName returns true if want is unset, or equal to got.
+func matchesName(want, got *string) bool {
+	if want == nil {
+		return true
+	}
+	return got != nil && strings.EqualFold(*want, *got)
+}
+
+// parseSuppressionDirective returns the linter names of a directive in text,
+// or nil if there is none.
+func parseSuppressionDirective(text string) []string {
+	var linters []string
+	for _, m := range suppressionRe.FindAllStringSubmatch(text, -1) {
+		for name := range strings.SplitSeq(m[1], ",") {
+			linters = append(linters, strings.TrimSpace(name))
+		}
+	}
+	return linters
+}
+
+// parseSuppressions collects the suppression directives from the SQL
+// comments in sql and from the COMMENT attributes of the parsed table.
+func (ct *CreateTable) parseSuppressions(sql string) {
+	var suppressions []Suppression
+	lines := splitCommentLines(sql)
+	for i, line := range lines {
+		linters := parseSuppressionDirective(line.comment)
+		if len(linters) == 0 {
+			continue
+		}
+		code := line.code
+		if strings.TrimSpace(code) == "" {
+			// A comment on its own line applies to the next line with code.
+			for _, next := range lines[i+1:] {
+				if strings.TrimSpace(next.code) != "" {
+					code = next.code
+					break
+				}
+			}
+		}
+		column, index, constraint, ok := ct.elementForLine(code)
+		if !ok {
+			continue
+		}
+		suppressions = append(suppressions, Suppression{Linters: linters, Column: column, Index: index, Constraint: constraint})
+	}
+
+	for _, col := range ct.Columns {
+		if col.Comment == nil {
+			continue
+		}
+		if linters := parseSuppressionDirective(*col.Comment); len(linters) > 0 {
+			suppressions = append(suppressions, Suppression{Linters: linters, Column: stringPtr(col.Name)})
+		}
+	}
+	for _, idx := range ct.GetIndexes() {
+		if idx.Comment == nil {
+			continue
+		}
+		if linters := parseSuppressionDirective(*idx.Comment); len(linters) > 0 {
+			suppressions = append(suppressions, Suppression{Linters: linters, Index: stringPtr(idx.Name)})
+		}
+	}
+	if ct.TableOptions != nil && ct.TableOptions.Comment != nil {
+		if linters := parseSuppressionDirective(*ct.TableOptions.Comment); len(linters) > 0 {
+			suppressions = append(suppressions, Suppression{Linters: linters})
+		}
+	}
+	ct.Suppressions = suppressions
+}
+
+// elementForLine returns the column, index or constraint defined by a line
+// of a CREATE TABLE statement. All are nil for the CREATE TABLE line, which
+// applies to the whole table. ok is false for lines that attach to nothing,
+// such as unnamed indexes or the closing line.
+func (ct *CreateTable) elementForLine(code string) (column, index, constraint *string, ok bool) {
+	code = strings.TrimLeft(strings.TrimSpace(code), ",")
+	code = strings.TrimSpace(code)
+	upper := strings.ToUpper(code)
+	switch {
+	case hasKeywordPrefix(upper, "CREATE"):
+		return nil, nil, nil, true
+	case strings.HasPrefix(upper, "PRIMARY KEY"):
+		return nil, stringPtr("PRIMARY"), nil, true
+	case strings.HasPrefix(upper, "CONSTRAINT"):
+		// The symbol is optional, as in CONSTRAINT FOREIGN KEY (a) ...
+		rest := code[len("CONSTRAINT"):]
+		if hasKeywordPrefix(strings.ToUpper(strings.TrimSpace(rest)), "FOREIGN", "CHECK", "UNIQUE", "PRIMARY") {
+			return nil, nil, nil, false
+		}
+		if name, _ := leadingIdentifier(rest); name != "" {
+			return nil, nil, stringPtr(name), true
+		}
+		return nil, nil, nil, false
+	case hasKeywordPrefix(upper, "KEY", "INDEX", "UNIQUE", "FULLTEXT", "SPATIAL"):
+		rest := code
+		for _, kw := range []string{"UNIQUE", "FULLTEXT", "SPATIAL", "KEY", "INDEX"} {
+			if hasKeywordPrefix(strings.ToUpper(strings.TrimSpace(rest)), kw) {
+				rest = strings.TrimSpace(rest)[len(kw):]
+			}
+		}
+		if name, _ := leadingIdentifier(rest); name != "" {
+			return nil, stringPtr(name), nil, true
+		}
+		return nil, nil, nil, false
+	}
+	if name, _ := leadingIdentifier(code); name != "" {
+		if col := ct.Columns.ByName(name); col != nil {
+			return stringPtr(col.Name), nil, nil, true
+		}
+	}
+	return nil, nil, nil, false
+}
+
+// hasKeywordPrefix returns true if s starts with one of the keywords
+// followed by a non-identifier character.
+func hasKeywordPrefix(s string, keywords ...string) bool {
+	for _, kw := range keywords {
+		if strings.HasPrefix(s, kw) && (len(s) == len(kw) || !isIdentifierChar(s[len(kw)])) {
+			return true
+		}
+	}
+	return false
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// leadingIdentifier returns the (optionally backtick-quoted) identifier
+// at the start of s, and the remainder of s.
+func leadingIdentifier(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "`") {
+		var sb strings.Builder
+		for i := 1; i < len(s); i++ {
+			if s[i] == '`' {
+				if i+1 < len(s) && s[i+1] == '`' {
+					sb.WriteByte('`')
+					i++
+					continue
+				}
+				return sb.String(), s[i+1:]
+			}
+			sb.WriteByte(s[i])
+		}
+		return "", s
+	}
+	i := 0
+	for i < len(s) && isIdentifierChar(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// commentLine is a line of SQL split into its code and comment text.
+type commentLine struct {
+	code    string
+	comment string
+}
+
+// splitCommentLines splits sql into lines, separating the code on each line
+// from its comments (--, # and /* */). Quoted strings and identifiers are
+// kept as code. A block comment spanning several lines is attributed to the
+// line it starts on.
+func splitCommentLines(sql string) []commentLine {
+	var lines []commentLine
+	var code, comment strings.Builder
+	flush := func() {
+		lines = append(lines, commentLine{code: code.String(), comment: comment.String()})
+		code.Reset()
+		comment.Reset()
+	}
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\n':
+			flush()
+		case c == '\'' || c == '"' || c == '`':
+			// Copy the quoted string, honoring backslash escapes
+			// and doubled quotes.
+			code.WriteByte(c)
+			for i++; i < len(sql); i++ {
+				if sql[i] == '\n' {
+					flush()
+					continue
+				}
+				code.WriteByte(sql[i])
+				if sql[i] == '\\' && c != '`' && i+1 < len(sql) {
+					i++
+					code.WriteByte(sql[i])
+					continue
+				}
+				if sql[i] == c {
+					if i+1 < len(sql) && sql[i+1] == c {
+						i++
+						code.WriteByte(sql[i])
+						continue
+					}
+					break
+				}
+			}
+		case c == '#' || c == '-' && strings.HasPrefix(sql[i:], "--") && (i+2 == len(sql) || sql[i+2] == ' ' || sql[i+2] == '\t' || sql[i+2] == '\n' || sql[i+2] == '\r'):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			comment.WriteString(sql[i : i+end])
+			i += end - 1
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql) - i - 2
+			}
+			text := sql[i : i+2+end]
+			comment.WriteString(text)
+			// Keep the line count in step with the source.
+			for range strings.Count(text, "\n") {
+				flush()
+			}
+			i += 2 + end + 1
+		default:
+			code.WriteByte(c)
+		}
+	}
+	flush()
+	return lines
+}
@@ -0,0 +1,92 @@
+package statement
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSuppressions(t *testing.T) {
+	ct, err := ParseCreateTable(`-- spirit-lint:ignore name_case
+CREATE TABLE t1 (
+  id bigint unsigned NOT NULL AUTO_INCREMENT,
+  balance float DEFAULT NULL, -- spirit-lint:ignore has_float
+  -- spirit-lint:ignore has_timestamp, zero_date
+  created_at timestamp NOT NULL,
+  note varchar(255) DEFAULT '-- spirit-lint:ignore not_a_comment',
+  ` + "`weird``name`" + ` int, # spirit-lint:ignore reserved_words
+  PRIMARY KEY (id), /* spirit-lint:ignore primary_key */
+  KEY ` + "`idx_balance`" + ` (balance), -- spirit-lint:ignore redundant_indexes
+  UNIQUE KEY uk_note (note) -- spirit-lint:ignore redundant_indexes
+) ENGINE=InnoDB`)
+	require.NoError(t, err)
+	require.Equal(t, []Suppression{
+		{Linters: []string{"name_case"}},
+		{Linters: []string{"has_float"}, Column: stringPtr("balance")},
+		{Linters: []string{"has_timestamp", "zero_date"}, Column: stringPtr("created_at")},
+		{Linters: []string{"reserved_words"}, Column: stringPtr("weird`name")},
+		{Linters: []string{"primary_key"}, Index: stringPtr("PRIMARY")},
+		{Linters: []string{"redundant_indexes"}, Index: stringPtr("idx_balance")},
+		{Linters: []string{"redundant_indexes"}, Index: stringPtr("uk_note")},
+	}, ct.Suppressions)
+}
+
+func TestParseSuppressions_Unattached(t *testing.T) {
+	// Directives which do not attach to a named element are ignored,
+	// rather than suppressing the linter for the whole table.
+	ct, err := ParseCreateTable(`CREATE TABLE t1 ( -- spirit-lint:ignore name_case
+  id int NOT NULL,
+  a int,
+  PRIMARY KEY (id),
+  KEY (a), -- spirit-lint:ignore redundant_indexes
+  -- spirit-lint:ignore has_fk
+  CONSTRAINT FOREIGN KEY (a) REFERENCES t2 (id)
+) ENGINE=InnoDB -- spirit-lint:ignore has_float`)
+	require.NoError(t, err)
+	require.Equal(t, []Suppression{{Linters: []string{"name_case"}}}, ct.Suppressions)
+}
+
+func TestParseSuppressions_CommentAttributes(t *testing.T) {
+	ct, err := ParseCreateTable(`CREATE TABLE t1 (
+  id bigint unsigned NOT NULL AUTO_INCREMENT,
+  balance float DEFAULT NULL COMMENT 'legacy spirit-lint:ignore has_float',
+  PRIMARY KEY (id),
+  KEY idx_balance (balance) COMMENT 'spirit-lint:ignore redundant_indexes'
+) ENGINE=InnoDB COMMENT='spirit-lint:ignore name_case'`)
+	require.NoError(t, err)
+	require.Equal(t, []Suppression{
+		{Linters: []string{"has_float"}, Column: stringPtr("balance")},
+		{Linters: []string{"redundant_indexes"}, Index: stringPtr("idx_balance")},
+		{Linters: []string{"name_case"}},
+	}, ct.Suppressions)
+
+	// The AbstractStatement path also keeps suppressions.
+	stmts, err := New(`CREATE TABLE t2 (
+  id int NOT NULL, -- spirit-lint:ignore primary_key
+  PRIMARY KEY (id)
+)`)
+	require.NoError(t, err)
+	ct, err = stmts[0].ParseCreateTable()
+	require.NoError(t, err)
+	require.Equal(t, []Suppression{{Linters: []string{"primary_key"}, Column: stringPtr("id")}}, ct.Suppressions)
+
+	ct, err = ParseCreateTable(`CREATE TABLE t3 (id int NOT NULL PRIMARY KEY)`)
+	require.NoError(t, err)
+	require.Empty(t, ct.Suppressions)
+}
+
+func TestSuppressionMatches(t *testing.T) {
+	table := Suppression{Linters: []string{"has_float"}}
+	require.True(t, table.Matches("has_float", nil, nil, nil))
+	require.True(t, table.Matches("HAS_FLOAT", stringPtr("balance"), nil, nil))
+	require.False(t, table.Matches("has_timestamp", nil, nil, nil))
+
+	column := Suppression{Linters: []string{"has_float", "zero_date"}, Column: stringPtr("balance")}
+	require.True(t, column.Matches("zero_date", stringPtr("Balance"), nil, nil))
+	require.False(t, column.Matches("has_float", stringPtr("price"), nil, nil))
+	require.False(t, column.Matches("has_float", nil, nil, nil))
+
+	index := Suppression{Linters: []string{"redundant_indexes"}, Index: stringPtr("idx_a")}
+	require.True(t, index.Matches("redundant_indexes", nil, stringPtr("idx_a"), nil))
+	require.False(t, index.Matches("redundant_indexes", nil, stringPtr("idx_b"), nil))
+}
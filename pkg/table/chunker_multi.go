@@ -4,17 +4,47 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"slices"
 	"sync"
 	"time"
 )
 
+// MultiChunkerConfig controls how a multi-chunker schedules its tables.
+// The zero value schedules all tables concurrently by least progress.
+type MultiChunkerConfig struct {
+	// Priorities maps a table name to its priority. Chunks are always
+	// dispensed from the table with the highest priority that has chunks
+	// remaining; tables with equal priority are balanced by least progress.
+	// Tables which are not in the map have priority 0.
+	Priorities map[string]int
+	// MaxConcurrentTables caps how many tables can be in progress at once.
+	// A table is in progress from its first chunk until it is read. Once
+	// the cap is reached, no new table is started until one of the tables
+	// in progress is read. Zero means no limit.
+	MaxConcurrentTables int
+}
+
+// Validate returns an error if a priority is set for a table which is not
+// one of tables. Priorities are matched by name, so a typo would otherwise
+// silently leave the table at priority 0.
+func (c MultiChunkerConfig) Validate(tables []*TableInfo) error {
+	for _, name := range slices.Sorted(maps.Keys(c.Priorities)) {
+		if !slices.ContainsFunc(tables, func(t *TableInfo) bool { return t.TableName == name }) {
+			return fmt.Errorf("table priority set for table %q, which is not being copied", name)
+		}
+	}
+	return nil
+}
+
 // multiChunker wraps multiple chunkers and distributes Next() calls
-// to the chunker that has made the least progress
+// to the chunker with the highest priority that has made the least progress
 type multiChunker struct {
 	sync.Mutex
 
 	chunkers map[string]Chunker // map of table name to chunker for quick lookup
+	config   MultiChunkerConfig
+	started  map[string]bool // tables which have dispensed at least one chunk
 	isOpen   bool
 }
 
@@ -22,6 +52,12 @@ var _ Chunker = &multiChunker{}
 
 // NewMultiChunker creates a new multi-chunker that wraps multiple chunkers
 func NewMultiChunker(c ...Chunker) Chunker {
+	return NewMultiChunkerWithConfig(MultiChunkerConfig{}, c...)
+}
+
+// NewMultiChunkerWithConfig creates a new multi-chunker that wraps multiple
+// chunkers and schedules them according to config.
+func NewMultiChunkerWithConfig(config MultiChunkerConfig, c ...Chunker) Chunker {
 	if len(c) == 0 {
 		return nil
 	}
@@ -40,6 +76,8 @@ func NewMultiChunker(c ...Chunker) Chunker {
 	}
 	return &multiChunker{
 		chunkers: chunkers,
+		config:   config,
+		started:  make(map[string]bool, len(chunkers)),
 	}
 }
 
@@ -102,6 +140,7 @@ func (m *multiChunker) Reset() error {
 		return ErrChunkerNotOpen
 	}
 
+	clear(m.started)
 	var errs []error
 	for name, chunker := range m.chunkers {
 		if err := chunker.Reset(); err != nil {
@@ -114,7 +153,8 @@ func (m *multiChunker) Reset() error {
 	return nil
 }
 
-// Next returns the next chunk from the chunker that has made the least progress (by percentage)
+// Next returns the next chunk from the chunker with the highest priority
+// that has made the least progress (by percentage)
 func (m *multiChunker) Next() (*Chunk, error) {
 	m.Lock()
 	defer m.Unlock()
@@ -123,20 +163,30 @@ func (m *multiChunker) Next() (*Chunk, error) {
 		return nil, ErrTableNotOpen
 	}
 
-	// Find the chunker with the least progress (lowest percentage)
+	// If the maximum number of tables are already in progress,
+	// only those tables can be picked.
+	onlyStarted := m.config.MaxConcurrentTables > 0 && m.tablesInProgress() >= m.config.MaxConcurrentTables
+
+	// Find the chunker with the highest priority and least progress (lowest percentage)
 	// Only consider chunkers that are not complete (IsRead() == false)
 	var selectedChunker Chunker
+	var selectedKey string
+	var maxPriority int
 	var minProgressPercent float64
 	var maxTotalRows uint64
 	var hasActiveChunkers = false
 	var firstCandidate = true
 
-	for _, chunker := range m.chunkers {
+	for key, chunker := range m.chunkers {
 		if chunker.IsRead() {
 			continue // skip chunkers that are done
 		}
+		if onlyStarted && !m.started[key] {
+			continue // skip tables that would exceed MaxConcurrentTables
+		}
 
 		hasActiveChunkers = true
+		priority := m.priority(chunker)
 		rowsCopied, _, totalRowsExpected := chunker.Progress()
 
 		// Calculate progress percentage
@@ -150,13 +200,17 @@ func (m *multiChunker) Next() (*Chunk, error) {
 		// If it's our first candidate, we select it.
 		// Then we could potentially pick a different candidate as we range through
 		// the chunkers. We'll pick a better candidate if:
-		// - It has a lower progress percentage
-		// - If percentages are equal, it has more total rows expected
-		if firstCandidate || progressPercent < minProgressPercent ||
-			(progressPercent == minProgressPercent && totalRowsExpected > maxTotalRows) {
+		// - It has a higher priority
+		// - If priorities are equal, it has a lower progress percentage
+		// - If percentages are also equal, it has more total rows expected
+		if firstCandidate || priority > maxPriority ||
+			(priority == maxPriority && progressPercent < minProgressPercent) ||
+			(priority == maxPriority && progressPercent == minProgressPercent && totalRowsExpected > maxTotalRows) {
+			maxPriority = priority
 			minProgressPercent = progressPercent
 			maxTotalRows = totalRowsExpected
 			selectedChunker = chunker
+			selectedKey = key
 			firstCandidate = false
 		}
 	}
@@ -165,9 +219,31 @@ func (m *multiChunker) Next() (*Chunk, error) {
 	if !hasActiveChunkers || selectedChunker == nil {
 		return nil, ErrTableIsRead
 	}
+	m.started[selectedKey] = true
 	return selectedChunker.Next()
 }
 
+// tablesInProgress returns the number of tables that have been started
+// but are not yet read. The caller must hold the lock.
+func (m *multiChunker) tablesInProgress() int {
+	var n int
+	for key, chunker := range m.chunkers {
+		if m.started[key] && !chunker.IsRead() {
+			n++
+		}
+	}
+	return n
+}
+
+// priority returns the configured priority of a chunker's table.
+func (m *multiChunker) priority(chunker Chunker) int {
+	tables := chunker.Tables()
+	if len(tables) == 0 {
+		return 0
+	}
+	return m.config.Priorities[tables[0].TableName]
+}
+
 // Feedback forwards feedback to the appropriate chunker based on the chunk's table
 func (m *multiChunker) Feedback(chunk *Chunk, duration time.Duration, actualRows uint64) {
 	m.Lock()
@@ -244,10 +320,12 @@ func (m *multiChunker) OpenAtWatermark(watermark string) error {
 	// Open each chunker - either at watermark if available, or from scratch if not
 	for tableName, chunker := range m.chunkers {
 		if tableWatermark, hasWatermark := watermarks[tableName]; hasWatermark {
-			// Table has a watermark, resume from checkpoint
+			// Table has a watermark, resume from checkpoint. It counts as
+			// in progress, so that MaxConcurrentTables finishes it first.
 			if err := chunker.OpenAtWatermark(tableWatermark); err != nil {
 				return fmt.Errorf("could not open chunker for table %q at watermark: %w", tableName, err)
 			}
+			m.started[tableName] = true
 		} else {
 			// Table doesn't have a watermark (wasn't ready when checkpoint was saved)
 			// Start from scratch
@@ -137,6 +137,99 @@ func TestMultiChunkerProgressBasedSelection(t *testing.T) {
 	})
 }
 
+func TestMultiChunkerPriorities(t *testing.T) {
+	big := NewMockChunker("big", 100000)
+	small1 := NewMockChunker("small1", 2000)
+	small2 := NewMockChunker("small2", 3000)
+
+	chunker := NewMultiChunkerWithConfig(MultiChunkerConfig{
+		Priorities: map[string]int{"small1": 10, "small2": 5},
+	}, big, small1, small2)
+	require.NoError(t, chunker.Open())
+	defer func() {
+		require.NoError(t, chunker.Close())
+	}()
+
+	// Tables are fully dispensed in priority order, even though
+	// the big table has made the least progress.
+	var order []string
+	for {
+		chunk, err := chunker.Next()
+		if errors.Is(err, ErrTableIsRead) {
+			break
+		}
+		require.NoError(t, err)
+		if len(order) == 0 || order[len(order)-1] != chunk.Table.TableName {
+			order = append(order, chunk.Table.TableName)
+		}
+	}
+	require.Equal(t, []string{"small1", "small2", "big"}, order)
+}
+
+func TestMultiChunkerConfigValidate(t *testing.T) {
+	tables := []*TableInfo{{TableName: "big"}, {TableName: "small1"}}
+	require.NoError(t, MultiChunkerConfig{}.Validate(tables))
+	require.NoError(t, MultiChunkerConfig{Priorities: map[string]int{"small1": 10}}.Validate(tables))
+
+	err := MultiChunkerConfig{Priorities: map[string]int{"small1": 10, "smal2": 5}}.Validate(tables)
+	require.ErrorContains(t, err, `table priority set for table "smal2"`)
+}
+
+func TestMultiChunkerMaxConcurrentTables(t *testing.T) {
+	mock1 := NewMockChunker("table1", 3000)
+	mock2 := NewMockChunker("table2", 3000)
+	mock3 := NewMockChunker("table3", 3000)
+
+	chunker := NewMultiChunkerWithConfig(MultiChunkerConfig{MaxConcurrentTables: 2}, mock1, mock2, mock3)
+	require.NoError(t, chunker.Open())
+	defer func() {
+		require.NoError(t, chunker.Close())
+	}()
+
+	// The first two chunks start two different tables (least progress).
+	seen := map[string]bool{}
+	for range 2 {
+		chunk, err := chunker.Next()
+		require.NoError(t, err)
+		seen[chunk.Table.TableName] = true
+	}
+	require.Len(t, seen, 2)
+
+	// Until one of them is read, the third table is not started,
+	// even though it has made the least progress.
+	for range 3 {
+		chunk, err := chunker.Next()
+		require.NoError(t, err)
+		require.True(t, seen[chunk.Table.TableName])
+	}
+	// Draining the rest eventually starts the third table, but only
+	// once one of the first two tables has been read.
+	mocks := map[string]*MockChunker{"table1": mock1, "table2": mock2, "table3": mock3}
+	var startedThird bool
+	for {
+		chunk, err := chunker.Next()
+		if errors.Is(err, ErrTableIsRead) {
+			break
+		}
+		require.NoError(t, err)
+		if !startedThird && !seen[chunk.Table.TableName] {
+			startedThird = true
+			var readCount int
+			for name := range seen {
+				if mocks[name].IsRead() {
+					readCount++
+				}
+			}
+			require.Equal(t, 1, readCount)
+		}
+	}
+	require.True(t, startedThird)
+
+	// Reset forgets which tables were started.
+	require.NoError(t, chunker.Reset())
+	require.Empty(t, chunker.(*multiChunker).started)
+}
+
 func TestMultiChunkerIsRead(t *testing.T) {
 	mock1 := NewMockChunker("table1", 1000)
 	mock2 := NewMockChunker("table2", 2000)
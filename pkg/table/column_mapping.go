@@ -96,6 +96,16 @@ func (m *ColumnMapping) ColumnsSlice() (sourceColumns, targetColumns []string) {
 // The CAST type always comes from the target table's type definition.
 // When there are no renames, both expressions are identical.
 func (m *ColumnMapping) ChecksumExprs() (source, target string, err error) {
+	sourceExprs, targetExprs, err := m.ChecksumColumnExprs()
+	if err != nil {
+		return "", "", err
+	}
+	return strings.Join(sourceExprs, ", "), strings.Join(targetExprs, ", "), nil
+}
+
+// ChecksumColumnExprs is like ChecksumExprs, but returns the expression
+// for each column separately, so that columns can be compared one by one.
+func (m *ColumnMapping) ChecksumColumnExprs() (source, target []string, err error) {
 	sourceExprs := make([]string, len(m.sourceColumns))
 	targetExprs := make([]string, len(m.targetColumns))
 	for i := range m.sourceColumns {
@@ -105,16 +115,16 @@ func (m *ColumnMapping) ChecksumExprs() (source, target string, err error) {
 		// For target: both SQL reference and type lookup use the new column name.
 		srcCast, err := m.targetTable.wrapCastTypeAs(m.sourceColumns[i], m.targetColumns[i])
 		if err != nil {
-			return "", "", err
+			return nil, nil, err
 		}
 		tgtCast, err := m.targetTable.wrapCastType(m.targetColumns[i])
 		if err != nil {
-			return "", "", err
+			return nil, nil, err
 		}
 		sourceExprs[i] = "IFNULL(" + srcCast + ",''), ISNULL(`" + m.sourceColumns[i] + "`)"
 		targetExprs[i] = "IFNULL(" + tgtCast + ",''), ISNULL(`" + m.targetColumns[i] + "`)"
 	}
-	return strings.Join(sourceExprs, ", "), strings.Join(targetExprs, ", "), nil
+	return sourceExprs, targetExprs, nil
 }
 
 // SourceColumnIndices returns the indices into sourceTable.NonGeneratedColumns
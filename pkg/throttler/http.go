@@ -0,0 +1,182 @@
+package throttler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/block/spirit/pkg/utils"
+)
+
+// appPlaceholder can be used in the check URL to place the app name in the
+// path, as freno expects (e.g. http://freno:9777/check/{app}/mysql/main).
+// If the URL does not contain it, the app name is sent as the "app" query
+// parameter instead, as the Vitess tablet throttler expects
+// (e.g. http://tablet:15000/throttler/check).
+const appPlaceholder = "{app}"
+
+// DefaultHTTPCheckInterval is how often the HTTP throttler checks the
+// throttling service when no interval is given.
+const DefaultHTTPCheckInterval = 1 * time.Second
+
+// HTTP is a throttler which delegates the decision to an external
+// throttling service such as freno or the Vitess tablet throttler. The
+// service is polled in the background: a 200 response means the copy may
+// proceed, and a 429 (or 417, which freno uses) means it should throttle.
+type HTTP struct {
+	checkURL string
+	interval time.Duration
+	client   *http.Client
+	logger   *slog.Logger
+
+	isThrottled atomic.Bool
+	isClosed    atomic.Bool
+}
+
+var _ Throttler = (*HTTP)(nil)
+
+// NewHTTPThrottler returns a Throttler that polls checkURL on behalf of
+// appName every interval. If interval is zero, DefaultHTTPCheckInterval
+// is used.
+func NewHTTPThrottler(checkURL, appName string, interval time.Duration, logger *slog.Logger) (*HTTP, error) {
+	if checkURL == "" {
+		return nil, errors.New("http throttler requires a check URL")
+	}
+	if appName == "" {
+		return nil, errors.New("http throttler requires an app name")
+	}
+	if interval < 0 {
+		return nil, errors.New("http throttler requires a non-negative check interval")
+	}
+	if interval == 0 {
+		interval = DefaultHTTPCheckInterval
+	}
+	fullURL, err := buildCheckURL(checkURL, appName)
+	if err != nil {
+		return nil, err
+	}
+	return &HTTP{
+		checkURL: fullURL,
+		interval: interval,
+		// A check should never take longer than the interval between checks,
+		// but allow a floor for very short intervals.
+		client: &http.Client{Timeout: max(interval, time.Second)},
+		logger: logger,
+	}, nil
+}
+
+// buildCheckURL substitutes the app name into the path, or adds it as a
+// query parameter.
+func buildCheckURL(checkURL, appName string) (string, error) {
+	usePlaceholder := strings.Contains(checkURL, appPlaceholder)
+	if usePlaceholder {
+		checkURL = strings.ReplaceAll(checkURL, appPlaceholder, url.PathEscape(appName))
+	}
+	u, err := url.Parse(checkURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid throttler check URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid throttler check URL %q: scheme must be http or https", checkURL)
+	}
+	if !usePlaceholder {
+		q := u.Query()
+		q.Set("app", appName)
+		u.RawQuery = q.Encode()
+	}
+	return u.String(), nil
+}
+
+// Open performs an initial check and then polls the service every interval.
+func (h *HTTP) Open(ctx context.Context) error {
+	if err := h.UpdateLag(ctx); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if h.isClosed.Load() {
+					return
+				}
+				if err := h.UpdateLag(ctx); err != nil {
+					h.logger.Error("error checking throttling service", "error", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (h *HTTP) Close() error {
+	h.isClosed.Store(true)
+	h.client.CloseIdleConnections()
+	return nil
+}
+
+func (h *HTTP) IsThrottled() bool {
+	return h.isThrottled.Load()
+}
+
+// BlockWait blocks until the service stops throttling, or up to 60s
+// to allow some progress to be made. It respects context cancellation.
+func (h *HTTP) BlockWait(ctx context.Context) {
+	timer := time.NewTimer(blockWaitInterval)
+	defer timer.Stop()
+
+	for range 60 {
+		if !h.isThrottled.Load() {
+			return
+		}
+		timer.Reset(blockWaitInterval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			// Continue checking
+		}
+	}
+	h.logger.Warn("http throttler timed out", "url", h.checkURL)
+}
+
+// UpdateLag checks the service and updates the throttled state. If the
+// service can not be reached or returns an unexpected status, the previous
+// state is kept and an error is returned.
+func (h *HTTP) UpdateLag(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.checkURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not reach throttling service: %w", err)
+	}
+	defer utils.CloseAndLog(resp.Body)
+	_, _ = io.Copy(io.Discard, resp.Body) // drain so the connection can be reused
+
+	var throttled bool
+	switch resp.StatusCode {
+	case http.StatusOK:
+		throttled = false
+	case http.StatusTooManyRequests, http.StatusExpectationFailed:
+		throttled = true
+	default:
+		return fmt.Errorf("unexpected status from throttling service: %s", resp.Status)
+	}
+	prev := h.isThrottled.Swap(throttled)
+	if throttled && !prev {
+		h.logger.Warn("throttling service requested throttling", "url", h.checkURL, "status", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package throttler
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newStubService returns a stub throttling service which responds with
+// the status stored in code, and records the last request URL.
+func newStubService(t *testing.T, code *atomic.Int32, lastURL *atomic.Value) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastURL.Store(r.URL.String())
+		w.WriteHeader(int(code.Load()))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHTTPThrottler(t *testing.T) {
+	var code atomic.Int32
+	var lastURL atomic.Value
+	code.Store(http.StatusOK)
+	srv := newStubService(t, &code, &lastURL)
+
+	h, err := NewHTTPThrottler(srv.URL+"/throttler/check", "spirit", 10*time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, h.Open(t.Context()))
+	require.False(t, h.IsThrottled())
+	require.Equal(t, "/throttler/check?app=spirit", lastURL.Load())
+
+	code.Store(http.StatusTooManyRequests)
+	require.Eventually(t, h.IsThrottled, time.Second, 5*time.Millisecond)
+
+	code.Store(http.StatusOK)
+	require.Eventually(t, func() bool { return !h.IsThrottled() }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, h.Close())
+}
+
+func TestHTTPThrottler_AppPlaceholder(t *testing.T) {
+	var code atomic.Int32
+	var lastURL atomic.Value
+	code.Store(http.StatusExpectationFailed)
+	srv := newStubService(t, &code, &lastURL)
+
+	h, err := NewHTTPThrottler(srv.URL+"/check/{app}/mysql/main", "my app", time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, h.UpdateLag(t.Context()))
+	require.True(t, h.IsThrottled()) // 417 is freno's throttled response
+	require.Equal(t, "/check/my%20app/mysql/main", lastURL.Load())
+}
+
+func TestHTTPThrottler_UnexpectedStatusKeepsState(t *testing.T) {
+	var code atomic.Int32
+	var lastURL atomic.Value
+	code.Store(http.StatusTooManyRequests)
+	srv := newStubService(t, &code, &lastURL)
+
+	h, err := NewHTTPThrottler(srv.URL, "spirit", time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, h.UpdateLag(t.Context()))
+	require.True(t, h.IsThrottled())
+
+	code.Store(http.StatusInternalServerError)
+	require.ErrorContains(t, h.UpdateLag(t.Context()), "500")
+	require.True(t, h.IsThrottled())
+
+	// A service which is down fails Open.
+	srv.Close()
+	h, err = NewHTTPThrottler(srv.URL, "spirit", time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.ErrorContains(t, h.Open(t.Context()), "could not reach throttling service")
+}
+
+func TestHTTPThrottler_BlockWait(t *testing.T) {
+	prev := blockWaitInterval
+	blockWaitInterval = 10 * time.Millisecond
+	t.Cleanup(func() { blockWaitInterval = prev })
+
+	var code atomic.Int32
+	var lastURL atomic.Value
+	code.Store(http.StatusTooManyRequests)
+	srv := newStubService(t, &code, &lastURL)
+
+	h, err := NewHTTPThrottler(srv.URL, "spirit", 10*time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	multi := NewMultiThrottler(h, &Noop{})
+	require.NoError(t, multi.Open(t.Context()))
+	require.True(t, multi.IsThrottled())
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		code.Store(http.StatusOK)
+	}()
+	start := time.Now()
+	multi.BlockWait(t.Context())
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+	require.False(t, multi.IsThrottled())
+	require.NoError(t, multi.Close())
+}
+
+func TestNewHTTPThrottler_RejectsBadInputs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	_, err := NewHTTPThrottler("", "spirit", time.Second, logger)
+	require.ErrorContains(t, err, "requires a check URL")
+	_, err = NewHTTPThrottler("http://localhost/check", "", time.Second, logger)
+	require.ErrorContains(t, err, "requires an app name")
+	_, err = NewHTTPThrottler("http://localhost/check", "spirit", -time.Second, logger)
+	require.ErrorContains(t, err, "non-negative check interval")
+	_, err = NewHTTPThrottler("localhost:1234/check", "spirit", time.Second, logger)
+	require.ErrorContains(t, err, "invalid throttler check URL")
+
+	h, err := NewHTTPThrottler("http://localhost/check?store=main", "spirit", 0, logger)
+	require.NoError(t, err)
+	require.Equal(t, DefaultHTTPCheckInterval, h.interval)
+	require.Equal(t, "http://localhost/check?app=spirit&store=main", h.checkURL)
+}
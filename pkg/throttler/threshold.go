@@ -0,0 +1,219 @@
+package throttler
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// threadsRunningQuery reads Threads_running from performance_schema.
+	// VARIABLE_VALUE is a string column, so it is cast to keep the scan simple.
+	threadsRunningQuery = `SELECT CAST(VARIABLE_VALUE AS UNSIGNED)
+	FROM performance_schema.global_status
+	WHERE VARIABLE_NAME = 'Threads_running'`
+
+	// historyListLengthQuery reads the InnoDB history list length (HLL).
+	// The trx_rseg_history_len counter is enabled by default.
+	historyListLengthQuery = `SELECT COUNT
+	FROM information_schema.INNODB_METRICS
+	WHERE NAME = 'trx_rseg_history_len'`
+)
+
+// Threshold is a throttler that polls a single server metric and throttles
+// while the most recent value is at or above a configured limit. It is the
+// basis for throttlers which only need "is this number too high?", such as
+// Threads_running, history list length and checkpoint age.
+type Threshold struct {
+	name   string
+	limit  int64
+	sample func(ctx context.Context) (int64, error)
+	logger *slog.Logger
+
+	current  atomic.Int64
+	isClosed atomic.Bool
+}
+
+var _ Throttler = (*Threshold)(nil)
+
+// NewThreadsRunningThrottler returns a Throttler that throttles while
+// Threads_running on db is at or above maxThreads. Note that the value
+// includes Spirit's own connections, so maxThreads should leave room for
+// the copier and applier threads.
+func NewThreadsRunningThrottler(db *sql.DB, maxThreads int64, logger *slog.Logger) (*Threshold, error) {
+	if db == nil {
+		return nil, errors.New("threads-running throttler requires a non-nil DB")
+	}
+	return newThreshold("threads_running", maxThreads, func(ctx context.Context) (int64, error) {
+		var v int64
+		if err := db.QueryRowContext(ctx, threadsRunningQuery).Scan(&v); err != nil {
+			return 0, fmt.Errorf("could not read Threads_running, check that performance_schema is enabled: %w", err)
+		}
+		return v, nil
+	}, logger)
+}
+
+// NewHistoryListLengthThrottler returns a Throttler that throttles while the
+// InnoDB history list length on db is at or above maxLength. A long history
+// list means purge is falling behind, typically because of long-running
+// read views, and slows down reads for the whole server.
+func NewHistoryListLengthThrottler(db *sql.DB, maxLength int64, logger *slog.Logger) (*Threshold, error) {
+	if db == nil {
+		return nil, errors.New("history-list-length throttler requires a non-nil DB")
+	}
+	return newThreshold("history_list_length", maxLength, func(ctx context.Context) (int64, error) {
+		var v int64
+		if err := db.QueryRowContext(ctx, historyListLengthQuery).Scan(&v); err != nil {
+			return 0, fmt.Errorf("could not read trx_rseg_history_len from INNODB_METRICS: %w", err)
+		}
+		return v, nil
+	}, logger)
+}
+
+// NewCheckpointAgeThrottler returns a Throttler that throttles while the
+// InnoDB checkpoint age (the distance in bytes between the current LSN
+// and the last checkpoint) on db is at or above maxBytes. As the checkpoint
+// age approaches the redo log capacity InnoDB has to flush aggressively,
+// which stalls writes.
+func NewCheckpointAgeThrottler(db *sql.DB, maxBytes int64, logger *slog.Logger) (*Threshold, error) {
+	if db == nil {
+		return nil, errors.New("checkpoint-age throttler requires a non-nil DB")
+	}
+	return newThreshold("checkpoint_age", maxBytes, func(ctx context.Context) (int64, error) {
+		var typ, name, status string
+		if err := db.QueryRowContext(ctx, "SHOW ENGINE INNODB STATUS").Scan(&typ, &name, &status); err != nil {
+			return 0, fmt.Errorf("could not read InnoDB status, check that the user has the PROCESS privilege: %w", err)
+		}
+		return parseCheckpointAge(status)
+	}, logger)
+}
+
+// parseCheckpointAge returns the checkpoint age from the LOG section
+// of SHOW ENGINE INNODB STATUS.
+func parseCheckpointAge(status string) (int64, error) {
+	var lsn, checkpoint int64 = -1, -1
+	scanner := bufio.NewScanner(strings.NewReader(status))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		var err error
+		switch {
+		case strings.HasPrefix(line, "Log sequence number"):
+			lsn, err = parseLastInt(line)
+		case strings.HasPrefix(line, "Last checkpoint at"):
+			checkpoint, err = parseLastInt(line)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if lsn < 0 || checkpoint < 0 {
+		return 0, errors.New("could not find log sequence number and last checkpoint in InnoDB status")
+	}
+	return lsn - checkpoint, nil
+}
+
+func parseLastInt(line string) (int64, error) {
+	fields := strings.Fields(line)
+	v, err := strconv.ParseInt(fields[len(fields)-1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse InnoDB status line %q: %w", line, err)
+	}
+	return v, nil
+}
+
+func newThreshold(name string, limit int64, sample func(ctx context.Context) (int64, error), logger *slog.Logger) (*Threshold, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("%s throttler requires a positive threshold", name)
+	}
+	return &Threshold{
+		name:   name,
+		limit:  limit,
+		sample: sample,
+		logger: logger,
+	}, nil
+}
+
+// Open takes an initial sample and then polls the metric every loopInterval.
+func (t *Threshold) Open(ctx context.Context) error {
+	if err := t.UpdateLag(ctx); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(loopInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if t.isClosed.Load() {
+					return
+				}
+				if err := t.UpdateLag(ctx); err != nil {
+					t.logger.Error("error sampling throttler metric", "metric", t.name, "error", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (t *Threshold) Close() error {
+	t.isClosed.Store(true)
+	return nil
+}
+
+func (t *Threshold) IsThrottled() bool {
+	return t.current.Load() >= t.limit
+}
+
+// BlockWait blocks until the metric is below the threshold, or up to 60s
+// to allow some progress to be made. It respects context cancellation.
+func (t *Threshold) BlockWait(ctx context.Context) {
+	timer := time.NewTimer(blockWaitInterval)
+	defer timer.Stop()
+
+	for range 60 {
+		if !t.IsThrottled() {
+			return
+		}
+		timer.Reset(blockWaitInterval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			// Continue checking
+		}
+	}
+	t.logger.Warn("throttler timed out", "metric", t.name, "value", t.current.Load(), "threshold", t.limit)
+}
+
+// UpdateLag samples the metric and updates the throttled state.
+// It is named to match the Throttler interface.
+func (t *Threshold) UpdateLag(ctx context.Context) error {
+	v, err := t.sample(ctx)
+	if err != nil {
+		return err
+	}
+	t.applySample(v)
+	return nil
+}
+
+// applySample updates state from a single observation. Split out so tests
+// can drive it without a server.
+func (t *Threshold) applySample(v int64) {
+	prev := t.current.Swap(v)
+	if v >= t.limit && prev < t.limit {
+		t.logger.Warn("metric exceeds threshold, throttling",
+			"metric", t.name,
+			"value", v,
+			"threshold", t.limit)
+	}
+}
@@ -0,0 +1,182 @@
+package throttler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/block/spirit/pkg/testutils"
+	"github.com/block/spirit/pkg/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestThreshold(t *testing.T, limit int64, sample func(ctx context.Context) (int64, error)) *Threshold {
+	t.Helper()
+	th, err := newThreshold("test_metric", limit, sample, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	return th
+}
+
+func TestThreshold_ApplySample(t *testing.T) {
+	th := newTestThreshold(t, 100, nil)
+	require.False(t, th.IsThrottled())
+
+	th.applySample(99)
+	require.False(t, th.IsThrottled())
+
+	th.applySample(100) // at the threshold is throttled
+	require.True(t, th.IsThrottled())
+
+	th.applySample(500)
+	require.True(t, th.IsThrottled())
+
+	th.applySample(10)
+	require.False(t, th.IsThrottled())
+}
+
+func TestThreshold_UpdateLag(t *testing.T) {
+	value := int64(5)
+	th := newTestThreshold(t, 10, func(ctx context.Context) (int64, error) {
+		return value, nil
+	})
+	require.NoError(t, th.UpdateLag(t.Context()))
+	require.False(t, th.IsThrottled())
+
+	value = 50
+	require.NoError(t, th.UpdateLag(t.Context()))
+	require.True(t, th.IsThrottled())
+	require.Equal(t, int64(50), th.current.Load())
+}
+
+func TestThreshold_UpdateLagError(t *testing.T) {
+	th := newTestThreshold(t, 10, func(ctx context.Context) (int64, error) {
+		return 0, errors.New("sample failed")
+	})
+	require.ErrorContains(t, th.UpdateLag(t.Context()), "sample failed")
+	require.ErrorContains(t, th.Open(t.Context()), "sample failed")
+}
+
+func TestThreshold_BlockWaitReturnsWhenThrottlingClears(t *testing.T) {
+	prev := blockWaitInterval
+	blockWaitInterval = 10 * time.Millisecond
+	t.Cleanup(func() { blockWaitInterval = prev })
+
+	th := newTestThreshold(t, 10, nil)
+	th.applySample(20)
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		th.applySample(1)
+	}()
+
+	start := time.Now()
+	th.BlockWait(t.Context())
+	elapsed := time.Since(start)
+	require.GreaterOrEqual(t, elapsed, 20*time.Millisecond)
+	require.Less(t, elapsed, 500*time.Millisecond)
+}
+
+func TestThreshold_BlockWaitRespectsContext(t *testing.T) {
+	th := newTestThreshold(t, 10, nil)
+	th.applySample(20)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	start := time.Now()
+	th.BlockWait(ctx)
+	require.Less(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestNewThreadsRunningThrottler_RejectsBadInputs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	_, err := NewThreadsRunningThrottler(nil, 10, logger)
+	require.ErrorContains(t, err, "non-nil DB")
+
+	db, openErr := sql.Open("mysql", "user:pass@tcp(127.0.0.1:0)/db")
+	require.NoError(t, openErr)
+	defer utils.CloseAndLog(db)
+
+	_, err = NewThreadsRunningThrottler(db, 0, logger)
+	require.ErrorContains(t, err, "positive threshold")
+}
+
+func TestThreadsRunningThrottler(t *testing.T) {
+	db, err := sql.Open("mysql", testutils.DSN())
+	require.NoError(t, err)
+	defer utils.CloseAndLog(db)
+
+	th, err := NewThreadsRunningThrottler(db, 10000, slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, th.Open(t.Context()))
+	require.False(t, th.IsThrottled())
+	require.Positive(t, th.current.Load()) // at least our own connection is running
+	require.NoError(t, th.Close())
+}
+
+func TestParseCheckpointAge(t *testing.T) {
+	status := `
+---
+LOG
+---
+Log sequence number          1234567890
+Log buffer assigned up to    1234567890
+Log buffer completed up to   1234567890
+Log written up to            1234567890
+Log flushed up to            1234567890
+Added dirty pages up to      1234567890
+Pages flushed up to          1234000000
+Last checkpoint at           1230000000
+Log minimum file id is       3
+Log maximum file id is       4
+`
+	age, err := parseCheckpointAge(status)
+	require.NoError(t, err)
+	require.Equal(t, int64(4567890), age)
+
+	_, err = parseCheckpointAge("no log section here")
+	require.ErrorContains(t, err, "could not find log sequence number")
+
+	_, err = parseCheckpointAge("Log sequence number abc\nLast checkpoint at 1")
+	require.ErrorContains(t, err, "could not parse")
+}
+
+func TestNewHistoryListLengthAndCheckpointAgeThrottlers_RejectBadInputs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	_, err := NewHistoryListLengthThrottler(nil, 10, logger)
+	require.ErrorContains(t, err, "non-nil DB")
+	_, err = NewCheckpointAgeThrottler(nil, 10, logger)
+	require.ErrorContains(t, err, "non-nil DB")
+
+	db, openErr := sql.Open("mysql", "user:pass@tcp(127.0.0.1:0)/db")
+	require.NoError(t, openErr)
+	defer utils.CloseAndLog(db)
+
+	_, err = NewHistoryListLengthThrottler(db, -1, logger)
+	require.ErrorContains(t, err, "positive threshold")
+	_, err = NewCheckpointAgeThrottler(db, 0, logger)
+	require.ErrorContains(t, err, "positive threshold")
+}
+
+func TestHistoryListLengthAndCheckpointAgeThrottlers(t *testing.T) {
+	db, err := sql.Open("mysql", testutils.DSN())
+	require.NoError(t, err)
+	defer utils.CloseAndLog(db)
+
+	hll, err := NewHistoryListLengthThrottler(db, 1<<40, slog.Default())
+	require.NoError(t, err)
+	ckpt, err := NewCheckpointAgeThrottler(db, 1<<40, slog.Default())
+	require.NoError(t, err)
+
+	throttler := NewMultiThrottler(hll, ckpt)
+	require.NoError(t, throttler.Open(t.Context()))
+	require.False(t, throttler.IsThrottled())
+	require.GreaterOrEqual(t, ckpt.current.Load(), int64(0))
+	require.NoError(t, throttler.Close())
+}